@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/db"
+	"github.com/shenikar/order-service/internal/export"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+)
+
+// order_export выгружает заказы из БД сервиса в CSV, NDJSON или Parquet.
+// Подключение к БД берется из той же конфигурации, что и у order_service.
+//
+//	go run ./cmd/order_export -format parquet -from 2025-01-01T00:00:00Z -to 2025-02-01T00:00:00Z -o january.parquet
+//	go run ./cmd/order_export -format ndjson -delivery-service meest > meest.ndjson
+func main() {
+	format := flag.String("format", export.FormatCSV, "export format: csv, ndjson, parquet")
+	output := flag.String("o", "", "output file (default: stdout)")
+	customerID := flag.String("customer-id", "", "export only orders of the customer")
+	deliveryService := flag.String("delivery-service", "", "export only orders of the delivery service")
+	from := flag.String("from", "", "export orders created at or after (RFC3339)")
+	to := flag.String("to", "", "export orders created before (RFC3339)")
+	includePII := flag.Bool("include-pii", false, "export recipient name, phone, address and email")
+	flag.Parse()
+
+	filter := models.OrderFilter{CustomerID: *customerID, DeliveryService: *deliveryService}
+	var err error
+	if *from != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, *from); err != nil {
+			log.Fatalf("Invalid -from: %v", err)
+		}
+	}
+	if *to != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, *to); err != nil {
+			log.Fatalf("Invalid -to: %v", err)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	dbConn, err := db.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer dbConn.Close()
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer out.Close()
+	}
+	buffered := bufio.NewWriter(out)
+
+	w, err := export.NewWriter(*format, buffered, export.Options{IncludePII: *includePII})
+	if err != nil {
+		log.Fatalf("Failed to create writer: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var count int
+	repo := repository.NewOrderRepository(dbConn)
+	err = repo.ExportOrders(ctx, filter, func(order *models.Order) error {
+		count++
+		return w.Write(order)
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		log.Fatalf("Export failed after %d orders: %v", count, err)
+	}
+
+	log.Printf("Exported %d orders", count)
+}
@@ -26,7 +26,7 @@ func randomOrder() *models.Order {
 		DeliveryService: gofakeit.Company(),
 		ShardKey:        gofakeit.DigitN(1),
 		SmID:            gofakeit.Number(0, 100),
-		DateCreated:     now.Format(time.RFC3339),
+		DateCreated:     now.UTC().Truncate(time.Second),
 		Delivery: models.Delivery{
 			Name:    gofakeit.Name(),
 			Phone:   gofakeit.Phone(),
@@ -81,6 +81,7 @@ func main() {
 	}()
 
 	for i := 0; i < 5; i++ {
+		// Заказ отправляется в JSON v1 без заголовков: суммы в основных единицах валюты
 		order := randomOrder()
 		data, err := json.Marshal(order)
 		if err != nil {
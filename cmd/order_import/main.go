@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/cache"
+	"github.com/shenikar/order-service/internal/db"
+	"github.com/shenikar/order-service/internal/importer"
+	"github.com/shenikar/order-service/internal/repository"
+	"github.com/shenikar/order-service/internal/service"
+)
+
+// order_import загружает заказы из файлов NDJSON (в том числе сжатых gzip) в БД сервиса.
+// Каждый заказ проверяется теми же правилами, что и сообщения из Kafka, и сохраняется
+// пачками в одной транзакции. Отклоненные строки с причинами пишутся в отчет NDJSON.
+//
+//	go run ./cmd/order_import -batch-size 1000 -rejects rejects.ndjson orders-2025-01.ndjson.gz
+func main() {
+	batchSize := flag.Int("batch-size", 500, "orders saved in one transaction")
+	rejects := flag.String("rejects", "rejects.ndjson", "rejects report file")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("Usage: order_import [flags] file.ndjson[.gz] ...")
+	}
+	if *batchSize <= 0 {
+		log.Fatal("Invalid -batch-size: must be positive")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	dbConn, err := db.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer dbConn.Close()
+
+	cacheOrder, err := cache.NewCache(cfg.Cache.Capacity, time.Duration(cfg.Cache.TTL)*time.Minute)
+	if err != nil {
+		log.Fatalf("Error creating cache: %v", err)
+	}
+
+	orderService := service.NewOrderService(repository.NewOrderRepository(dbConn), cacheOrder,
+		service.WithDedupMode(cfg.Dedup.Mode))
+
+	reportFile, err := os.Create(*rejects)
+	if err != nil {
+		log.Fatalf("Failed to create rejects report: %v", err)
+	}
+	defer reportFile.Close()
+	report := bufio.NewWriter(reportFile)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	im := importer.New(orderService, *batchSize, report)
+	for _, path := range flag.Args() {
+		if err = im.ImportFile(ctx, path); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = im.Flush()
+	}
+	if flushErr := report.Flush(); err == nil {
+		err = flushErr
+	}
+
+	stats := im.Stats()
+	if err != nil {
+		log.Fatalf("Import failed after %d orders (imported %d, rejected %d): %v",
+			stats.Read, stats.Imported, stats.Rejected, err)
+	}
+	log.Printf("Read %d orders: imported %d, rejected %d (see %s)",
+		stats.Read, stats.Imported, stats.Rejected, *rejects)
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,14 +13,21 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
-	kf "github.com/segmentio/kafka-go"
 	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/auth"
 	"github.com/shenikar/order-service/internal/cache"
 	"github.com/shenikar/order-service/internal/db"
+	"github.com/shenikar/order-service/internal/feed"
+	"github.com/shenikar/order-service/internal/grpcserver"
+	"github.com/shenikar/order-service/internal/health"
 	"github.com/shenikar/order-service/internal/kafka"
+	"github.com/shenikar/order-service/internal/logging"
+	"github.com/shenikar/order-service/internal/projection"
+	"github.com/shenikar/order-service/internal/ratelimit"
 	"github.com/shenikar/order-service/internal/repository"
 	"github.com/shenikar/order-service/internal/server"
 	"github.com/shenikar/order-service/internal/service"
+	"github.com/shenikar/order-service/internal/tracing"
 )
 
 // @title Order Service API
@@ -28,25 +35,46 @@ import (
 // @description API для управления заказами
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT в формате "Bearer <token>"
 func main() {
 	// Загружаем конфигурацию
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Error loading config: %v", err)
+		logging.Fatal("Error loading config", "error", err)
+	}
+
+	// Настраиваем структурированный журнал до запуска остальных компонентов
+	logger, err := logging.New(os.Stdout, cfg.Log)
+	if err != nil {
+		logging.Fatal("Error configuring logger", "error", err)
+	}
+	slog.SetDefault(logger)
+
+	// Настраиваем трассировку до запуска компонентов, которые создают span'ы
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		logging.Fatal("Error initializing tracing", "error", err)
 	}
 
 	// Инициализируем Kafka DLQ writer
 	kafka.InitDLQWriter(cfg)
 
 	// Выполняем миграции базы данных
-	if err := runMigrations(cfg); err != nil {
-		log.Fatalf("Error running migrations: %v", err)
+	schemaVersion, err := runMigrations(cfg)
+	if err != nil {
+		logging.Fatal("Error running migrations", "error", err)
 	}
 
 	// Подключаемся к БД
 	dbConn, err := db.Connect(cfg)
 	if err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
+		logging.Fatal("Error connecting to database", "error", err)
 	}
 
 	// Создаем компоненты приложения
@@ -54,56 +82,122 @@ func main() {
 
 	cacheOrder, err := cache.NewCache(cfg.Cache.Capacity, time.Duration(cfg.Cache.TTL)*time.Minute)
 	if err != nil {
-		log.Fatalf("Error creating cache: %v", err)
+		logging.Fatal("Error creating cache", "error", err)
 	}
 
-	orderService := service.NewOrderService(repo, cacheOrder)
+	orderService := service.NewOrderService(repo, cacheOrder, service.WithDedupMode(cfg.Dedup.Mode))
 
-	// Восстанавливаем кэш из БД
-	if err := orderService.RestoreCacheFromDB(); err != nil {
-		log.Println("Warning: Failed to restore cache from DB:", err)
-	} else {
-		log.Println("Cache restored from database successfully")
-	}
+	// Восстанавливаем кэш из БД в фоне; до завершения /readyz сообщает, что сервис не готов
+	go func() {
+		if err := orderService.RestoreCacheFromDB(); err != nil {
+			slog.Warn("Failed to restore cache from DB", "error", err)
+		} else {
+			slog.Info("Cache restored from database successfully")
+		}
+	}()
 
 	// Создаем context для Kafka consumer
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Заполняем отпечатки заказов, сохраненных до появления поиска дублей
+	go func() {
+		processed, err := orderService.BackfillFingerprints(ctx)
+		if err != nil {
+			slog.Warn("Failed to backfill order fingerprints", "processed", processed, "error", err)
+		} else if processed > 0 {
+			slog.Info("Order fingerprints backfilled", "processed", processed)
+		}
+	}()
+
 	// Запускаем Kafka consumer
 	consumer := kafka.StartConsumer(ctx, cfg, orderService)
 
+	// Запускаем публикацию событий из outbox
+	outboxRepo := repository.NewOutboxRepository(dbConn)
+	outboxRelay := kafka.StartOutboxRelay(ctx, cfg, outboxRepo)
+
+	// Запускаем ленту новых заказов для /orders/stream
+	orderFeed := feed.NewHub(outboxRepo, time.Duration(cfg.Feed.PollInterval)*time.Millisecond)
+	go orderFeed.Run(ctx)
+
+	// Запускаем фоновое обновление срезов статистики для /stats/orders
+	statsService := service.NewStatsService(repository.NewStatsRepository(dbConn),
+		time.Duration(cfg.Stats.RollupRefreshInterval)*time.Second)
+	go statsService.Run(ctx)
+
+	searchService := service.NewSearchService(repository.NewSearchRepository(dbConn))
+
+	// Аутентификация клиентов HTTP и gRPC API
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		logging.Fatal("Failed to configure authentication", "error", err)
+	}
+	if !authenticator.Enabled() {
+		slog.Warn("Authentication is disabled, order data is available without credentials")
+	}
+
+	// Доступ ролей к персональным данным в ответах
+	piiPolicy, err := projection.ParsePolicy(cfg.Privacy.PIIPolicy)
+	if err != nil {
+		logging.Fatal("Failed to parse PII policy", "error", err)
+	}
+
+	// Ограничения частоты запросов по группам маршрутов
+	rateLimits, err := ratelimit.ParseLimits(cfg.RateLimit.Limits)
+	if err != nil {
+		logging.Fatal("Failed to parse rate limits", "error", err)
+	}
+	// Неудачные попытки аутентификации ограничиваются общим лимитом для HTTP и gRPC
+	authLimiter := rateLimits.AuthLimiter()
+
+	// Проверки готовности для /readyz
+	checker := health.NewChecker(time.Duration(cfg.Health.CheckTimeout) * time.Millisecond)
+	checker.Register("database", health.Database(dbConn))
+	checker.Register("migrations", health.Migrations(dbConn, schemaVersion))
+	checker.Register("kafka", consumer.HealthCheck(int64(cfg.Health.KafkaMaxLag)))
+	checker.Register("cache", health.Warmup(orderService.CacheWarmupStatus))
+
 	// Запускаем HTTP сервер
-	server.StartServer(cfg, orderService)
+	server.StartServer(cfg, checker, authenticator, piiPolicy, rateLimits, authLimiter, orderService, statsService, searchService, orderFeed)
+
+	// Запускаем gRPC сервер
+	grpcserver.StartServer(cfg, authenticator, authLimiter, orderService)
 
 	// Корректное завершение работы приложения
-	gracefulShutdown(dbConn, consumer, cancel)
+	gracefulShutdown(dbConn, consumer, outboxRelay, cancel, shutdownTracing)
 }
 
-func runMigrations(cfg *config.Config) error {
+// runMigrations применяет миграции и возвращает версию схемы БД
+func runMigrations(cfg *config.Config) (uint, error) {
 	dbURL := cfg.GetDatabaseURL()
 
 	m, err := migrate.New(
 		"file://migrations", dbURL)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return err
+		return 0, err
+	}
+
+	version, _, err := m.Version()
+	if err != nil {
+		return 0, err
 	}
 
-	log.Println("Database migrations applied successfully")
-	return nil
+	slog.Info("Database migrations applied successfully", "schema_version", version)
+	return version, nil
 }
 
 // Graceful shutdown
-func gracefulShutdown(dbConn *sqlx.DB, consumer *kf.Reader, cancel context.CancelFunc) {
+func gracefulShutdown(dbConn *sqlx.DB, consumer *kafka.Consumer, outboxRelay *kafka.OutboxRelay, cancel context.CancelFunc, shutdownTracing func(context.Context) error) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutdown signal received")
+	slog.Info("Shutdown signal received")
 
 	// Отменяем context для Kafka
 	cancel()
@@ -114,26 +208,41 @@ func gracefulShutdown(dbConn *sqlx.DB, consumer *kf.Reader, cancel context.Cance
 
 	// Завершаем Kafka consumer
 	kafka.StopConsumer(consumer, nil)
-	log.Println("Kafka consumer stopped")
+	slog.Info("Kafka consumer stopped")
+
+	// Завершаем публикацию событий из outbox
+	kafka.StopOutboxRelay(outboxRelay)
+	slog.Info("Outbox relay stopped")
 
 	// Завершаем DLQ writer
 	if kafka.DLQWriter != nil {
 		if err := kafka.DLQWriter.Close(); err != nil {
-			log.Printf("Error closing DLQ writer: %v", err)
+			slog.Error("Error closing DLQ writer", "error", err)
 		}
-		log.Println("DLQ writer closed")
+		slog.Info("DLQ writer closed")
 	}
 
 	// Завершаем HTTP сервер
 	if err := server.ShutdownServer(ctx); err != nil {
-		log.Printf("Error shutting down server: %v", err)
+		slog.Error("Error shutting down server", "error", err)
+	}
+
+	// Завершаем gRPC сервер
+	grpcserver.ShutdownServer(ctx)
+
+	// Отправляем оставшиеся span'ы с отдельным timeout: долгие соединения (SSE, gRPC-потоки)
+	// могут израсходовать timeout завершения серверов
+	tracingCtx, tracingCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer tracingCancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Error("Error shutting down tracing", "error", err)
 	}
 
 	// Закрываем БД
 	if err := dbConn.Close(); err != nil {
-		log.Printf("Error closing DB connection: %v", err)
+		slog.Error("Error closing DB connection", "error", err)
 	}
-	log.Println("Database connection closed")
+	slog.Info("Database connection closed")
 
-	log.Println("Shutdown completed successfully")
+	slog.Info("Shutdown completed successfully")
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/shenikar/order-service/internal/codec"
+	"github.com/shenikar/order-service/internal/compat"
+)
+
+// schema_compat сравнивает предлагаемую JSON Schema заказа с текущей
+// и завершается с кодом 1, если найдены несовместимые изменения.
+//
+//	go run ./cmd/schema_compat -proposed new_order.schema.json
+//	go run ./cmd/schema_compat -current internal/codec/schemas/order.v1.schema.json -proposed new.json
+func main() {
+	currentPath := flag.String("current", "", "path to the current schema (default: embedded latest JSON schema)")
+	proposedPath := flag.String("proposed", "", "path to the proposed schema")
+	flag.Parse()
+
+	if *proposedPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		currentData []byte
+		err         error
+	)
+	if *currentPath == "" {
+		currentData, err = codec.JSONSchema(codec.LatestJSONVersion)
+	} else {
+		currentData, err = os.ReadFile(*currentPath)
+	}
+	if err != nil {
+		log.Fatalf("Failed to read current schema: %v", err)
+	}
+
+	proposedData, err := os.ReadFile(*proposedPath)
+	if err != nil {
+		log.Fatalf("Failed to read proposed schema: %v", err)
+	}
+
+	var current, proposed map[string]any
+	if err := json.Unmarshal(currentData, &current); err != nil {
+		log.Fatalf("Invalid current schema: %v", err)
+	}
+	if err := json.Unmarshal(proposedData, &proposed); err != nil {
+		log.Fatalf("Invalid proposed schema: %v", err)
+	}
+
+	changes := compat.Compare(current, proposed)
+	if len(changes) == 0 {
+		fmt.Println("Schemas are compatible, no changes found")
+		return
+	}
+
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+
+	if compat.HasBreaking(changes) {
+		fmt.Println("Breaking changes found")
+		os.Exit(1)
+	}
+	fmt.Println("No breaking changes found")
+}
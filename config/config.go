@@ -2,18 +2,33 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Kafka    KafkaConfig
-	Server   ServerConfig
-	Cache    CacheConfig
+	Database  DatabaseConfig
+	Kafka     KafkaConfig
+	Server    ServerConfig
+	GRPC      GRPCConfig
+	Cache     CacheConfig
+	Outbox    OutboxConfig
+	Dedup     DedupConfig
+	Feed      FeedConfig
+	Stats     StatsConfig
+	API       APIConfig
+	Auth      AuthConfig
+	Privacy   PrivacyConfig
+	RateLimit RateLimitConfig
+	Health    HealthConfig
+	Tracing   TracingConfig
+	Log       LogConfig
 }
 
 type DatabaseConfig struct {
@@ -26,16 +41,25 @@ type DatabaseConfig struct {
 }
 
 type KafkaConfig struct {
-	Brokers  []string
-	Topic    string
-	GroupID  string
-	DLQTopic string
+	Brokers       []string
+	Topic         string
+	GroupID       string // consumer group, распределяющая партиции между экземплярами сервиса
+	DLQTopic      string
+	EventsTopic   string
+	MessageFormat string // формат сообщений без заголовка content-type: json, protobuf, avro
 }
 
 type ServerConfig struct {
 	Host              string
 	Port              string
 	ReadHeaderTimeout int
+	// TrustedProxies - адреса и подсети прокси, которым доверяется X-Forwarded-For.
+	// Пустой список - IP клиента берется из адреса соединения
+	TrustedProxies []string
+}
+
+type GRPCConfig struct {
+	Port string
 }
 
 type CacheConfig struct {
@@ -43,10 +67,75 @@ type CacheConfig struct {
 	Capacity int
 }
 
+type DedupConfig struct {
+	Mode string // flag - сохранять дубли с пометкой, skip - пропускать
+}
+
+type FeedConfig struct {
+	PollInterval int // интервал опроса новых заказов для ленты в миллисекундах
+}
+
+type StatsConfig struct {
+	RollupRefreshInterval int // интервал обновления срезов статистики в секундах, 0 - срезы не используются
+}
+
+// APIConfig - сроки вывода из эксплуатации устаревших версий HTTP API.
+// Нулевое время означает, что дата не объявлена
+type APIConfig struct {
+	UnversionedDeprecatedAt time.Time // маршруты без префикса версии устарели с этой даты
+	UnversionedSunset       time.Time // и будут отключены в эту дату
+	V1DeprecatedAt          time.Time
+	V1Sunset                time.Time
+}
+
+// AuthConfig - аутентификация клиентов HTTP и gRPC API
+type AuthConfig struct {
+	Enabled     bool
+	APIKeys     string // ключи вида name:role:sha256hex через запятую
+	JWKSFile    string // JWKS с открытыми ключами подписи JWT; пустой - JWT не принимаются
+	JWTIssuer   string // ожидаемый iss, пустой - не проверяется
+	JWTAudience string // ожидаемый aud, пустой - не проверяется
+}
+
+// PrivacyConfig - доступ клиентов к персональным данным в заказах
+type PrivacyConfig struct {
+	PIIPolicy string // уровни доступа ролей вида role=level через запятую: full, masked, tracking
+}
+
+// RateLimitConfig - ограничение частоты запросов клиентов по группам маршрутов
+type RateLimitConfig struct {
+	Limits string // ограничения вида group=rate:burst через запятую, rate - запросов в секунду
+}
+
+// HealthConfig - проверки готовности сервиса для /readyz
+type HealthConfig struct {
+	CheckTimeout int // ограничение времени каждой проверки в миллисекундах
+	KafkaMaxLag  int // отставание чтения партиции в сообщениях, выше которого kafka в состоянии degraded, 0 - не проверяется
+}
+
+// TracingConfig - экспорт трассировки OpenTelemetry
+type TracingConfig struct {
+	Exporter    string // otlp, stdout или none
+	Endpoint    string // URL OTLP/gRPC-коллектора, например http://otel-collector:4317
+	ServiceName string
+}
+
+// LogConfig - журнал сервиса
+type LogConfig struct {
+	Level  string // debug, info, warn или error
+	Format string // json или text
+}
+
+type OutboxConfig struct {
+	PollInterval  int // интервал опроса outbox в миллисекундах
+	BatchSize     int
+	RetentionDays int // срок хранения опубликованных событий в днях, 0 - хранить всегда
+}
+
 // Загрузка конфигурации из .env файла
 func LoadConfig() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
-		log.Println("Error loading .env file")
+		slog.Warn("Error loading .env file", "error", err)
 	}
 
 	config := &Config{
@@ -59,24 +148,107 @@ func LoadConfig() (*Config, error) {
 			SSLMode:  os.Getenv("DB_SSLMODE"),
 		},
 		Kafka: KafkaConfig{
-			Brokers:  []string{os.Getenv("KAFKA_BROKERS")},
-			Topic:    os.Getenv("KAFKA_TOPIC"),
-			GroupID:  os.Getenv("KAFKA_GROUP_ID"),
-			DLQTopic: os.Getenv("KAFKA_DLQ_TOPIC"),
+			Brokers:       []string{os.Getenv("KAFKA_BROKERS")},
+			Topic:         os.Getenv("KAFKA_TOPIC"),
+			GroupID:       getEnvDefault("KAFKA_GROUP_ID", "order"),
+			DLQTopic:      os.Getenv("KAFKA_DLQ_TOPIC"),
+			EventsTopic:   os.Getenv("KAFKA_EVENTS_TOPIC"),
+			MessageFormat: os.Getenv("KAFKA_MESSAGE_FORMAT"),
 		},
 		Server: ServerConfig{
 			Host:              os.Getenv("SERVER_HOST"),
 			Port:              os.Getenv("SERVER_PORT"),
 			ReadHeaderTimeout: mustParseEnvInt("SERVER_READ_HEADER_TIMEOUT"),
+			TrustedProxies:    parseEnvList("TRUSTED_PROXIES"),
+		},
+		GRPC: GRPCConfig{
+			Port: getEnvDefault("GRPC_PORT", "9090"),
 		},
 		Cache: CacheConfig{
 			TTL:      mustParseEnvInt("CACHE_TTL"),
 			Capacity: mustParseEnvInt("CACHE_CAPACITY"),
 		},
+		Outbox: OutboxConfig{
+			PollInterval:  parseEnvIntDefault("OUTBOX_POLL_INTERVAL_MS", 1000),
+			BatchSize:     parseEnvIntDefault("OUTBOX_BATCH_SIZE", 100),
+			RetentionDays: parseEnvIntDefault("OUTBOX_RETENTION_DAYS", 7),
+		},
+		Dedup: DedupConfig{
+			Mode: getEnvDefault("DEDUP_MODE", "flag"),
+		},
+		Feed: FeedConfig{
+			PollInterval: parseEnvIntDefault("FEED_POLL_INTERVAL_MS", 500),
+		},
+		Stats: StatsConfig{
+			RollupRefreshInterval: parseEnvIntDefault("STATS_ROLLUP_REFRESH_INTERVAL_SEC", 0),
+		},
+		API: APIConfig{
+			// Маршруты без префикса устарели с появлением /v1
+			UnversionedDeprecatedAt: parseEnvDateDefault("API_UNVERSIONED_DEPRECATED_AT", "2026-10-19"),
+			UnversionedSunset:       parseEnvDateDefault("API_UNVERSIONED_SUNSET", ""),
+			V1DeprecatedAt:          parseEnvDateDefault("API_V1_DEPRECATED_AT", ""),
+			V1Sunset:                parseEnvDateDefault("API_V1_SUNSET", ""),
+		},
+		Auth: AuthConfig{
+			Enabled:     getEnvDefault("AUTH_ENABLED", "true") == "true",
+			APIKeys:     os.Getenv("AUTH_API_KEYS"),
+			JWKSFile:    os.Getenv("AUTH_JWKS_FILE"),
+			JWTIssuer:   os.Getenv("AUTH_JWT_ISSUER"),
+			JWTAudience: os.Getenv("AUTH_JWT_AUDIENCE"),
+		},
+		Privacy: PrivacyConfig{
+			PIIPolicy: getEnvDefault("PII_POLICY", "support=full,analyst=masked,partner=tracking"),
+		},
+		Health: HealthConfig{
+			CheckTimeout: parseEnvIntDefault("HEALTH_CHECK_TIMEOUT_MS", 2000),
+			KafkaMaxLag:  parseEnvIntDefault("HEALTH_KAFKA_MAX_LAG", 10000),
+		},
+		Log: LogConfig{
+			Level:  getEnvDefault("LOG_LEVEL", "info"),
+			Format: getEnvDefault("LOG_FORMAT", "json"),
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnvDefault("OTEL_TRACES_EXPORTER", "none"),
+			Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+			ServiceName: getEnvDefault("OTEL_SERVICE_NAME", "order-service"),
+		},
+		RateLimit: RateLimitConfig{
+			Limits: getEnvDefault("RATE_LIMITS", "orders=20:40,batch=20:1000,reports=5:10,search=5:10,export=0.1:3,auth=0.1:10"),
+		},
+	}
+
+	if err := config.Dedup.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Server.validate(); err != nil {
+		return nil, err
 	}
 	return config, nil
 }
 
+// validate проверяет, что доверенные прокси заданы IP-адресами или подсетями CIDR
+func (c ServerConfig) validate() error {
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err == nil {
+			continue
+		}
+		if net.ParseIP(proxy) == nil {
+			return fmt.Errorf("invalid TRUSTED_PROXIES entry %q: expected IP address or CIDR", proxy)
+		}
+	}
+	return nil
+}
+
+// validate проверяет, что режим обработки дублей известен сервису
+func (c DedupConfig) validate() error {
+	switch c.Mode {
+	case "flag", "skip":
+		return nil
+	default:
+		return fmt.Errorf("invalid DEDUP_MODE %q: expected flag or skip", c.Mode)
+	}
+}
+
 // GetDatabaseUrl формирует строку подключения к базе данных
 func (c *Config) GetDatabaseURL() string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
@@ -93,6 +265,19 @@ func (c *Config) GetServerAddress() string {
 	return fmt.Sprintf("%s:%s", c.Server.Host, c.Server.Port)
 }
 
+// GetGRPCAddress формирует адрес gRPC сервера
+func (c *Config) GetGRPCAddress() string {
+	return fmt.Sprintf("%s:%s", c.Server.Host, c.GRPC.Port)
+}
+
+// getEnvDefault возвращает значение переменной окружения или def, если она не задана
+func getEnvDefault(key, def string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return def
+}
+
 // mustParseEnvInt парсит int из env, паникует если не удалось
 func mustParseEnvInt(key string) int {
 	val := os.Getenv(key)
@@ -102,3 +287,39 @@ func mustParseEnvInt(key string) int {
 	}
 	return v
 }
+
+// parseEnvList парсит список значений через запятую из env, пустые значения пропускаются
+func parseEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseEnvIntDefault парсит int из env, возвращает def если переменная не задана
+func parseEnvIntDefault(key string, def int) int {
+	if os.Getenv(key) == "" {
+		return def
+	}
+	return mustParseEnvInt(key)
+}
+
+// parseEnvDateDefault парсит дату в формате YYYY-MM-DD (UTC) или RFC3339 из env,
+// использует def если переменная не задана, паникует если не удалось. Пустая дата дает нулевое время
+func parseEnvDateDefault(key, def string) time.Time {
+	val := getEnvDefault(key, def)
+	if val == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.DateOnly, val); err == nil {
+		return t
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		panic("env " + key + " must be date (YYYY-MM-DD or RFC3339), got: " + val)
+	}
+	return t
+}
@@ -35,19 +35,789 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/brands/{brand}/orders": {
             "get": {
-                "description": "Возвращает статус сервиса (ok)",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу заказов с товарами от новых к старым, в которых есть товары бренда",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Заказы с товарами бренда",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Бренд",
+                        "name": "brand",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Размер страницы (не больше 500)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Токен следующей страницы",
+                        "name": "page_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные не раньше (RFC3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные раньше (RFC3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/brands/{brand}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает количество проданных единиц и заказов, выручку по валютам,\nраспределение единиц по скидке и по размерам для всех товаров бренда. Дубли заказов не учитываются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Продажи бренда",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Бренд",
+                        "name": "brand",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные не раньше (RFC3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные раньше (RFC3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/livez": {
+            "get": {
+                "description": "Отвечает, пока процесс работает; состояние зависимостей не проверяется.\nПерезапускать сервис имеет смысл, только если не отвечает этот маршрут",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "general"
+                ],
+                "summary": "Проверка жизнеспособности",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/duplicates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает группы заказов, которые ссылаются на одну и ту же платежную транзакцию",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Получить подозрительные дубли заказов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Максимальное количество групп",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DuplicateGroup"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Потоково выгружает заказы, подходящие под фильтр, от новых к старым.\nCSV и Parquet содержат одну строку на товар с развернутыми колонками доставки и платежа.\nИмя, телефон, адрес и email получателя выгружаются только при include_pii=true,\nкоторый доступен только клиентам с полным доступом к данным заказа.\nИтог выгрузки передается в trailer X-Export-Status: ok или error",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.apache.parquet"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Выгрузить заказы",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "parquet"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Формат выгрузки",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только заказы покупателя",
+                        "name": "customer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только заказы службы доставки",
+                        "name": "delivery_service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные не раньше (RFC3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные раньше (RFC3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Выгружать персональные данные получателя",
+                        "name": "include_pii",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отправляет краткие сведения о каждом новом заказе через Server-Sent Events\n(событие order) или WebSocket, если запрос содержит заголовок Upgrade: websocket.\nДля возобновления передайте идентификатор последнего события в Last-Event-ID или last_event_id",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Лента новых заказов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Только заказы покупателя",
+                        "name": "customer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только заказы службы доставки",
+                        "name": "delivery_service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор последнего полученного события",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор последнего полученного события",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/feed.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_uid}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Получает заказ с товарами по уникальному идентификатору.\nФормат ответа выбирается по Accept: JSON (по умолчанию), MessagePack или Protobuf\n(сообщение Order из api/order/v1/order.proto).\nВ /v2 JSON и MessagePack содержат models.OrderV2: время в RFC 3339, суммы - объекты Money.\nДанные заказа ограничены ролью клиента: аналитики получают маскированные телефон, email\nи адрес получателя, партнеры - только трек-номера и статусы (models.OrderTracking).\nПоддерживает условные запросы: ETag вычисляется по содержимому ответа,\nпри совпадении If-None-Match возвращается 304 без тела",
+                "produces": [
+                    "application/json",
+                    "application/x-msgpack",
+                    "application/x-protobuf"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Получить заказ по UID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order UID",
+                        "name": "order_uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag ранее полученного ответа",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "304": {
+                        "description": "Заказ не изменился"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders:batchGet": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает заказы с товарами: сначала ищет их в кэше, а недостающие загружает\nиз базы данных одним запросом. UID, которых нет в сервисе, перечисляются в missing.\nПовторяющиеся UID отбрасываются, заказы возвращаются в порядке запроса.\nДанные заказов ограничены ролью клиента так же, как в GET /orders/{order_uid}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Получить заказы по списку UID",
+                "parameters": [
+                    {
+                        "description": "Список UID заказов (не больше 1000)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchGetOrdersRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchGetOrdersResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{nm_id}/orders": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу заказов с товарами от новых к старым, в которых есть товар с артикулом nm_id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Заказы с товаром",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Артикул товара",
+                        "name": "nm_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Размер страницы (не больше 500)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Токен следующей страницы",
+                        "name": "page_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные не раньше (RFC3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные раньше (RFC3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{nm_id}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает количество проданных единиц и заказов, выручку по валютам,\nраспределение единиц по скидке и по размерам. Дубли заказов не учитываются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Продажи товара",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Артикул товара",
+                        "name": "nm_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные не раньше (RFC3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные раньше (RFC3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Проверяет доступность БД, версию схемы, чтение Kafka и завершение прогрева кэша.\nВозвращает состояние каждого компонента: up, degraded или down.\nСервис готов, если ни один компонент не в состоянии down",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "general"
                 ],
-                "summary": "Проверка состояния сервиса",
+                "summary": "Проверка готовности",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ищет заказы по customer_id, имени, телефону, email, городу и адресу получателя,\nтрек-номерам, названиям и брендам товаров. Слова запроса ищутся как начала слов,\nа также по триграммному сходству, поэтому находятся части слов и слова с опечатками.\nСовпадения в полях заказа обернуты в \u003cmark\u003e\u003c/mark\u003e",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Поиск заказов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Максимальное количество заказов (не больше 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -58,27 +828,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_uid}": {
+        "/stats/orders": {
             "get": {
-                "description": "Получает заказ с товарами по уникальному идентификатору",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает количество заказов, суммы amount, goods_total и delivery_cost\nи среднее число товаров в заказе, сгруппированные по измерениям group_by.\nВалюта всегда входит в группировку, суммы указаны в минорных единицах валюты.\nДубли заказов не учитываются. При группировке по brand заказ учитывается в каждом бренде, товары которого в нем есть.\nЕсли границы периода выровнены по часу и включены почасовые срезы, статистика считается по ним (source=rollup)",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "orders"
+                    "stats"
                 ],
-                "summary": "Получить заказ по UID",
+                "summary": "Статистика заказов",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order UID",
-                        "name": "order_uid",
-                        "in": "path",
-                        "required": true
+                        "default": "day",
+                        "description": "Измерения через запятую: day, hour, currency, delivery_service, bank, provider, region, brand",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные не раньше (RFC3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Заказы, созданные раньше (RFC3339)",
+                        "name": "created_to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.OrderStatsReport"
                         }
                     },
                     "400": {
@@ -90,8 +883,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -104,8 +897,98 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "feed.Event": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "order": {
+                    "$ref": "#/definitions/models.OrderAcceptedEvent"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.Result"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Result": {
+            "type": "object",
+            "properties": {
+                "details": {},
+                "duration_ms": {
+                    "type": "number"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BatchGetOrdersRequest": {
+            "type": "object",
+            "required": [
+                "order_uids"
+            ],
+            "properties": {
+                "order_uids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.BatchGetOrdersResult": {
+            "type": "object",
+            "properties": {
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                }
+            }
+        },
+        "models.CurrencyAmount": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Delivery": {
             "type": "object",
+            "required": [
+                "address",
+                "city",
+                "email",
+                "name",
+                "phone",
+                "zip"
+            ],
             "properties": {
                 "address": {
                     "type": "string"
@@ -130,8 +1013,28 @@ const docTemplate = `{
                 }
             }
         },
+        "models.DuplicateGroup": {
+            "type": "object",
+            "properties": {
+                "order_uids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "transaction": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Item": {
             "type": "object",
+            "required": [
+                "chrt_id",
+                "name",
+                "nm_id",
+                "track_number"
+            ],
             "properties": {
                 "brand": {
                     "type": "string"
@@ -146,13 +1049,15 @@ const docTemplate = `{
                     "type": "integer"
                 },
                 "price": {
-                    "type": "integer"
+                    "type": "integer",
+                    "minimum": 0
                 },
                 "rid": {
                     "type": "string"
                 },
                 "sale": {
-                    "type": "integer"
+                    "type": "integer",
+                    "minimum": 0
                 },
                 "size": {
                     "type": "string"
@@ -161,7 +1066,8 @@ const docTemplate = `{
                     "type": "integer"
                 },
                 "total_price": {
-                    "type": "integer"
+                    "type": "integer",
+                    "minimum": 0
                 },
                 "track_number": {
                     "type": "string"
@@ -170,6 +1076,17 @@ const docTemplate = `{
         },
         "models.Order": {
             "type": "object",
+            "required": [
+                "customer_id",
+                "date_created",
+                "delivery",
+                "entry",
+                "items",
+                "locale",
+                "order_uid",
+                "payment",
+                "track_number"
+            ],
             "properties": {
                 "customer_id": {
                     "type": "string"
@@ -183,6 +1100,9 @@ const docTemplate = `{
                 "delivery_service": {
                     "type": "string"
                 },
+                "duplicate_of": {
+                    "type": "string"
+                },
                 "entry": {
                     "type": "string"
                 },
@@ -218,27 +1138,143 @@ const docTemplate = `{
                 }
             }
         },
-        "models.Payment": {
+        "models.OrderAcceptedEvent": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "integer"
                 },
-                "bank": {
-                    "type": "string"
-                },
                 "currency": {
                     "type": "string"
                 },
                 "custom_fee": {
                     "type": "integer"
                 },
+                "customer_id": {
+                    "type": "string"
+                },
+                "date_created": {
+                    "type": "string"
+                },
+                "delivery_cost": {
+                    "type": "integer"
+                },
+                "delivery_service": {
+                    "type": "string"
+                },
+                "goods_total": {
+                    "type": "integer"
+                },
+                "items_count": {
+                    "type": "integer"
+                },
+                "order_uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderPage": {
+            "type": "object",
+            "properties": {
+                "next_page_token": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                }
+            }
+        },
+        "models.OrderStats": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "avg_items": {
+                    "type": "number"
+                },
+                "bank": {
+                    "type": "string"
+                },
+                "brand": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
                 "delivery_cost": {
                     "type": "integer"
                 },
+                "delivery_service": {
+                    "type": "string"
+                },
                 "goods_total": {
                     "type": "integer"
                 },
+                "orders": {
+                    "type": "integer"
+                },
+                "period": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderStatsReport": {
+            "type": "object",
+            "properties": {
+                "refreshed_at": {
+                    "description": "время обновления срезов для source=rollup",
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderStats"
+                    }
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Payment": {
+            "type": "object",
+            "required": [
+                "currency",
+                "transaction"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "bank": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "custom_fee": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "delivery_cost": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "goods_total": {
+                    "type": "integer",
+                    "minimum": 0
+                },
                 "payment_dt": {
                     "type": "integer"
                 },
@@ -252,6 +1288,107 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.ProductStats": {
+            "type": "object",
+            "properties": {
+                "brand": {
+                    "type": "string"
+                },
+                "nm_id": {
+                    "type": "integer"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CurrencyAmount"
+                    }
+                },
+                "sale_distribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SaleBucket"
+                    }
+                },
+                "sizes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SizeStats"
+                    }
+                },
+                "units": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SaleBucket": {
+            "type": "object",
+            "properties": {
+                "sale": {
+                    "type": "integer"
+                },
+                "units": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SearchHighlight": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "snippet": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SearchResult": {
+            "type": "object",
+            "properties": {
+                "date_created": {
+                    "type": "string"
+                },
+                "highlights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SearchHighlight"
+                    }
+                },
+                "order_uid": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.SizeStats": {
+            "type": "object",
+            "properties": {
+                "size": {
+                    "type": "string"
+                },
+                "units": {
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT в формате \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,183 @@
+// Package auth проверяет учетные данные клиентов HTTP и gRPC API:
+// статические API-ключи из конфигурации и JWT, подписанные ключами из локального JWKS-файла
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shenikar/order-service/config"
+)
+
+// Роли клиентов
+const (
+	RoleSupport = "support" // служба поддержки: полный доступ к заказам
+	RoleAnalyst = "analyst" // аналитики: выгрузки, списки и статистика
+	RolePartner = "partner" // партнеры: только отдельные заказы
+)
+
+// Способы аутентификации
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+	MethodNone   = "none" // аутентификация отключена
+)
+
+var (
+	// ErrNoCredentials возвращается, если запрос не содержит учетных данных
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials возвращается для неизвестного API-ключа или недействительного токена
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Identity - аутентифицированный клиент
+type Identity struct {
+	Subject string   // имя API-ключа или claim sub токена
+	Method  string   // MethodAPIKey, MethodJWT или MethodNone
+	Roles   []string // роли клиента
+}
+
+// HasAnyRole проверяет, есть ли у клиента хотя бы одна из ролей
+func (i *Identity) HasAnyRole(roles ...string) bool {
+	for _, role := range roles {
+		if slices.Contains(i.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+type identityContextKey struct{}
+
+// NewContext возвращает контекст с данными клиента
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// FromContext возвращает данные клиента, сохраненные в контексте
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey{}).(*Identity)
+	return identity, ok
+}
+
+// APIKey - статический API-ключ. Сам ключ не хранится, только его SHA-256 хэш
+type APIKey struct {
+	Name string
+	Role string
+	Hash [sha256.Size]byte
+}
+
+// HashAPIKey возвращает SHA-256 хэш ключа в hex; в таком виде ключи задаются в конфигурации
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseAPIKeys разбирает список ключей вида "name:role:sha256hex,name2:role:sha256hex"
+func ParseAPIKeys(spec string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid api key entry %q: expected name:role:sha256hex", entry)
+		}
+
+		hash, err := hex.DecodeString(parts[2])
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("invalid hash of api key %q: expected hex-encoded sha256", parts[0])
+		}
+		key := APIKey{Name: parts[0], Role: parts[1]}
+		copy(key.Hash[:], hash)
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Authenticator проверяет API-ключи и JWT
+type Authenticator struct {
+	enabled bool
+	apiKeys []APIKey
+	jwt     *JWTVerifier // nil, если JWKS не задан
+}
+
+// New создает Authenticator по конфигурации. Если аутентификация включена,
+// должен быть задан хотя бы один API-ключ или JWKS-файл
+func New(cfg config.AuthConfig) (*Authenticator, error) {
+	if !cfg.Enabled {
+		return &Authenticator{}, nil
+	}
+
+	apiKeys, err := ParseAPIKeys(cfg.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	a := &Authenticator{enabled: true, apiKeys: apiKeys}
+
+	if cfg.JWKSFile != "" {
+		keys, err := LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = NewJWTVerifier(keys, cfg.JWTIssuer, cfg.JWTAudience)
+	}
+
+	if len(a.apiKeys) == 0 && a.jwt == nil {
+		return nil, errors.New("authentication is enabled, but neither api keys nor jwks file are configured")
+	}
+	return a, nil
+}
+
+// Enabled сообщает, включена ли аутентификация
+func (a *Authenticator) Enabled() bool {
+	return a.enabled
+}
+
+// Authenticate проверяет API-ключ или значение заголовка Authorization ("Bearer <jwt>").
+// Если аутентификация отключена, возвращает анонимного клиента без ролей
+func (a *Authenticator) Authenticate(apiKey, authorization string) (*Identity, error) {
+	if !a.enabled {
+		return &Identity{Subject: "anonymous", Method: MethodNone}, nil
+	}
+
+	if apiKey != "" {
+		return a.authenticateAPIKey(apiKey)
+	}
+	if authorization == "" {
+		return nil, ErrNoCredentials
+	}
+
+	scheme, token, ok := strings.Cut(authorization, " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, fmt.Errorf("%w: unsupported authorization scheme", ErrInvalidCredentials)
+	}
+	if a.jwt == nil {
+		return nil, fmt.Errorf("%w: bearer tokens are not accepted", ErrInvalidCredentials)
+	}
+	return a.jwt.Verify(token)
+}
+
+// authenticateAPIKey ищет ключ по хэшу; хэши сравниваются за постоянное время
+func (a *Authenticator) authenticateAPIKey(apiKey string) (*Identity, error) {
+	hash := sha256.Sum256([]byte(apiKey))
+	var found *APIKey
+	for i := range a.apiKeys {
+		if subtle.ConstantTimeCompare(hash[:], a.apiKeys[i].Hash[:]) == 1 {
+			found = &a.apiKeys[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: unknown api key", ErrInvalidCredentials)
+	}
+	return &Identity{Subject: found.Name, Method: MethodAPIKey, Roles: []string{found.Role}}, nil
+}
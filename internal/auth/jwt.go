@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/MicahParks/jwkset"
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtLeeway - допустимое расхождение часов при проверке exp и nbf
+const jwtLeeway = 30 * time.Second
+
+// jwtMethods - принимаемые алгоритмы подписи: только асимметричные, без none и HMAC
+var jwtMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// LoadJWKS читает открытые ключи подписи из JWKS-файла
+func LoadJWKS(path string) (keyfunc.Keyfunc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS разбирает JWKS с ключами RSA, EC и Ed25519. Ключ выбирается по kid,
+// алгоритм токена должен совпадать с alg ключа, если он задан. Ключи шифрования
+// (use=enc) пропускаются
+func ParseJWKS(data []byte) (keyfunc.Keyfunc, error) {
+	var set jwkset.JWKSMarshal
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	ctx := context.Background()
+	storage := jwkset.NewMemoryStorage()
+	count := 0
+	for _, marshal := range set.Keys {
+		if marshal.USE == jwkset.UseEnc {
+			continue
+		}
+		jwk, err := jwkset.NewJWKFromMarshal(marshal, jwkset.JWKMarshalOptions{}, jwkset.JWKValidateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("jwk %q: %w", marshal.KID, err)
+		}
+		switch key := jwk.Key().(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+		case *ecdsa.PublicKey:
+			// jwkset не проверяет, что точка лежит на кривой
+			if _, err := key.ECDH(); err != nil {
+				return nil, fmt.Errorf("jwk %q: invalid ec point", marshal.KID)
+			}
+		default:
+			return nil, fmt.Errorf("jwk %q: unsupported key type %q", marshal.KID, marshal.KTY)
+		}
+		if _, err := storage.KeyRead(ctx, marshal.KID); err == nil {
+			return nil, fmt.Errorf("duplicate jwk kid %q", marshal.KID)
+		}
+		if err := storage.KeyWrite(ctx, jwk); err != nil {
+			return nil, fmt.Errorf("jwk %q: %w", marshal.KID, err)
+		}
+		count++
+	}
+	if count == 0 {
+		return nil, errors.New("jwks contains no signing keys")
+	}
+
+	return keyfunc.New(keyfunc.Options{Ctx: ctx, Storage: storage})
+}
+
+// JWTVerifier проверяет подпись и claims JWT
+type JWTVerifier struct {
+	keys   keyfunc.Keyfunc
+	parser *jwt.Parser
+	now    func() time.Time
+}
+
+// NewJWTVerifier создает JWTVerifier с ключами из JWKS. Пустые issuer и audience не проверяются
+func NewJWTVerifier(keys keyfunc.Keyfunc, issuer, audience string) *JWTVerifier {
+	v := &JWTVerifier{keys: keys, now: time.Now}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(jwtLeeway),
+		jwt.WithTimeFunc(func() time.Time { return v.now() }),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v
+}
+
+// jwtClaims - стандартные claims и роли клиента
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+}
+
+// Verify проверяет подпись ключом из JWKS, exp (обязателен), nbf, iss и aud.
+// Роли клиента берутся из claim roles
+func (v *JWTVerifier) Verify(token string) (*Identity, error) {
+	var claims jwtClaims
+	if _, err := v.parser.ParseWithClaims(token, &claims, v.keys.Keyfunc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no sub", ErrInvalidCredentials)
+	}
+
+	return &Identity{Subject: claims.Subject, Method: MethodJWT, Roles: claims.Roles}, nil
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+type testKeys struct {
+	rsa   *rsa.PrivateKey
+	ec    *ecdsa.PrivateKey
+	ed    ed25519.PrivateKey
+	jwks  []byte
+	other *rsa.PrivateKey // ключ, которого нет в JWKS
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa1", "use": "sig", "alg": "RS256",
+			"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec1", "crv": "P-256",
+			"x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "kid": "ed1", "crv": "Ed25519", "x": b64(edPub)},
+		{"kty": "RSA", "kid": "enc1", "use": "enc", "n": b64(otherKey.N.Bytes()), "e": "AQAB"},
+	}})
+	require.NoError(t, err)
+
+	return testKeys{rsa: rsaKey, ec: ecKey, ed: edKey, jwks: jwks, other: otherKey}
+}
+
+func signToken(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	signed := b64(header) + "." + b64(payload)
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		if alg == "PS256" {
+			signature, err = rsa.SignPSS(rand.Reader, k, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		}
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(k, []byte(signed))
+	}
+	require.NoError(t, err)
+
+	return signed + "." + b64(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "agent-42",
+		"iss":   "https://auth.example.com",
+		"aud":   []string{"order-service"},
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"roles": []string{RoleSupport},
+	}
+}
+
+func newTestVerifier(t *testing.T, keys testKeys) *JWTVerifier {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, keys.jwks, 0o600))
+
+	publicKeys, err := LoadJWKS(path)
+	require.NoError(t, err)
+	stored, err := publicKeys.Storage().KeyReadAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, stored, 3, "encryption keys are skipped")
+
+	v := NewJWTVerifier(publicKeys, "https://auth.example.com", "order-service")
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func TestJWTVerifier_ValidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestVerifier(t, keys)
+
+	tokens := map[string]string{
+		"RS256": signToken(t, "RS256", "rsa1", keys.rsa, validClaims()),
+		"ES256": signToken(t, "ES256", "ec1", keys.ec, validClaims()),
+		"EdDSA": signToken(t, "EdDSA", "ed1", keys.ed, validClaims()),
+	}
+	for alg, token := range tokens {
+		identity, err := v.Verify(token)
+		require.NoError(t, err, alg)
+		assert.Equal(t, &Identity{Subject: "agent-42", Method: MethodJWT, Roles: []string{RoleSupport}}, identity, alg)
+	}
+}
+
+func TestJWTVerifier_InvalidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestVerifier(t, keys)
+
+	withClaim := func(name string, value any) map[string]any {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+	tampered := signToken(t, "RS256", "rsa1", keys.rsa, validClaims())
+	tampered = tampered[:len(tampered)-4] + "AAAA"
+
+	// Подмена алгоритма: HMAC с открытым ключом RSA в качестве секрета
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(validClaims()))
+	hmacToken.Header["kid"] = "rsa1"
+	hmacSigned, err := hmacToken.SignedString(keys.rsa.PublicKey.N.Bytes())
+	require.NoError(t, err)
+
+	tokens := map[string]string{
+		"hmac":            hmacSigned,
+		"expired":         signToken(t, "RS256", "rsa1", keys.rsa, withClaim("exp", testNow.Add(-time.Hour).Unix())),
+		"no exp":          signToken(t, "RS256", "rsa1", keys.rsa, withClaim("exp", nil)),
+		"no sub":          signToken(t, "RS256", "rsa1", keys.rsa, withClaim("sub", nil)),
+		"not yet valid":   signToken(t, "RS256", "rsa1", keys.rsa, withClaim("nbf", testNow.Add(time.Hour).Unix())),
+		"wrong issuer":    signToken(t, "RS256", "rsa1", keys.rsa, withClaim("iss", "https://evil.example.com")),
+		"wrong audience":  signToken(t, "RS256", "rsa1", keys.rsa, withClaim("aud", "billing")),
+		"unknown key":     signToken(t, "RS256", "rsa2", keys.other, validClaims()),
+		"foreign key":     signToken(t, "RS256", "rsa1", keys.other, validClaims()),
+		"alg mismatch":    signToken(t, "PS256", "rsa1", keys.rsa, validClaims()),
+		"wrong key type":  signToken(t, "RS256", "ec1", keys.rsa, validClaims()),
+		"bad signature":   tampered,
+		"alg none":        "eyJhbGciOiJub25lIiwia2lkIjoiZWQxIn0.e30.",
+		"malformed":       "not-a-token",
+		"encryption only": signToken(t, "RS256", "enc1", keys.other, validClaims()),
+	}
+	for name, token := range tokens {
+		_, err := v.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidCredentials, name)
+	}
+}
+
+func TestParseJWKS_Invalid(t *testing.T) {
+	_, err := ParseJWKS([]byte(`{"keys":[]}`))
+	assert.Error(t, err)
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"EC","kid":"k","crv":"P-256","x":"AQ","y":"AQ"}]}`))
+	assert.Error(t, err)
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"oct","kid":"k"}]}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdentityKey - ключ данных клиента в контексте Gin
+const IdentityKey = "auth_identity"
+
+// HeaderAPIKey - заголовок со статическим API-ключом
+const HeaderAPIKey = "X-API-Key"
+
+// Require возвращает middleware, которое пропускает только аутентифицированных клиентов
+// с одной из ролей roles (без roles - с любой ролью). Данные клиента сохраняются
+// в контексте Gin под IdentityKey и в контексте запроса. Если аутентификация отключена,
+// все запросы пропускаются от имени анонимного клиента
+func (a *Authenticator) Require(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := a.Authenticate(c.GetHeader(HeaderAPIKey), c.GetHeader("Authorization"))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="order-service"`)
+			if errors.Is(err, ErrNoCredentials) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+				return
+			}
+			slog.WarnContext(c.Request.Context(), "Rejected credentials", "client_ip", c.ClientIP(), "error", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			return
+		}
+
+		c.Set(IdentityKey, identity)
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), identity))
+
+		if a.enabled && len(roles) > 0 && !identity.HasAnyRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// IdentityFromGin возвращает данные клиента, сохраненные middleware Require
+func IdentityFromGin(c *gin.Context) (*Identity, bool) {
+	identity, ok := c.Get(IdentityKey)
+	if !ok {
+		return nil, false
+	}
+	id, ok := identity.(*Identity)
+	return id, ok
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	a, err := New(config.AuthConfig{
+		Enabled: true,
+		APIKeys: "support-bot:support:" + HashAPIKey("support-secret") + ", partner-x:partner:" + HashAPIKey("partner-secret"),
+	})
+	require.NoError(t, err)
+	return a
+}
+
+func serve(a *Authenticator, headers map[string]string, roles ...string) (*httptest.ResponseRecorder, *Identity) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	var identity *Identity
+	engine.GET("/orders", a.Require(roles...), func(c *gin.Context) {
+		identity, _ = IdentityFromGin(c)
+		fromCtx, _ := FromContext(c.Request.Context())
+		if fromCtx != identity {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	return w, identity
+}
+
+func TestRequire(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	w, identity := serve(a, map[string]string{HeaderAPIKey: "support-secret"}, RoleSupport, RoleAnalyst)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, &Identity{Subject: "support-bot", Method: MethodAPIKey, Roles: []string{RoleSupport}}, identity)
+
+	w, _ = serve(a, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="order-service"`, w.Header().Get("WWW-Authenticate"))
+
+	w, _ = serve(a, map[string]string{HeaderAPIKey: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w, _ = serve(a, map[string]string{"Authorization": "Basic dXNlcjpwYXNz"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// JWKS не задан, поэтому токены не принимаются
+	w, _ = serve(a, map[string]string{"Authorization": "Bearer a.b.c"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w, _ = serve(a, map[string]string{HeaderAPIKey: "partner-secret"}, RoleSupport, RoleAnalyst)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, _ = serve(a, map[string]string{HeaderAPIKey: "partner-secret"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequire_JWT(t *testing.T) {
+	keys := newTestKeys(t)
+	a := &Authenticator{enabled: true, jwt: newTestVerifier(t, keys)}
+
+	claims := validClaims()
+	claims["roles"] = []string{RoleAnalyst}
+	token := signToken(t, "ES256", "ec1", keys.ec, claims)
+
+	w, identity := serve(a, map[string]string{"Authorization": "Bearer " + token}, RoleAnalyst)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "agent-42", identity.Subject)
+	assert.Equal(t, MethodJWT, identity.Method)
+
+	w, _ = serve(a, map[string]string{"Authorization": "Bearer " + token}, RoleSupport)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequire_Disabled(t *testing.T) {
+	a, err := New(config.AuthConfig{Enabled: false})
+	require.NoError(t, err)
+
+	w, identity := serve(a, nil, RoleSupport)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MethodNone, identity.Method)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := New(config.AuthConfig{Enabled: true})
+	assert.Error(t, err, "no credentials configured")
+
+	_, err = New(config.AuthConfig{Enabled: true, APIKeys: "bot:support"})
+	assert.Error(t, err)
+
+	_, err = New(config.AuthConfig{Enabled: true, APIKeys: "bot:support:abcd"})
+	assert.Error(t, err)
+
+	_, err = New(config.AuthConfig{Enabled: true, JWKSFile: "/nonexistent/jwks.json"})
+	assert.Error(t, err)
+}
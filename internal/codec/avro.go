@@ -0,0 +1,182 @@
+package codec
+
+import (
+	"embed"
+	"fmt"
+	"time"
+
+	"github.com/hamba/avro/v2"
+	"github.com/shenikar/order-service/internal/models"
+)
+
+//go:embed schemas/*.avsc
+var avroSchemas embed.FS
+
+// avroSchemaFiles - схемы записи (writer schema) по версиям
+var avroSchemaFiles = map[int]string{
+	1: "schemas/order.v1.avsc",
+	2: "schemas/order.v2.avsc",
+}
+
+// AvroDecoder декодирует бинарные Avro-сообщения (без заголовка контейнера).
+// Версия схемы выбирает writer schema, с которой было закодировано сообщение
+type AvroDecoder struct {
+	schemas map[int]avro.Schema
+}
+
+// NewAvroDecoder создает новый экземпляр AvroDecoder
+func NewAvroDecoder() (*AvroDecoder, error) {
+	d := &AvroDecoder{schemas: make(map[int]avro.Schema, len(avroSchemaFiles))}
+	for version, file := range avroSchemaFiles {
+		data, err := avroSchemas.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read avro schema %s: %w", file, err)
+		}
+		schema, err := avro.Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse avro schema %s: %w", file, err)
+		}
+		d.schemas[version] = schema
+	}
+	return d, nil
+}
+
+// Schema возвращает Avro-схему заданной версии
+func (d *AvroDecoder) Schema(version int) (avro.Schema, bool) {
+	schema, ok := d.schemas[version]
+	return schema, ok
+}
+
+// Decode декодирует Avro-сообщение версии version
+func (d *AvroDecoder) Decode(data []byte, version int) (*models.Order, error) {
+	schema, ok := d.schemas[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: avro v%d", ErrUnsupportedVersion, version)
+	}
+
+	var record orderAvro
+	if err := avro.Unmarshal(schema, data, &record); err != nil {
+		return nil, fmt.Errorf("invalid avro: %w", err)
+	}
+
+	order := record.toModel()
+	if version < minorUnitsAvroVersion {
+		if err := scaleToMinorUnits(order); err != nil {
+			return nil, fmt.Errorf("invalid avro: %w", err)
+		}
+	}
+	return order, nil
+}
+
+// orderAvro - представление заказа в Avro-схемах order.v1 и order.v2, которые
+// различаются только единицами денежных сумм
+type orderAvro struct {
+	OrderUID          string       `avro:"order_uid"`
+	TrackNumber       string       `avro:"track_number"`
+	Entry             string       `avro:"entry"`
+	Locale            string       `avro:"locale"`
+	InternalSignature string       `avro:"internal_signature"`
+	CustomerID        string       `avro:"customer_id"`
+	DeliveryService   string       `avro:"delivery_service"`
+	ShardKey          string       `avro:"shardkey"`
+	SmID              int64        `avro:"sm_id"`
+	DateCreated       time.Time    `avro:"date_created"`
+	OofShard          string       `avro:"oof_shard"`
+	Delivery          deliveryAvro `avro:"delivery"`
+	Payment           paymentAvro  `avro:"payment"`
+	Items             []itemAvro   `avro:"items"`
+}
+
+type deliveryAvro struct {
+	Name    string `avro:"name"`
+	Phone   string `avro:"phone"`
+	Zip     string `avro:"zip"`
+	City    string `avro:"city"`
+	Address string `avro:"address"`
+	Region  string `avro:"region"`
+	Email   string `avro:"email"`
+}
+
+type paymentAvro struct {
+	Transaction  string `avro:"transaction"`
+	RequestID    string `avro:"request_id"`
+	Currency     string `avro:"currency"`
+	Provider     string `avro:"provider"`
+	Amount       int64  `avro:"amount"`
+	PaymentDT    int64  `avro:"payment_dt"`
+	Bank         string `avro:"bank"`
+	DeliveryCost int64  `avro:"delivery_cost"`
+	GoodsTotal   int64  `avro:"goods_total"`
+	CustomFee    int64  `avro:"custom_fee"`
+}
+
+type itemAvro struct {
+	ChrtID      int64  `avro:"chrt_id"`
+	TrackNumber string `avro:"track_number"`
+	Price       int64  `avro:"price"`
+	Rid         string `avro:"rid"`
+	Name        string `avro:"name"`
+	Sale        int32  `avro:"sale"`
+	Size        string `avro:"size"`
+	TotalPrice  int64  `avro:"total_price"`
+	NmID        int64  `avro:"nm_id"`
+	Brand       string `avro:"brand"`
+	Status      int32  `avro:"status"`
+}
+
+// toModel отображает Avro-запись в доменную модель
+func (o *orderAvro) toModel() *models.Order {
+	order := &models.Order{
+		OrderUID:          o.OrderUID,
+		TrackNumber:       o.TrackNumber,
+		Entry:             o.Entry,
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerID:        o.CustomerID,
+		DeliveryService:   o.DeliveryService,
+		ShardKey:          o.ShardKey,
+		SmID:              int(o.SmID),
+		DateCreated:       o.DateCreated,
+		OofShard:          o.OofShard,
+		Delivery: models.Delivery{
+			Name:    o.Delivery.Name,
+			Phone:   o.Delivery.Phone,
+			Zip:     o.Delivery.Zip,
+			City:    o.Delivery.City,
+			Address: o.Delivery.Address,
+			Region:  o.Delivery.Region,
+			Email:   o.Delivery.Email,
+		},
+		Payment: models.Payment{
+			Transaction:  o.Payment.Transaction,
+			RequestID:    o.Payment.RequestID,
+			Currency:     o.Payment.Currency,
+			Provider:     o.Payment.Provider,
+			Amount:       int(o.Payment.Amount),
+			PaymentDT:    o.Payment.PaymentDT,
+			Bank:         o.Payment.Bank,
+			DeliveryCost: int(o.Payment.DeliveryCost),
+			GoodsTotal:   int(o.Payment.GoodsTotal),
+			CustomFee:    int(o.Payment.CustomFee),
+		},
+		Items: make([]models.Item, 0, len(o.Items)),
+	}
+
+	for _, item := range o.Items {
+		order.Items = append(order.Items, models.Item{
+			ChrtID:      int(item.ChrtID),
+			TrackNumber: item.TrackNumber,
+			Price:       int(item.Price),
+			Rid:         item.Rid,
+			Name:        item.Name,
+			Sale:        int(item.Sale),
+			Size:        item.Size,
+			TotalPrice:  int(item.TotalPrice),
+			NmID:        int(item.NmID),
+			Brand:       item.Brand,
+			Status:      int(item.Status),
+		})
+	}
+
+	return order
+}
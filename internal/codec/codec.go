@@ -0,0 +1,110 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// Заголовки Kafka-сообщения, определяющие формат и версию схемы
+const (
+	HeaderContentType   = "content-type"
+	HeaderSchemaVersion = "schema-version"
+)
+
+// Поддерживаемые форматы сообщений
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+	FormatAvro     = "avro"
+)
+
+// DefaultSchemaVersion - версия схемы для сообщений без заголовка schema-version
+const DefaultSchemaVersion = 1
+
+var (
+	// ErrUnsupportedFormat - формат сообщения не поддерживается
+	ErrUnsupportedFormat = errors.New("unsupported message format")
+	// ErrUnsupportedVersion - версия схемы не поддерживается форматом
+	ErrUnsupportedVersion = errors.New("unsupported schema version")
+)
+
+// Decoder декодирует полезную нагрузку сообщения заданной версии схемы в доменную модель
+type Decoder interface {
+	Decode(data []byte, version int) (*models.Order, error)
+}
+
+// Registry выбирает декодер по заголовку content-type или формату по умолчанию
+type Registry struct {
+	decoders      map[string]Decoder
+	contentTypes  map[string]string
+	defaultFormat string
+}
+
+// NewRegistry создает реестр со всеми поддерживаемыми форматами
+func NewRegistry(defaultFormat string) (*Registry, error) {
+	if defaultFormat == "" {
+		defaultFormat = FormatJSON
+	}
+
+	avroDecoder, err := NewAvroDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	schemaValidator, err := NewSchemaValidator()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		decoders: map[string]Decoder{
+			FormatJSON:     NewJSONDecoder(schemaValidator),
+			FormatProtobuf: NewProtobufDecoder(),
+			FormatAvro:     avroDecoder,
+		},
+		contentTypes: map[string]string{
+			"application/json":       FormatJSON,
+			"application/x-protobuf": FormatProtobuf,
+			"application/protobuf":   FormatProtobuf,
+			"application/avro":       FormatAvro,
+			"avro/binary":            FormatAvro,
+		},
+		defaultFormat: defaultFormat,
+	}
+
+	if _, ok := r.decoders[defaultFormat]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, defaultFormat)
+	}
+
+	return r, nil
+}
+
+// Decode декодирует сообщение. contentType и schemaVersion - значения заголовков,
+// пустые значения означают формат по умолчанию и DefaultSchemaVersion
+func (r *Registry) Decode(contentType, schemaVersion string, data []byte) (*models.Order, error) {
+	format := r.defaultFormat
+	if contentType != "" {
+		// Параметры вида "; charset=utf-8" не учитываем
+		mediaType := strings.TrimSpace(strings.ToLower(strings.Split(contentType, ";")[0]))
+		f, ok := r.contentTypes[mediaType]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, contentType)
+		}
+		format = f
+	}
+
+	version := DefaultSchemaVersion
+	if schemaVersion != "" {
+		v, err := strconv.Atoi(schemaVersion)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedVersion, schemaVersion)
+		}
+		version = v
+	}
+
+	return r.decoders[format].Decode(data, version)
+}
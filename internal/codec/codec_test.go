@@ -0,0 +1,203 @@
+package codec
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hamba/avro/v2"
+	"github.com/shenikar/order-service/internal/mapper"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+const orderV1JSON = `{
+	"order_uid": "b563feb7b2b84b6test",
+	"track_number": "WBILMTESTTRACK",
+	"entry": "WBIL",
+	"locale": "en",
+	"customer_id": "test",
+	"delivery_service": "meest",
+	"shardkey": "9",
+	"sm_id": 99,
+	"date_created": "2021-11-26T06:22:19Z",
+	"oof_shard": "1",
+	"delivery": {"name": "Test Testov", "phone": "+9720000000", "zip": "2639809", "city": "Kiryat Mozkin",
+		"address": "Ploshad Mira 15", "region": "Kraiot", "email": "test@gmail.com"},
+	"payment": {"transaction": "b563feb7b2b84b6test", "currency": "USD", "provider": "wbpay", "amount": 1817,
+		"payment_dt": 1637907727, "bank": "alpha", "delivery_cost": 1500, "goods_total": 317, "custom_fee": 0},
+	"items": [{"chrt_id": 9934930, "track_number": "WBILMTESTTRACK", "price": 453, "rid": "ab4219087a764ae0btest",
+		"name": "Mascaras", "sale": 30, "size": "0", "total_price": 317, "nm_id": 2389212, "brand": "Vivienne Sabo",
+		"status": 202}]
+}`
+
+const orderV2JSON = `{
+	"order_uid": "b563feb7b2b84b6test",
+	"track_number": "WBILMTESTTRACK",
+	"entry": "WBIL",
+	"locale": "en",
+	"customer_id": "test",
+	"delivery_service": "meest",
+	"shard_key": "9",
+	"sm_id": 99,
+	"created_at": "2021-11-26T06:22:19Z",
+	"oof_shard": "1",
+	"delivery": {"name": "Test Testov", "phone": "+9720000000", "zip": "2639809", "city": "Kiryat Mozkin",
+		"address": "Ploshad Mira 15", "region": "Kraiot", "email": "test@gmail.com"},
+	"payment": {"transaction": "b563feb7b2b84b6test", "currency": "USD", "provider": "wbpay", "amount": 1817,
+		"paid_at": "2021-11-26T06:22:07Z", "bank": "alpha", "delivery_cost": 1500, "goods_total": 317, "custom_fee": 0},
+	"items": [{"chrt_id": 9934930, "track_number": "WBILMTESTTRACK", "price": 453, "rid": "ab4219087a764ae0btest",
+		"name": "Mascaras", "sale": 30, "size": "0", "total_price": 317, "nm_id": 2389212, "brand": "Vivienne Sabo",
+		"status": 202}]
+}`
+
+// orderV3JSON - заказ orderV2JSON в схеме v3: суммы в минорных единицах валюты
+var orderV3JSON = strings.NewReplacer(
+	`"amount": 1817`, `"amount": 181700`,
+	`"delivery_cost": 1500`, `"delivery_cost": 150000`,
+	`"goods_total": 317`, `"goods_total": 31700`,
+	`"price": 453`, `"price": 45300`,
+	`"total_price": 317`, `"total_price": 31700`,
+).Replace(orderV2JSON)
+
+func newTestRegistry(t *testing.T) *Registry {
+	r, err := NewRegistry(FormatJSON)
+	require.NoError(t, err)
+	return r
+}
+
+func assertSampleOrder(t *testing.T, order *models.Order) {
+	assert.Equal(t, "b563feb7b2b84b6test", order.OrderUID)
+	assert.Equal(t, "9", order.ShardKey)
+	assert.True(t, order.DateCreated.Equal(time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)))
+	assert.Equal(t, int64(1637907727), order.Payment.PaymentDT)
+	assert.Equal(t, 181700, order.Payment.Amount)
+	assert.Equal(t, 150000, order.Payment.DeliveryCost)
+	assert.Equal(t, "test@gmail.com", order.Delivery.Email)
+	require.Len(t, order.Items, 1)
+	assert.Equal(t, 2389212, order.Items[0].NmID)
+}
+
+func TestRegistry_JSONVersionsMapToSameModel(t *testing.T) {
+	r := newTestRegistry(t)
+
+	v1, err := r.Decode("", "", []byte(orderV1JSON))
+	require.NoError(t, err)
+	assertSampleOrder(t, v1)
+
+	v2, err := r.Decode("application/json", "2", []byte(orderV2JSON))
+	require.NoError(t, err)
+	assertSampleOrder(t, v2)
+
+	v3, err := r.Decode("application/json", "3", []byte(orderV3JSON))
+	require.NoError(t, err)
+	assertSampleOrder(t, v3)
+
+	assert.Equal(t, v1, v2)
+	assert.Equal(t, v1, v3)
+	assert.Equal(t, 45300, v1.Items[0].Price, "v1 and v2 amounts are scaled to minor units")
+}
+
+func TestRegistry_JSONMinorUnitsByCurrency(t *testing.T) {
+	r := newTestRegistry(t)
+
+	for currency, amount := range map[string]int{"USD": 181700, "JPY": 1817, "KWD": 1817000} {
+		payload := strings.Replace(orderV2JSON, `"currency": "USD"`, `"currency": "`+currency+`"`, 1)
+		order, err := r.Decode("", "2", []byte(payload))
+		require.NoError(t, err)
+		assert.Equal(t, amount, order.Payment.Amount, currency)
+	}
+
+	overflow := strings.Replace(orderV2JSON, `"amount": 1817`, `"amount": 9223372036854775807`, 1)
+	_, err := r.Decode("", "2", []byte(overflow))
+	assert.ErrorContains(t, err, "payment.amount")
+}
+
+func TestRegistry_UnsupportedVersionAndFormat(t *testing.T) {
+	r := newTestRegistry(t)
+
+	_, err := r.Decode("", "4", []byte(orderV1JSON))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	_, err = r.Decode("", "abc", []byte(orderV1JSON))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	_, err = r.Decode("text/xml", "", []byte(orderV1JSON))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = NewRegistry("xml")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestRegistry_JSONSchemaViolation(t *testing.T) {
+	r := newTestRegistry(t)
+
+	// v2-полезная нагрузка, отправленная без заголовка версии, не проходит схему v1
+	_, err := r.Decode("", "", []byte(orderV2JSON))
+	assert.ErrorIs(t, err, ErrSchemaViolation)
+
+	_, err = r.Decode("", "", []byte(`{"order_uid": 42}`))
+	assert.ErrorIs(t, err, ErrSchemaViolation)
+}
+
+func TestRegistry_Protobuf(t *testing.T) {
+	r := newTestRegistry(t)
+	expected, err := r.Decode("", "", []byte(orderV1JSON))
+	require.NoError(t, err)
+
+	data, err := proto.Marshal(mapper.MapModelToProto(expected))
+	require.NoError(t, err)
+
+	order, err := r.Decode("application/x-protobuf", "2", data)
+	require.NoError(t, err)
+	assertSampleOrder(t, order)
+
+	// v1 передает суммы в основных единицах валюты
+	po := mapper.MapModelToProto(expected)
+	po.Payment.Amount, po.Payment.DeliveryCost = 1817, 1500
+	data, err = proto.Marshal(po)
+	require.NoError(t, err)
+
+	order, err = r.Decode("application/x-protobuf", "", data)
+	require.NoError(t, err)
+	assertSampleOrder(t, order)
+
+	_, err = r.Decode("application/x-protobuf", "3", data)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+}
+
+func TestRegistry_Avro(t *testing.T) {
+	r := newTestRegistry(t)
+	avroDecoder, err := NewAvroDecoder()
+	require.NoError(t, err)
+
+	record := orderAvro{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Locale:      "en",
+		CustomerID:  "test",
+		ShardKey:    "9",
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Delivery:    deliveryAvro{Name: "Test Testov", Email: "test@gmail.com"},
+		Payment:     paymentAvro{Transaction: "b563feb7b2b84b6test", Currency: "USD", PaymentDT: 1637907727},
+		Items:       []itemAvro{{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Name: "Mascaras", NmID: 2389212}},
+	}
+
+	// v1 передает суммы в основных единицах валюты, v2 - в минорных
+	for version, amounts := range map[int][2]int64{1: {1817, 1500}, 2: {181700, 150000}} {
+		schema, ok := avroDecoder.Schema(version)
+		require.True(t, ok)
+
+		record.Payment.Amount, record.Payment.DeliveryCost = amounts[0], amounts[1]
+		data, err := avro.Marshal(schema, record)
+		require.NoError(t, err)
+
+		order, err := r.Decode("avro/binary", strconv.Itoa(version), data)
+		require.NoError(t, err, version)
+		assertSampleOrder(t, order)
+	}
+}
@@ -0,0 +1,239 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// LatestJSONVersion - последняя версия JSON-схемы заказа
+const LatestJSONVersion = 3
+
+// Upcaster преобразует JSON-объект версии N в версию N+1
+type Upcaster func(payload map[string]any) error
+
+// jsonUpcasters - цепочка преобразований, ключ - исходная версия
+var jsonUpcasters = map[int]Upcaster{
+	1: upcastV1ToV2,
+	2: upcastV2ToV3,
+}
+
+// JSONDecoder декодирует JSON-сообщения любых поддерживаемых версий.
+// Сообщение проверяется по JSON Schema своей версии, затем приводится цепочкой
+// upcaster'ов к последней версии схемы, которая отображается в доменную модель
+type JSONDecoder struct {
+	validator *SchemaValidator
+}
+
+// NewJSONDecoder создает новый экземпляр JSONDecoder.
+// Если validator равен nil, проверка по JSON Schema не выполняется
+func NewJSONDecoder(validator *SchemaValidator) *JSONDecoder {
+	return &JSONDecoder{validator: validator}
+}
+
+// Decode декодирует JSON-сообщение версии version
+func (d *JSONDecoder) Decode(data []byte, version int) (*models.Order, error) {
+	if version < 1 || version > LatestJSONVersion {
+		return nil, fmt.Errorf("%w: json v%d", ErrUnsupportedVersion, version)
+	}
+
+	if d.validator != nil {
+		if err := d.validator.Validate(data, version); err != nil {
+			return nil, err
+		}
+	}
+
+	latest := data
+	if version < LatestJSONVersion {
+		var err error
+		if latest, err = upcastJSON(data, version); err != nil {
+			return nil, err
+		}
+	}
+
+	var payload orderV3
+	if err := json.Unmarshal(latest, &payload); err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+
+	return payload.toModel(), nil
+}
+
+// upcastJSON последовательно применяет upcaster'ы от version до LatestJSONVersion
+func upcastJSON(data []byte, version int) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var payload map[string]any
+	if err := dec.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+
+	for v := version; v < LatestJSONVersion; v++ {
+		if err := jsonUpcasters[v](payload); err != nil {
+			return nil, fmt.Errorf("failed to upcast json v%d to v%d: %w", v, v+1, err)
+		}
+	}
+
+	return json.Marshal(payload)
+}
+
+// upcastV1ToV2 переименовывает shardkey и date_created,
+// а unix-время оплаты payment_dt заменяет на paid_at в RFC3339
+func upcastV1ToV2(payload map[string]any) error {
+	renameKey(payload, "shardkey", "shard_key")
+	renameKey(payload, "date_created", "created_at")
+
+	payment, ok := payload["payment"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	raw, ok := payment["payment_dt"]
+	if !ok {
+		return nil
+	}
+	delete(payment, "payment_dt")
+
+	number, ok := raw.(json.Number)
+	if !ok {
+		return fmt.Errorf("payment.payment_dt must be a number, got %T", raw)
+	}
+	seconds, err := number.Int64()
+	if err != nil {
+		return fmt.Errorf("payment.payment_dt: %w", err)
+	}
+	if seconds != 0 {
+		payment["paid_at"] = time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+	}
+
+	return nil
+}
+
+// upcastV2ToV3 переводит денежные суммы из основных единиц валюты в минорные:
+// в v3 суммы передаются в минорных единицах, например 1817.00 RUB - 181700
+func upcastV2ToV3(payload map[string]any) error {
+	payment, ok := payload["payment"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	currency, _ := payment["currency"].(string)
+
+	if err := scaleKeys(payment, currency, "payment.", "amount", "delivery_cost", "goods_total", "custom_fee"); err != nil {
+		return err
+	}
+	items, _ := payload["items"].([]any)
+	for i, raw := range items {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if err := scaleKeys(item, currency, fmt.Sprintf("items[%d].", i), "price", "total_price"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scaleKeys переводит суммы в ключах keys объекта из основных единиц валюты в минорные.
+// prefix - путь к объекту для сообщений об ошибках
+func scaleKeys(object map[string]any, currency, prefix string, keys ...string) error {
+	for _, key := range keys {
+		raw, ok := object[key]
+		if !ok {
+			continue
+		}
+		number, ok := raw.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s%s must be a number, got %T", prefix, key, raw)
+		}
+		amount, err := number.Int64()
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", prefix, key, err)
+		}
+		scaled, err := toMinorUnits(amount, currency)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", prefix, key, err)
+		}
+		object[key] = json.Number(strconv.FormatInt(scaled, 10))
+	}
+	return nil
+}
+
+// renameKey переносит значение из ключа from в ключ to
+func renameKey(payload map[string]any, from, to string) {
+	if value, ok := payload[from]; ok {
+		payload[to] = value
+		delete(payload, from)
+	}
+}
+
+// orderV3 - JSON-схема заказа версии 3: поля как в v2, суммы в минорных единицах валюты
+type orderV3 struct {
+	OrderUID          string          `json:"order_uid"`
+	TrackNumber       string          `json:"track_number"`
+	Entry             string          `json:"entry"`
+	Locale            string          `json:"locale"`
+	InternalSignature string          `json:"internal_signature"`
+	CustomerID        string          `json:"customer_id"`
+	DeliveryService   string          `json:"delivery_service"`
+	ShardKey          string          `json:"shard_key"`
+	SmID              int             `json:"sm_id"`
+	CreatedAt         time.Time       `json:"created_at"`
+	OofShard          string          `json:"oof_shard"`
+	Delivery          models.Delivery `json:"delivery"`
+	Payment           paymentV3       `json:"payment"`
+	Items             []models.Item   `json:"items"`
+}
+
+type paymentV3 struct {
+	Transaction  string    `json:"transaction"`
+	RequestID    string    `json:"request_id"`
+	Currency     string    `json:"currency"`
+	Provider     string    `json:"provider"`
+	Amount       int       `json:"amount"`
+	PaidAt       time.Time `json:"paid_at"`
+	Bank         string    `json:"bank"`
+	DeliveryCost int       `json:"delivery_cost"`
+	GoodsTotal   int       `json:"goods_total"`
+	CustomFee    int       `json:"custom_fee"`
+}
+
+// toModel отображает JSON v3 в доменную модель
+func (o *orderV3) toModel() *models.Order {
+	var paymentDT int64
+	if !o.Payment.PaidAt.IsZero() {
+		paymentDT = o.Payment.PaidAt.Unix()
+	}
+
+	return &models.Order{
+		OrderUID:          o.OrderUID,
+		TrackNumber:       o.TrackNumber,
+		Entry:             o.Entry,
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerID:        o.CustomerID,
+		DeliveryService:   o.DeliveryService,
+		ShardKey:          o.ShardKey,
+		SmID:              o.SmID,
+		DateCreated:       o.CreatedAt,
+		OofShard:          o.OofShard,
+		Delivery:          o.Delivery,
+		Payment: models.Payment{
+			Transaction:  o.Payment.Transaction,
+			RequestID:    o.Payment.RequestID,
+			Currency:     o.Payment.Currency,
+			Provider:     o.Payment.Provider,
+			Amount:       o.Payment.Amount,
+			PaymentDT:    paymentDT,
+			Bank:         o.Payment.Bank,
+			DeliveryCost: o.Payment.DeliveryCost,
+			GoodsTotal:   o.Payment.GoodsTotal,
+			CustomFee:    o.Payment.CustomFee,
+		},
+		Items: o.Items,
+	}
+}
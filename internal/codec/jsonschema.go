@@ -0,0 +1,109 @@
+package codec
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+//go:embed schemas/*.schema.json
+var jsonSchemas embed.FS
+
+// jsonSchemaFiles - JSON Schema входящих сообщений по версиям
+var jsonSchemaFiles = map[int]string{
+	1: "schemas/order.v1.schema.json",
+	2: "schemas/order.v2.schema.json",
+	3: "schemas/order.v3.schema.json",
+}
+
+// ErrSchemaViolation - сообщение не соответствует JSON Schema своей версии
+var ErrSchemaViolation = errors.New("schema violation")
+
+// JSONSchema возвращает документ JSON Schema заданной версии
+func JSONSchema(version int) ([]byte, error) {
+	file, ok := jsonSchemaFiles[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: json v%d", ErrUnsupportedVersion, version)
+	}
+	return jsonSchemas.ReadFile(file)
+}
+
+// SchemaValidator проверяет JSON-сообщения по JSON Schema их версии
+type SchemaValidator struct {
+	schemas map[int]*jsonschema.Schema
+}
+
+// NewSchemaValidator компилирует JSON Schema всех поддерживаемых версий
+func NewSchemaValidator() (*SchemaValidator, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+
+	v := &SchemaValidator{schemas: make(map[int]*jsonschema.Schema, len(jsonSchemaFiles))}
+	for version, file := range jsonSchemaFiles {
+		data, err := jsonSchemas.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read json schema %s: %w", file, err)
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse json schema %s: %w", file, err)
+		}
+		if err := compiler.AddResource(file, doc); err != nil {
+			return nil, fmt.Errorf("failed to add json schema %s: %w", file, err)
+		}
+		schema, err := compiler.Compile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile json schema %s: %w", file, err)
+		}
+		v.schemas[version] = schema
+	}
+
+	return v, nil
+}
+
+// Validate проверяет сообщение версии version
+func (v *SchemaValidator) Validate(data []byte, version int) error {
+	schema, ok := v.schemas[version]
+	if !ok {
+		return fmt.Errorf("%w: json v%d", ErrUnsupportedVersion, version)
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+
+	if err := schema.Validate(instance); err != nil {
+		return fmt.Errorf("%w: %w", ErrSchemaViolation, err)
+	}
+
+	return nil
+}
+
+// SchemaViolationRules возвращает нарушенные ключевые слова JSON Schema (required, type,
+// minimum и т.д.) из ошибки Validate. Для других ошибок возвращается nil
+func SchemaViolationRules(err error) []string {
+	var violation *jsonschema.ValidationError
+	if !errors.As(err, &violation) {
+		return nil
+	}
+	var rules []string
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, cause := range e.Causes {
+				walk(cause)
+			}
+			return
+		}
+		if path := e.ErrorKind.KeywordPath(); len(path) > 0 && !slices.Contains(rules, path[len(path)-1]) {
+			rules = append(rules, path[len(path)-1])
+		}
+	}
+	walk(violation)
+	return rules
+}
@@ -0,0 +1,40 @@
+package codec
+
+import (
+	"fmt"
+
+	orderv1 "github.com/shenikar/order-service/api/order/v1"
+	"github.com/shenikar/order-service/internal/mapper"
+	"github.com/shenikar/order-service/internal/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtobufDecoder декодирует сообщения order.v1.Order.
+// Совместимость полей обеспечивается правилами эволюции Protobuf (номера полей), версия
+// схемы определяет только единицы денежных сумм: в v1 основные, в v2 минорные единицы валюты
+type ProtobufDecoder struct{}
+
+// NewProtobufDecoder создает новый экземпляр ProtobufDecoder
+func NewProtobufDecoder() *ProtobufDecoder {
+	return &ProtobufDecoder{}
+}
+
+// Decode декодирует Protobuf-сообщение
+func (d *ProtobufDecoder) Decode(data []byte, version int) (*models.Order, error) {
+	if version < 1 || version > minorUnitsProtobufVersion {
+		return nil, fmt.Errorf("%w: protobuf v%d", ErrUnsupportedVersion, version)
+	}
+
+	var po orderv1.Order
+	if err := proto.Unmarshal(data, &po); err != nil {
+		return nil, fmt.Errorf("invalid protobuf: %w", err)
+	}
+
+	order := mapper.MapProtoToModel(&po)
+	if version < minorUnitsProtobufVersion {
+		if err := scaleToMinorUnits(&order); err != nil {
+			return nil, fmt.Errorf("invalid protobuf: %w", err)
+		}
+	}
+	return &order, nil
+}
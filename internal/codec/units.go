@@ -0,0 +1,52 @@
+package codec
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// Версии схем, начиная с которых денежные суммы передаются в минорных единицах валюты.
+// В более ранних версиях суммы передаются в основных единицах (рубли, доллары и т.д.),
+// и декодер переводит их в минорные, в которых суммы хранятся в доменной модели
+const (
+	minorUnitsJSONVersion     = 3
+	minorUnitsAvroVersion     = 2
+	minorUnitsProtobufVersion = 2
+)
+
+// toMinorUnits переводит сумму в основных единицах валюты в минорные по масштабу ISO 4217
+func toMinorUnits(amount int64, currency string) (int64, error) {
+	factor := int64(1)
+	for i := 0; i < models.CurrencyScale(currency); i++ {
+		factor *= 10
+	}
+	if amount > math.MaxInt64/factor || amount < math.MinInt64/factor {
+		return 0, fmt.Errorf("amount %d %s overflows minor units", amount, currency)
+	}
+	return amount * factor, nil
+}
+
+// scaleToMinorUnits переводит денежные суммы заказа из основных единиц валюты в минорные
+func scaleToMinorUnits(order *models.Order) error {
+	currency := order.Payment.Currency
+	amounts := []*int{
+		&order.Payment.Amount,
+		&order.Payment.DeliveryCost,
+		&order.Payment.GoodsTotal,
+		&order.Payment.CustomFee,
+	}
+	for i := range order.Items {
+		amounts = append(amounts, &order.Items[i].Price, &order.Items[i].TotalPrice)
+	}
+
+	for _, amount := range amounts {
+		scaled, err := toMinorUnits(int64(*amount), currency)
+		if err != nil {
+			return err
+		}
+		*amount = int(scaled)
+	}
+	return nil
+}
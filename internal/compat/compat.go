@@ -0,0 +1,308 @@
+package compat
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Severity - критичность изменения схемы для consumer'а
+type Severity string
+
+const (
+	// Breaking - сообщения по новой схеме могут быть отвергнуты или прочитаны неверно
+	Breaking Severity = "BREAKING"
+	// Warning - сообщения будут приняты, но часть данных может быть потеряна
+	Warning Severity = "WARNING"
+)
+
+// Change - изменение в предлагаемой схеме относительно текущей
+type Change struct {
+	Path     string
+	Severity Severity
+	Message  string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%-8s %s: %s", c.Severity, c.Path, c.Message)
+}
+
+// HasBreaking сообщает, есть ли среди изменений несовместимые
+func HasBreaking(changes []Change) bool {
+	for _, change := range changes {
+		if change.Severity == Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare сравнивает JSON Schema, которую ожидает consumer (current), со схемой,
+// по которой producer'ы собираются отправлять сообщения (proposed).
+// Изменение считается несовместимым, если сообщение, валидное по proposed,
+// может не пройти проверку по current или потерять обязательные данные
+func Compare(current, proposed map[string]any) []Change {
+	c := &comparator{currentRoot: current, proposedRoot: proposed}
+	c.compare("$", current, proposed)
+
+	sort.SliceStable(c.changes, func(i, j int) bool {
+		return c.changes[i].Path < c.changes[j].Path
+	})
+	return c.changes
+}
+
+type comparator struct {
+	currentRoot  map[string]any
+	proposedRoot map[string]any
+	changes      []Change
+}
+
+func (c *comparator) add(path string, severity Severity, format string, args ...any) {
+	c.changes = append(c.changes, Change{Path: path, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *comparator) compare(path string, current, proposed map[string]any) {
+	current = resolve(c.currentRoot, current)
+	proposed = resolve(c.proposedRoot, proposed)
+
+	c.compareTypes(path, current, proposed)
+	c.compareFormat(path, current, proposed)
+	c.compareEnum(path, current, proposed)
+	c.compareBounds(path, current, proposed)
+	c.compareProperties(path, current, proposed)
+
+	currentItems, ok1 := current["items"].(map[string]any)
+	proposedItems, ok2 := proposed["items"].(map[string]any)
+	if ok1 && ok2 {
+		c.compare(path+"[]", currentItems, proposedItems)
+	}
+}
+
+func (c *comparator) compareTypes(path string, current, proposed map[string]any) {
+	currentTypes := types(current)
+	if len(currentTypes) == 0 {
+		return
+	}
+	proposedTypes := types(proposed)
+	if len(proposedTypes) == 0 {
+		c.add(path, Breaking, "type constraint %v removed", keys(currentTypes))
+		return
+	}
+	for typ := range proposedTypes {
+		// integer является подмножеством number
+		if currentTypes[typ] || (typ == "integer" && currentTypes["number"]) {
+			continue
+		}
+		c.add(path, Breaking, "type changed from %v to %v", keys(currentTypes), keys(proposedTypes))
+		return
+	}
+}
+
+func (c *comparator) compareFormat(path string, current, proposed map[string]any) {
+	currentFormat, _ := current["format"].(string)
+	proposedFormat, _ := proposed["format"].(string)
+	if currentFormat != "" && currentFormat != proposedFormat {
+		c.add(path, Breaking, "format changed from %q to %q", currentFormat, proposedFormat)
+	}
+}
+
+func (c *comparator) compareEnum(path string, current, proposed map[string]any) {
+	currentEnum, ok := current["enum"].([]any)
+	if !ok {
+		return
+	}
+	proposedEnum, ok := proposed["enum"].([]any)
+	if !ok {
+		c.add(path, Breaking, "enum constraint removed")
+		return
+	}
+	for _, value := range proposedEnum {
+		if !containsValue(currentEnum, value) {
+			c.add(path, Breaking, "enum value %v is not accepted by the current schema", value)
+		}
+	}
+}
+
+// compareBounds проверяет, что ограничения не ослаблены
+func (c *comparator) compareBounds(path string, current, proposed map[string]any) {
+	for _, keyword := range []string{"minimum", "exclusiveMinimum", "minLength", "minItems"} {
+		cur, ok := number(current[keyword])
+		if !ok {
+			continue
+		}
+		prop, ok := number(proposed[keyword])
+		if !ok || prop < cur {
+			c.add(path, Breaking, "%s loosened from %v to %v", keyword, current[keyword], proposed[keyword])
+		}
+	}
+	for _, keyword := range []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems"} {
+		cur, ok := number(current[keyword])
+		if !ok {
+			continue
+		}
+		prop, ok := number(proposed[keyword])
+		if !ok || prop > cur {
+			c.add(path, Breaking, "%s loosened from %v to %v", keyword, current[keyword], proposed[keyword])
+		}
+	}
+}
+
+func (c *comparator) compareProperties(path string, current, proposed map[string]any) {
+	currentProps, _ := current["properties"].(map[string]any)
+	proposedProps, _ := proposed["properties"].(map[string]any)
+	if currentProps == nil && proposedProps == nil {
+		return
+	}
+	currentRequired := stringSet(current["required"])
+	proposedRequired := stringSet(proposed["required"])
+
+	var removed, added []string
+	for name := range currentProps {
+		if _, ok := proposedProps[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	for name := range proposedProps {
+		if _, ok := currentProps[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	for _, name := range removed {
+		hint := renameHint(c, currentProps[name], proposedProps, added)
+		if currentRequired[name] {
+			c.add(path+"."+name, Breaking, "required property removed%s", hint)
+		} else {
+			c.add(path+"."+name, Warning, "optional property removed%s", hint)
+		}
+	}
+
+	closed := current["additionalProperties"] == false
+	for _, name := range added {
+		if closed {
+			c.add(path+"."+name, Breaking, "new property is rejected by the current schema (additionalProperties: false)")
+		} else {
+			c.add(path+"."+name, Warning, "new property is unknown to the consumer and will be ignored")
+		}
+	}
+
+	for name := range currentRequired {
+		if _, ok := proposedProps[name]; ok && !proposedRequired[name] {
+			c.add(path+"."+name, Breaking, "property is required by the current schema but optional in the proposed one")
+		}
+	}
+
+	for name, cur := range currentProps {
+		prop, ok := proposedProps[name]
+		if !ok {
+			continue
+		}
+		curSchema, ok1 := cur.(map[string]any)
+		propSchema, ok2 := prop.(map[string]any)
+		if ok1 && ok2 {
+			c.compare(path+"."+name, curSchema, propSchema)
+		}
+	}
+}
+
+// renameHint ищет среди добавленных свойств свойство с такой же схемой
+func renameHint(c *comparator, removed any, proposedProps map[string]any, added []string) string {
+	removedSchema, ok := removed.(map[string]any)
+	if !ok {
+		return ""
+	}
+	removedSchema = resolve(c.currentRoot, removedSchema)
+	var candidates []string
+	for _, name := range added {
+		addedSchema, ok := proposedProps[name].(map[string]any)
+		if ok && reflect.DeepEqual(removedSchema, resolve(c.proposedRoot, addedSchema)) {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (possibly renamed to %s)", strings.Join(candidates, ", "))
+}
+
+// resolve разрешает локальную ссылку $ref вида "#/$defs/name"
+func resolve(root, schema map[string]any) map[string]any {
+	for i := 0; i < 32; i++ {
+		ref, ok := schema["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return schema
+		}
+		var node any = root
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			obj, ok := node.(map[string]any)
+			if !ok {
+				return schema
+			}
+			node = obj[part]
+		}
+		target, ok := node.(map[string]any)
+		if !ok {
+			return schema
+		}
+		schema = target
+	}
+	return schema
+}
+
+func types(schema map[string]any) map[string]bool {
+	result := make(map[string]bool)
+	switch t := schema["type"].(type) {
+	case string:
+		result[t] = true
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				result[s] = true
+			}
+		}
+	}
+	return result
+}
+
+func stringSet(value any) map[string]bool {
+	result := make(map[string]bool)
+	if list, ok := value.([]any); ok {
+		for _, v := range list {
+			if s, ok := v.(string); ok {
+				result[s] = true
+			}
+		}
+	}
+	return result
+}
+
+func keys(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for k := range set {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func containsValue(list []any, value any) bool {
+	for _, v := range list {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,91 @@
+package compat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const currentSchema = `{
+	"type": "object",
+	"required": ["order_uid", "payment"],
+	"properties": {
+		"order_uid": {"type": "string"},
+		"shardkey": {"type": "string"},
+		"sm_id": {"type": "integer"},
+		"payment": {"$ref": "#/$defs/payment"}
+	},
+	"$defs": {
+		"payment": {
+			"type": "object",
+			"required": ["amount", "currency"],
+			"properties": {
+				"amount": {"type": "integer", "minimum": 0},
+				"currency": {"type": "string", "enum": ["USD", "RUB"]}
+			}
+		}
+	}
+}`
+
+func parse(t *testing.T, data string) map[string]any {
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal([]byte(data), &schema))
+	return schema
+}
+
+func TestCompare_IdenticalSchemas(t *testing.T) {
+	changes := Compare(parse(t, currentSchema), parse(t, currentSchema))
+
+	assert.Empty(t, changes)
+	assert.False(t, HasBreaking(changes))
+}
+
+func TestCompare_BreakingChanges(t *testing.T) {
+	proposed := `{
+		"type": "object",
+		"required": ["order_uid"],
+		"properties": {
+			"order_uid": {"type": "string"},
+			"shard_key": {"type": "string"},
+			"sm_id": {"type": "string"},
+			"payment": {
+				"type": "object",
+				"required": ["amount", "currency"],
+				"properties": {
+					"amount": {"type": "number"},
+					"currency": {"type": "string", "enum": ["USD", "RUB", "EUR"]}
+				}
+			}
+		}
+	}`
+
+	changes := Compare(parse(t, currentSchema), parse(t, proposed))
+
+	assert.True(t, HasBreaking(changes))
+	assert.Contains(t, changes, Change{Path: "$.payment", Severity: Breaking,
+		Message: "property is required by the current schema but optional in the proposed one"})
+	assert.Contains(t, changes, Change{Path: "$.sm_id", Severity: Breaking,
+		Message: "type changed from [integer] to [string]"})
+	assert.Contains(t, changes, Change{Path: "$.payment.amount", Severity: Breaking,
+		Message: "type changed from [integer] to [number]"})
+	assert.Contains(t, changes, Change{Path: "$.payment.amount", Severity: Breaking,
+		Message: "minimum loosened from 0 to <nil>"})
+	assert.Contains(t, changes, Change{Path: "$.payment.currency", Severity: Breaking,
+		Message: "enum value EUR is not accepted by the current schema"})
+	assert.Contains(t, changes, Change{Path: "$.shardkey", Severity: Warning,
+		Message: "optional property removed (possibly renamed to shard_key)"})
+	assert.Contains(t, changes, Change{Path: "$.shard_key", Severity: Warning,
+		Message: "new property is unknown to the consumer and will be ignored"})
+}
+
+func TestCompare_NewPropertyWithClosedSchema(t *testing.T) {
+	current := `{"type": "object", "additionalProperties": false, "properties": {"a": {"type": "string"}}}`
+	proposed := `{"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "string"}}}`
+
+	changes := Compare(parse(t, current), parse(t, proposed))
+
+	assert.Equal(t, []Change{{Path: "$.b", Severity: Breaking,
+		Message: "new property is rejected by the current schema (additionalProperties: false)"}}, changes)
+}
@@ -0,0 +1,232 @@
+// Package compress сжимает HTTP-ответы в brotli, zstd или gzip
+// в зависимости от заголовка Accept-Encoding
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Поддерживаемые кодировки в порядке предпочтения сервера
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+)
+
+var supportedEncodings = []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+
+// DefaultMinSize - ответы меньше этого размера не сжимаются
+const DefaultMinSize = 1024
+
+// skippedContentTypes - ответы, которые не сжимаются: уже сжатые форматы
+// и потоки событий, которые клиент должен получать без задержки
+var skippedContentTypes = []string{
+	"text/event-stream",
+	"application/vnd.apache.parquet",
+	"application/gzip",
+	"image/",
+}
+
+// encoder - потоковый компрессор, который можно переиспользовать через Reset
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	EncodingBrotli: {New: func() any {
+		return brotli.NewWriterLevel(io.Discard, 5)
+	}},
+	EncodingZstd: {New: func() any {
+		enc, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		return enc
+	}},
+	EncodingGzip: {New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}},
+}
+
+// Middleware сжимает ответы не меньше minSize байт кодировкой, выбранной по Accept-Encoding.
+// Запросы на WebSocket и HEAD не сжимаются
+func Middleware(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := Negotiate(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = w
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		defer w.finish()
+
+		c.Next()
+	}
+}
+
+// Negotiate выбирает кодировку по Accept-Encoding с учетом q-значений.
+// При равных q предпочтение отдается порядку supportedEncodings.
+// Пустая строка означает, что ответ нужно отправить без сжатия
+func Negotiate(acceptEncoding string) string {
+	best, bestQ := "", 0.0
+	wildcardQ := -1.0
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			wildcardQ = q
+			continue
+		}
+		weights[name] = q
+	}
+
+	for _, encoding := range supportedEncodings {
+		q, ok := weights[encoding]
+		if !ok {
+			q = max(wildcardQ, 0)
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressWriter накапливает начало ответа до minSize байт и только затем решает,
+// сжимать ли его, поэтому короткие ответы отправляются как есть
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	buf         []byte
+	decided     bool
+	passthrough bool
+	enc         encoder
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		if w.skip() {
+			w.decided, w.passthrough = true, true
+			w.flushBuffer()
+		} else {
+			w.buf = append(w.buf, p...)
+			if len(w.buf) < w.minSize {
+				return len(p), nil
+			}
+			if err := w.start(); err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+	}
+
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.enc.Write(p)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush отправляет клиенту все, что уже записано. Ответ, сброшенный до набора
+// minSize байт, считается потоковым и сжимается
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.skip() {
+			w.decided, w.passthrough = true, true
+			w.flushBuffer()
+		} else if err := w.start(); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// skip проверяет, нужно ли отправить ответ без сжатия
+func (w *compressWriter) skip() bool {
+	header := w.Header()
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return true
+	}
+	if header.Get("Content-Encoding") != "" {
+		return true
+	}
+	contentType := header.Get("Content-Type")
+	for _, skipped := range skippedContentTypes {
+		if strings.HasPrefix(contentType, skipped) {
+			return true
+		}
+	}
+	return false
+}
+
+// start включает сжатие и записывает накопленное начало ответа
+func (w *compressWriter) start() error {
+	w.decided = true
+
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	// Сжатое представление отличается побайтно, поэтому сильный ETag становится слабым
+	if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+		header.Set("ETag", "W/"+etag)
+	}
+
+	w.enc = encoderPools[w.encoding].Get().(encoder)
+	w.enc.Reset(w.ResponseWriter)
+
+	buf := w.buf
+	w.buf = nil
+	_, err := w.enc.Write(buf)
+	return err
+}
+
+// flushBuffer отправляет накопленное начало ответа без сжатия
+func (w *compressWriter) flushBuffer() {
+	if len(w.buf) > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+// finish завершает ответ: короткий ответ отправляется как есть, сжатый - закрывается
+func (w *compressWriter) finish() {
+	if !w.decided {
+		w.flushBuffer()
+		return
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.enc.Reset(io.Discard)
+		encoderPools[w.encoding].Put(w.enc)
+		w.enc = nil
+	}
+}
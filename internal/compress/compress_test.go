@@ -0,0 +1,105 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]string{
+		"":                           "",
+		"identity":                   "",
+		"gzip":                       EncodingGzip,
+		"gzip, deflate, br, zstd":    EncodingBrotli,
+		"gzip;q=1.0, br;q=0.5":       EncodingGzip,
+		"zstd, gzip;q=0.8":           EncodingZstd,
+		"*":                          EncodingBrotli,
+		"*;q=0.5, br;q=0, zstd;q=0":  EncodingGzip,
+		"br;q=0, zstd;q=0, gzip;q=0": "",
+		"GZIP;q=bad, deflate":        "",
+	}
+	for header, expected := range tests {
+		assert.Equal(t, expected, Negotiate(header), "Accept-Encoding: %q", header)
+	}
+}
+
+func newEngine(body string, headers map[string]string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware(DefaultMinSize))
+	engine.GET("/", func(c *gin.Context) {
+		for k, v := range headers {
+			c.Header(k, v)
+		}
+		c.Data(http.StatusOK, "application/json", []byte(body))
+	})
+	return engine
+}
+
+func get(engine *gin.Engine, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	var r io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		r = gr
+	case EncodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	case EncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	}
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestMiddleware_CompressesLargeResponses(t *testing.T) {
+	body := `{"items":"` + strings.Repeat("Mascaras ", 500) + `"}`
+	engine := newEngine(body, map[string]string{"ETag": `"abc"`})
+
+	for _, encoding := range supportedEncodings {
+		rec := get(engine, encoding)
+		assert.Equal(t, encoding, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		assert.Equal(t, `W/"abc"`, rec.Header().Get("ETag"))
+		assert.Less(t, rec.Body.Len(), len(body))
+		assert.Equal(t, body, decode(t, encoding, rec.Body.Bytes()))
+	}
+}
+
+func TestMiddleware_SkipsSmallAndUnsupported(t *testing.T) {
+	small := get(newEngine(`{"status":"ok"}`, nil), "gzip")
+	assert.Empty(t, small.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"status":"ok"}`, small.Body.String())
+
+	body := strings.Repeat("x", 4*DefaultMinSize)
+	plain := get(newEngine(body, nil), "deflate")
+	assert.Empty(t, plain.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, plain.Body.String())
+
+	encoded := get(newEngine(body, map[string]string{"Content-Encoding": "gzip"}), "br")
+	assert.Equal(t, "gzip", encoded.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, encoded.Body.String())
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"log/slog"
 
 	_ "github.com/jackc/pgx/v5/stdlib" // Import pgx driver for database/sql
 	"github.com/jmoiron/sqlx"
@@ -22,6 +23,6 @@ func Connect(cfg *config.Config) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	fmt.Println("Connected to the database successfully")
+	slog.Info("Connected to the database successfully")
 	return db, nil
 }
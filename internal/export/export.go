@@ -0,0 +1,73 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// Форматы выгрузки
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+// ErrUnsupportedFormat возвращается для неизвестного формата выгрузки
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Writer записывает заказы в выгрузку. Close дописывает буферизованные данные
+// и служебные структуры формата, но не закрывает нижележащий io.Writer
+type Writer interface {
+	Write(order *models.Order) error
+	Close() error
+}
+
+// Options - параметры выгрузки
+type Options struct {
+	// IncludePII включает в выгрузку имя, телефон, адрес и email получателя
+	IncludePII bool
+}
+
+// NewWriter создает Writer для формата format
+func NewWriter(format string, w io.Writer, opts Options) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, opts), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w, opts), nil
+	case FormatParquet:
+		return newParquetWriter(w, opts)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// ContentType возвращает MIME-тип формата
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// redact убирает персональные данные получателя, если они не запрошены
+func redact(order *models.Order, opts Options) *models.Order {
+	if opts.IncludePII {
+		return order
+	}
+	redacted := *order
+	redacted.Delivery.Name = ""
+	redacted.Delivery.Phone = ""
+	redacted.Delivery.Address = ""
+	redacted.Delivery.Email = ""
+	return &redacted
+}
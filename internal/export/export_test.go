@@ -0,0 +1,93 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleOrders() []*models.Order {
+	return []*models.Order{
+		{
+			OrderUID:    "uid1",
+			CustomerID:  "test",
+			DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+			Delivery:    models.Delivery{Name: "Test Testov", Phone: "+9720000000", City: "Kiryat Mozkin", Email: "test@gmail.com"},
+			Payment:     models.Payment{Transaction: "tx1", Currency: "USD", Amount: 1817},
+			Items: []models.Item{
+				{ChrtID: 1, Name: "Mascaras", NmID: 2389212},
+				{ChrtID: 2, Name: "Lipstick", NmID: 2389213},
+			},
+		},
+		{OrderUID: "uid2", Payment: models.Payment{Currency: "RUB"}},
+	}
+}
+
+func writeAll(t *testing.T, format string, opts Options) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, opts)
+	require.NoError(t, err)
+	for _, order := range sampleOrders() {
+		require.NoError(t, w.Write(order))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestCSV_OneRowPerItem(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeAll(t, FormatCSV, Options{}))).ReadAll()
+	require.NoError(t, err)
+
+	// Заголовок, два товара первого заказа и строка заказа без товаров
+	require.Len(t, records, 4)
+	assert.Equal(t, csvHeader, records[0])
+
+	column := func(record []string, name string) string {
+		for i, h := range csvHeader {
+			if h == name {
+				return record[i]
+			}
+		}
+		t.Fatalf("unknown column %s", name)
+		return ""
+	}
+	assert.Equal(t, "uid1", column(records[1], "order_uid"))
+	assert.Equal(t, "2021-11-26T06:22:19Z", column(records[1], "date_created"))
+	assert.Equal(t, "1817", column(records[1], "payment_amount"))
+	assert.Equal(t, "Kiryat Mozkin", column(records[1], "delivery_city"))
+	assert.Equal(t, "", column(records[1], "delivery_name"))
+	assert.Equal(t, "2389213", column(records[2], "item_nm_id"))
+	assert.Equal(t, "uid2", column(records[3], "order_uid"))
+	assert.Equal(t, "", column(records[3], "item_chrt_id"))
+}
+
+func TestNDJSON_IncludePII(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(writeAll(t, FormatNDJSON, Options{IncludePII: true}))), "\n")
+	require.Len(t, lines, 2)
+
+	var order models.Order
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &order))
+	assert.Equal(t, "uid1", order.OrderUID)
+	assert.Equal(t, "Test Testov", order.Delivery.Name)
+	assert.Len(t, order.Items, 2)
+}
+
+func TestParquet_ValidFile(t *testing.T) {
+	data := writeAll(t, FormatParquet, Options{})
+
+	require.Greater(t, len(data), 8)
+	assert.Equal(t, "PAR1", string(data[:4]))
+	assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+}
+
+func TestNewWriter_UnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("xml", &bytes.Buffer{}, Options{})
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,145 @@
+package export
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// row - заказ с доставкой, платежом и одним товаром в плоском виде.
+// Заказ без товаров выгружается одной строкой с пустыми колонками товара
+type row struct {
+	OrderUID          string `parquet:"name=order_uid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TrackNumber       string `parquet:"name=track_number, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Entry             string `parquet:"name=entry, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Locale            string `parquet:"name=locale, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CustomerID        string `parquet:"name=customer_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryService   string `parquet:"name=delivery_service, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ShardKey          string `parquet:"name=shardkey, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SmID              int64  `parquet:"name=sm_id, type=INT64"`
+	DateCreated       int64  `parquet:"name=date_created, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	OofShard          string `parquet:"name=oof_shard, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DuplicateOf       string `parquet:"name=duplicate_of, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryName      string `parquet:"name=delivery_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryPhone     string `parquet:"name=delivery_phone, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryZip       string `parquet:"name=delivery_zip, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryCity      string `parquet:"name=delivery_city, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryAddress   string `parquet:"name=delivery_address, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryRegion    string `parquet:"name=delivery_region, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeliveryEmail     string `parquet:"name=delivery_email, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentTx         string `parquet:"name=payment_transaction, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentRequestID  string `parquet:"name=payment_request_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentCurrency   string `parquet:"name=payment_currency, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentProvider   string `parquet:"name=payment_provider, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentAmount     int64  `parquet:"name=payment_amount, type=INT64"`
+	PaymentDT         int64  `parquet:"name=payment_dt, type=INT64"`
+	PaymentBank       string `parquet:"name=payment_bank, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaymentDelivery   int64  `parquet:"name=payment_delivery_cost, type=INT64"`
+	PaymentGoodsTotal int64  `parquet:"name=payment_goods_total, type=INT64"`
+	PaymentCustomFee  int64  `parquet:"name=payment_custom_fee, type=INT64"`
+	ItemChrtID        *int64 `parquet:"name=item_chrt_id, type=INT64, repetitiontype=OPTIONAL"`
+	ItemTrackNumber   string `parquet:"name=item_track_number, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemPrice         int64  `parquet:"name=item_price, type=INT64"`
+	ItemRid           string `parquet:"name=item_rid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemName          string `parquet:"name=item_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemSale          int64  `parquet:"name=item_sale, type=INT64"`
+	ItemSize          string `parquet:"name=item_size, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemTotalPrice    int64  `parquet:"name=item_total_price, type=INT64"`
+	ItemNmID          int64  `parquet:"name=item_nm_id, type=INT64"`
+	ItemBrand         string `parquet:"name=item_brand, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ItemStatus        int64  `parquet:"name=item_status, type=INT64"`
+}
+
+// csvHeader - заголовок CSV, порядок совпадает с row.csvRecord
+var csvHeader = []string{
+	"order_uid", "track_number", "entry", "locale", "customer_id", "delivery_service", "shardkey",
+	"sm_id", "date_created", "oof_shard", "duplicate_of",
+	"delivery_name", "delivery_phone", "delivery_zip", "delivery_city", "delivery_address",
+	"delivery_region", "delivery_email",
+	"payment_transaction", "payment_request_id", "payment_currency", "payment_provider",
+	"payment_amount", "payment_dt", "payment_bank", "payment_delivery_cost", "payment_goods_total",
+	"payment_custom_fee",
+	"item_chrt_id", "item_track_number", "item_price", "item_rid", "item_name", "item_sale",
+	"item_size", "item_total_price", "item_nm_id", "item_brand", "item_status",
+}
+
+// flatten разворачивает заказ в строки, по одной на товар
+func flatten(order *models.Order) []row {
+	base := row{
+		OrderUID:          order.OrderUID,
+		TrackNumber:       order.TrackNumber,
+		Entry:             order.Entry,
+		Locale:            order.Locale,
+		CustomerID:        order.CustomerID,
+		DeliveryService:   order.DeliveryService,
+		ShardKey:          order.ShardKey,
+		SmID:              int64(order.SmID),
+		DateCreated:       order.DateCreated.UnixMilli(),
+		OofShard:          order.OofShard,
+		DuplicateOf:       order.DuplicateOf,
+		DeliveryName:      order.Delivery.Name,
+		DeliveryPhone:     order.Delivery.Phone,
+		DeliveryZip:       order.Delivery.Zip,
+		DeliveryCity:      order.Delivery.City,
+		DeliveryAddress:   order.Delivery.Address,
+		DeliveryRegion:    order.Delivery.Region,
+		DeliveryEmail:     order.Delivery.Email,
+		PaymentTx:         order.Payment.Transaction,
+		PaymentRequestID:  order.Payment.RequestID,
+		PaymentCurrency:   order.Payment.Currency,
+		PaymentProvider:   order.Payment.Provider,
+		PaymentAmount:     int64(order.Payment.Amount),
+		PaymentDT:         order.Payment.PaymentDT,
+		PaymentBank:       order.Payment.Bank,
+		PaymentDelivery:   int64(order.Payment.DeliveryCost),
+		PaymentGoodsTotal: int64(order.Payment.GoodsTotal),
+		PaymentCustomFee:  int64(order.Payment.CustomFee),
+	}
+	if len(order.Items) == 0 {
+		return []row{base}
+	}
+
+	rows := make([]row, 0, len(order.Items))
+	for _, item := range order.Items {
+		r := base
+		chrtID := int64(item.ChrtID)
+		r.ItemChrtID = &chrtID
+		r.ItemTrackNumber = item.TrackNumber
+		r.ItemPrice = int64(item.Price)
+		r.ItemRid = item.Rid
+		r.ItemName = item.Name
+		r.ItemSale = int64(item.Sale)
+		r.ItemSize = item.Size
+		r.ItemTotalPrice = int64(item.TotalPrice)
+		r.ItemNmID = int64(item.NmID)
+		r.ItemBrand = item.Brand
+		r.ItemStatus = int64(item.Status)
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// csvRecord возвращает значения колонок в порядке csvHeader
+func (r *row) csvRecord() []string {
+	record := []string{
+		r.OrderUID, r.TrackNumber, r.Entry, r.Locale, r.CustomerID, r.DeliveryService, r.ShardKey,
+		itoa(r.SmID), time.UnixMilli(r.DateCreated).UTC().Format(time.RFC3339), r.OofShard, r.DuplicateOf,
+		r.DeliveryName, r.DeliveryPhone, r.DeliveryZip, r.DeliveryCity, r.DeliveryAddress,
+		r.DeliveryRegion, r.DeliveryEmail,
+		r.PaymentTx, r.PaymentRequestID, r.PaymentCurrency, r.PaymentProvider,
+		itoa(r.PaymentAmount), itoa(r.PaymentDT), r.PaymentBank, itoa(r.PaymentDelivery),
+		itoa(r.PaymentGoodsTotal), itoa(r.PaymentCustomFee),
+	}
+	if r.ItemChrtID == nil {
+		return append(record, make([]string, len(csvHeader)-len(record))...)
+	}
+	return append(record,
+		itoa(*r.ItemChrtID), r.ItemTrackNumber, itoa(r.ItemPrice), r.ItemRid, r.ItemName, itoa(r.ItemSale),
+		r.ItemSize, itoa(r.ItemTotalPrice), itoa(r.ItemNmID), r.ItemBrand, itoa(r.ItemStatus),
+	)
+}
+
+func itoa(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetRowGroupSize ограничивает объем строк, которые parquet-writer держит в памяти
+const parquetRowGroupSize = 8 << 20
+
+type csvWriter struct {
+	w             *csv.Writer
+	opts          Options
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer, opts Options) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), opts: opts}
+}
+
+func (c *csvWriter) Write(order *models.Order) error {
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	for _, r := range flatten(redact(order, c.opts)) {
+		if err := c.w.Write(r.csvRecord()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc  *json.Encoder
+	opts Options
+}
+
+func newNDJSONWriter(w io.Writer, opts Options) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w), opts: opts}
+}
+
+func (n *ndjsonWriter) Write(order *models.Order) error {
+	return n.enc.Encode(redact(order, n.opts))
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+type parquetWriter struct {
+	pw   *writer.ParquetWriter
+	opts Options
+}
+
+func newParquetWriter(w io.Writer, opts Options) (*parquetWriter, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(row), 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &parquetWriter{pw: pw, opts: opts}, nil
+}
+
+func (p *parquetWriter) Write(order *models.Order) error {
+	for _, r := range flatten(redact(order, p.opts)) {
+		if err := p.pw.Write(r); err != nil {
+			return fmt.Errorf("failed to write parquet row: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.pw.WriteStop(); err != nil {
+		return fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return nil
+}
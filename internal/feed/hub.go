@@ -0,0 +1,265 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+const (
+	// pollBatchSize - количество событий, читаемых из outbox за один запрос
+	pollBatchSize = 500
+	// MaxReplay - максимальное количество событий, досылаемых при возобновлении по Last-Event-ID
+	MaxReplay = 1000
+	// subscriberBuffer - размер очереди подписчика. Подписчик, не успевающий читать события,
+	// отключается и может переподключиться с Last-Event-ID
+	subscriberBuffer = 256
+	// settleWindow - время, после которого событие считается окончательно видимым.
+	// Идентификаторы outbox выдаются при вставке, а транзакции фиксируются в произвольном
+	// порядке, поэтому событие с меньшим идентификатором может появиться позже
+	settleWindow = 10 * time.Second
+)
+
+// ErrClosed возвращается при подписке на остановленную ленту
+var ErrClosed = errors.New("order feed is closed")
+
+// Source - хранилище событий о принятых заказах
+type Source interface {
+	GetEventsAfter(ctx context.Context, eventType string, afterID int64, limit int) ([]models.OutboxEvent, error)
+	GetLastEventID(ctx context.Context, eventType string) (int64, error)
+}
+
+// Event - событие ленты о принятом заказе
+type Event struct {
+	ID    int64                     `json:"id"`
+	Order models.OrderAcceptedEvent `json:"order"`
+}
+
+// Filter - условия отбора событий для подписчика, пустые поля не ограничивают выборку
+type Filter struct {
+	CustomerID      string
+	DeliveryService string
+}
+
+// Match сообщает, подходит ли событие под фильтр
+func (f Filter) Match(event Event) bool {
+	if f.CustomerID != "" && f.CustomerID != event.Order.CustomerID {
+		return false
+	}
+	if f.DeliveryService != "" && f.DeliveryService != event.Order.DeliveryService {
+		return false
+	}
+	return true
+}
+
+// Subscription - подписка на ленту заказов
+type Subscription struct {
+	filter   Filter
+	backlog  []Event
+	replayed map[int64]struct{}
+	events   chan Event
+}
+
+// Backlog возвращает события, пропущенные с момента Last-Event-ID
+func (s *Subscription) Backlog() []Event {
+	return s.backlog
+}
+
+// Events возвращает канал новых событий. Канал закрывается при остановке ленты
+// или если подписчик не успевает читать события
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Hub опрашивает outbox и рассылает новые события о принятых заказах подписчикам.
+// Лента строится по outbox, поэтому идентификаторы событий сквозные для всех экземпляров
+// сервиса и могут использоваться для возобновления подписки
+type Hub struct {
+	source       Source
+	pollInterval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	// watermark - идентификатор, до которого все события уже разосланы
+	watermark int64
+	// delivered - идентификаторы разосланных событий выше watermark
+	delivered map[int64]struct{}
+	closed    bool
+}
+
+// NewHub создает новый экземпляр Hub
+func NewHub(source Source, pollInterval time.Duration) *Hub {
+	return &Hub{
+		source:       source,
+		pollInterval: pollInterval,
+		subscribers:  make(map[*Subscription]struct{}),
+		delivered:    make(map[int64]struct{}),
+	}
+}
+
+// Run опрашивает outbox до отмены ctx, после чего закрывает все подписки
+func (h *Hub) Run(ctx context.Context) {
+	defer h.closeAll()
+
+	watermark, err := h.source.GetLastEventID(ctx, models.EventTypeOrderAccepted)
+	if err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "Failed to get last order feed event id", "error", err)
+	}
+	h.mu.Lock()
+	h.watermark = watermark
+	h.mu.Unlock()
+
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := h.poll(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to poll order feed", "error", err)
+		}
+	}
+}
+
+// poll рассылает новые события и сдвигает watermark за окончательно видимые события.
+// Outbox читается без h.mu, чтобы медленный запрос не блокировал подписку и отписку;
+// вызывается только из Run, поэтому watermark между запросами меняет только poll
+func (h *Hub) poll(ctx context.Context, now time.Time) error {
+	h.mu.Lock()
+	after := h.watermark
+	h.mu.Unlock()
+
+	settled := true
+	for {
+		outboxEvents, err := h.source.GetEventsAfter(ctx, models.EventTypeOrderAccepted, after, pollBatchSize)
+		if err != nil {
+			return err
+		}
+
+		h.mu.Lock()
+		for _, outboxEvent := range outboxEvents {
+			after = outboxEvent.ID
+			if _, ok := h.delivered[outboxEvent.ID]; !ok {
+				h.delivered[outboxEvent.ID] = struct{}{}
+				if event, ok := decodeEvent(outboxEvent); ok {
+					h.broadcast(event)
+				}
+			}
+
+			settled = settled && now.Sub(outboxEvent.CreatedAt) >= settleWindow
+			if settled {
+				h.watermark = outboxEvent.ID
+				delete(h.delivered, outboxEvent.ID)
+			}
+		}
+		h.mu.Unlock()
+
+		if len(outboxEvents) < pollBatchSize {
+			return nil
+		}
+	}
+}
+
+// broadcast отправляет событие подходящим подписчикам, вызывается под h.mu
+func (h *Hub) broadcast(event Event) {
+	for sub := range h.subscribers {
+		if _, ok := sub.replayed[event.ID]; ok {
+			delete(sub.replayed, event.ID)
+			continue
+		}
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			slog.Warn("Order feed subscriber is too slow, disconnecting")
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe создает подписку. Если lastEventID больше нуля, в Backlog попадают
+// до MaxReplay событий, созданных после него
+func (h *Hub) Subscribe(ctx context.Context, filter Filter, lastEventID int64) (*Subscription, error) {
+	sub := &Subscription{
+		filter:   filter,
+		replayed: make(map[int64]struct{}),
+		events:   make(chan Event, subscriberBuffer),
+	}
+
+	// Backlog читается под h.mu, чтобы между чтением и регистрацией подписчика
+	// не было рассылки: уже досланные события затем пропускаются по replayed
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	if lastEventID > 0 {
+		outboxEvents, err := h.source.GetEventsAfter(ctx, models.EventTypeOrderAccepted, lastEventID, MaxReplay)
+		if err != nil {
+			return nil, err
+		}
+		for _, outboxEvent := range outboxEvents {
+			sub.replayed[outboxEvent.ID] = struct{}{}
+			if event, ok := decodeEvent(outboxEvent); ok && filter.Match(event) {
+				sub.backlog = append(sub.backlog, event)
+			}
+		}
+		// События, уже разосланные другим подписчикам, повторно рассылаться не будут
+		for id := range sub.replayed {
+			if _, ok := h.delivered[id]; ok || id <= h.watermark {
+				delete(sub.replayed, id)
+			}
+		}
+	}
+
+	h.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe удаляет подписку
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// remove удаляет подписку и закрывает ее канал, вызывается под h.mu
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+	delete(h.subscribers, sub)
+	close(sub.events)
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		h.remove(sub)
+	}
+}
+
+// decodeEvent разбирает полезную нагрузку outbox-события
+func decodeEvent(outboxEvent models.OutboxEvent) (Event, bool) {
+	event := Event{ID: outboxEvent.ID}
+	if err := json.Unmarshal(outboxEvent.Payload, &event.Order); err != nil {
+		slog.Error("Failed to decode outbox event", "event_id", outboxEvent.ID, "error", err)
+		return event, false
+	}
+	return event, true
+}
@@ -0,0 +1,227 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySource хранит события outbox в памяти
+type memorySource struct {
+	mu     sync.Mutex
+	events []models.OutboxEvent
+}
+
+func (s *memorySource) add(t *testing.T, id int64, createdAt time.Time, order models.OrderAcceptedEvent) {
+	payload, err := json.Marshal(order)
+	require.NoError(t, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, models.OutboxEvent{
+		ID:        id,
+		EventType: models.EventTypeOrderAccepted,
+		Payload:   payload,
+		CreatedAt: createdAt,
+	})
+	sort.Slice(s.events, func(i, j int) bool { return s.events[i].ID < s.events[j].ID })
+}
+
+func (s *memorySource) GetEventsAfter(_ context.Context, _ string, afterID int64, limit int) ([]models.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.OutboxEvent
+	for _, event := range s.events {
+		if event.ID > afterID && len(result) < limit {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (s *memorySource) GetLastEventID(context.Context, string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last int64
+	for _, event := range s.events {
+		if event.ID > last {
+			last = event.ID
+		}
+	}
+	return last, nil
+}
+
+func receive(t *testing.T, sub *Subscription) []int64 {
+	var ids []int64
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, event.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestHub_BroadcastWithFilter(t *testing.T) {
+	source := &memorySource{}
+	hub := NewHub(source, time.Second)
+	now := time.Now()
+
+	all, err := hub.Subscribe(context.Background(), Filter{}, 0)
+	require.NoError(t, err)
+	meest, err := hub.Subscribe(context.Background(), Filter{DeliveryService: "meest"}, 0)
+	require.NoError(t, err)
+
+	source.add(t, 1, now, models.OrderAcceptedEvent{OrderUID: "uid1", DeliveryService: "meest"})
+	source.add(t, 2, now, models.OrderAcceptedEvent{OrderUID: "uid2", DeliveryService: "dhl"})
+	require.NoError(t, hub.poll(context.Background(), now))
+
+	assert.Equal(t, []int64{1, 2}, receive(t, all))
+	assert.Equal(t, []int64{1}, receive(t, meest))
+
+	// Повторный опрос не рассылает те же события
+	require.NoError(t, hub.poll(context.Background(), now))
+	assert.Empty(t, receive(t, all))
+}
+
+func TestHub_LateCommittedEvent(t *testing.T) {
+	source := &memorySource{}
+	hub := NewHub(source, time.Second)
+	now := time.Now()
+
+	sub, err := hub.Subscribe(context.Background(), Filter{}, 0)
+	require.NoError(t, err)
+
+	// Событие 2 зафиксировано раньше события 1
+	source.add(t, 2, now, models.OrderAcceptedEvent{OrderUID: "uid2"})
+	require.NoError(t, hub.poll(context.Background(), now))
+	source.add(t, 1, now, models.OrderAcceptedEvent{OrderUID: "uid1"})
+	require.NoError(t, hub.poll(context.Background(), now))
+
+	assert.ElementsMatch(t, []int64{2, 1}, receive(t, sub))
+
+	// После окна ожидания watermark сдвигается за оба события
+	require.NoError(t, hub.poll(context.Background(), now.Add(settleWindow)))
+	assert.Equal(t, int64(2), hub.watermark)
+	assert.Empty(t, hub.delivered)
+}
+
+func TestHub_ResumeFromLastEventID(t *testing.T) {
+	source := &memorySource{}
+	hub := NewHub(source, time.Second)
+	now := time.Now()
+
+	source.add(t, 1, now, models.OrderAcceptedEvent{OrderUID: "uid1"})
+	require.NoError(t, hub.poll(context.Background(), now))
+	// Событие 2 зафиксировано, но лента его еще не разослала
+	source.add(t, 2, now, models.OrderAcceptedEvent{OrderUID: "uid2"})
+
+	sub, err := hub.Subscribe(context.Background(), Filter{}, 1)
+	require.NoError(t, err)
+	require.Len(t, sub.Backlog(), 1)
+	assert.Equal(t, "uid2", sub.Backlog()[0].Order.OrderUID)
+
+	// Событие из backlog не приходит повторно, новые события приходят
+	source.add(t, 3, now, models.OrderAcceptedEvent{OrderUID: "uid3"})
+	require.NoError(t, hub.poll(context.Background(), now))
+	assert.Equal(t, []int64{3}, receive(t, sub))
+}
+
+func TestHub_SlowSubscriberDisconnected(t *testing.T) {
+	source := &memorySource{}
+	hub := NewHub(source, time.Second)
+	now := time.Now()
+
+	sub, err := hub.Subscribe(context.Background(), Filter{}, 0)
+	require.NoError(t, err)
+
+	for id := int64(1); id <= subscriberBuffer+1; id++ {
+		source.add(t, id, now, models.OrderAcceptedEvent{OrderUID: "uid"})
+	}
+	require.NoError(t, hub.poll(context.Background(), now))
+
+	assert.Len(t, receive(t, sub), subscriberBuffer)
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+}
+
+func TestHub_RunClosesSubscriptions(t *testing.T) {
+	hub := NewHub(&memorySource{}, time.Millisecond)
+	sub, err := hub.Subscribe(context.Background(), Filter{}, 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hub.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+
+	_, err = hub.Subscribe(context.Background(), Filter{}, 0)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+// blockingSource задерживает чтение событий до закрытия release
+type blockingSource struct {
+	*memorySource
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSource) GetEventsAfter(ctx context.Context, eventType string, afterID int64, limit int) ([]models.OutboxEvent, error) {
+	close(s.started)
+	<-s.release
+	return s.memorySource.GetEventsAfter(ctx, eventType, afterID, limit)
+}
+
+func TestHub_PollDoesNotBlockSubscribers(t *testing.T) {
+	memory := &memorySource{}
+	source := &blockingSource{memorySource: memory, started: make(chan struct{}), release: make(chan struct{})}
+	hub := NewHub(source, time.Second)
+	now := time.Now()
+	memory.add(t, 1, now, models.OrderAcceptedEvent{OrderUID: "uid1"})
+
+	polled := make(chan error, 1)
+	go func() { polled <- hub.poll(context.Background(), now) }()
+	<-source.started
+
+	// Пока запрос к outbox выполняется, подписка и отписка не ждут его завершения
+	subscribed := make(chan *Subscription, 1)
+	go func() {
+		sub, err := hub.Subscribe(context.Background(), Filter{}, 0)
+		assert.NoError(t, err)
+		other, err := hub.Subscribe(context.Background(), Filter{}, 0)
+		assert.NoError(t, err)
+		hub.Unsubscribe(other)
+		subscribed <- sub
+	}()
+
+	var sub *Subscription
+	select {
+	case sub = <-subscribed:
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe blocked by poll")
+	}
+
+	close(source.release)
+	require.NoError(t, <-polled)
+	assert.Equal(t, []int64{1}, receive(t, sub))
+}
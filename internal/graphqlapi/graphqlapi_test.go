@@ -0,0 +1,148 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shenikar/order-service/internal/cache"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+	"github.com/shenikar/order-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepo реализует только методы, нужные GraphQL-резолверам
+type fakeRepo struct {
+	repository.OrderRepositoryInterface
+	orders     []models.Order
+	itemsCalls [][]string
+}
+
+func (r *fakeRepo) ListOrders(_ models.OrderFilter, _ *models.OrderCursor, limit int) ([]models.Order, error) {
+	if limit > len(r.orders) {
+		limit = len(r.orders)
+	}
+	return r.orders[:limit], nil
+}
+
+func (r *fakeRepo) GetItemsByOrderUIDs(uids []string) (map[string][]models.Item, error) {
+	r.itemsCalls = append(r.itemsCalls, uids)
+	items := make(map[string][]models.Item)
+	for _, uid := range uids {
+		items[uid] = []models.Item{{ChrtID: 1, NmID: len(uid)}}
+	}
+	return items, nil
+}
+
+func newTestHandler(t *testing.T, repo *fakeRepo) *Handler {
+	c, err := cache.NewCache(10, time.Minute)
+	require.NoError(t, err)
+	return NewHandler(service.NewOrderService(repo, c))
+}
+
+func TestOrders_ItemsLoadedInOneBatch(t *testing.T) {
+	repo := &fakeRepo{orders: []models.Order{
+		{OrderUID: "uid1", Payment: models.Payment{Amount: 100}},
+		{OrderUID: "uid22", Payment: models.Payment{Amount: 200}},
+		{OrderUID: "uid333", Payment: models.Payment{Amount: 300}},
+	}}
+	h := newTestHandler(t, repo)
+
+	query := `{ orders(first: 2) { nodes { uid payment { amount } items { nmId } } pageInfo { hasNextPage endCursor } } }`
+	ctx := withLoader(context.Background(), h.orderService)
+	resp := h.schema.Exec(ctx, query, "", nil)
+	require.Empty(t, resp.Errors)
+
+	var data struct {
+		Orders struct {
+			Nodes []struct {
+				UID     string
+				Payment struct{ Amount int }
+				Items   []struct{ NmID int }
+			}
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   *string
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+
+	require.Len(t, data.Orders.Nodes, 2)
+	assert.Equal(t, "uid22", data.Orders.Nodes[1].UID)
+	assert.Equal(t, 200, data.Orders.Nodes[1].Payment.Amount)
+	assert.Equal(t, 5, data.Orders.Nodes[1].Items[0].NmID)
+	assert.True(t, data.Orders.PageInfo.HasNextPage)
+	assert.NotNil(t, data.Orders.PageInfo.EndCursor)
+
+	require.Len(t, repo.itemsCalls, 1)
+	assert.ElementsMatch(t, []string{"uid1", "uid22"}, repo.itemsCalls[0])
+}
+
+func TestOrders_ItemsNotLoadedUnlessSelected(t *testing.T) {
+	repo := &fakeRepo{orders: []models.Order{{OrderUID: "uid1"}}}
+	h := newTestHandler(t, repo)
+
+	ctx := withLoader(context.Background(), h.orderService)
+	resp := h.schema.Exec(ctx, `{ orders { nodes { uid customerId } } }`, "", nil)
+	require.Empty(t, resp.Errors)
+
+	assert.Empty(t, repo.itemsCalls)
+}
+
+func TestOrder_LongValues(t *testing.T) {
+	// 30 млн рублей в копейках не помещаются в 32-битный Int
+	const amount = math.MaxInt32 + 1000
+	repo := &fakeRepo{orders: []models.Order{{
+		OrderUID: "uid1",
+		SmID:     math.MaxInt32 + 1,
+		Payment:  models.Payment{Amount: amount, GoodsTotal: amount},
+	}}}
+	h := newTestHandler(t, repo)
+
+	ctx := withLoader(context.Background(), h.orderService)
+	resp := h.schema.Exec(ctx, `{ orders { nodes { smId payment { amount goodsTotal } items { nmId } } } }`, "", nil)
+	require.Empty(t, resp.Errors)
+
+	var data struct {
+		Orders struct {
+			Nodes []struct {
+				SmID    int64
+				Payment struct{ Amount, GoodsTotal int64 }
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+
+	require.Len(t, data.Orders.Nodes, 1)
+	assert.Equal(t, int64(math.MaxInt32+1), data.Orders.Nodes[0].SmID)
+	assert.Equal(t, int64(amount), data.Orders.Nodes[0].Payment.Amount)
+	assert.Equal(t, int64(amount), data.Orders.Nodes[0].Payment.GoodsTotal)
+}
+
+func TestLong_UnmarshalGraphQL(t *testing.T) {
+	for _, input := range []any{int32(7), int64(7), float64(7), "7"} {
+		var l Long
+		require.NoError(t, l.UnmarshalGraphQL(input))
+		assert.Equal(t, Long(7), l)
+	}
+
+	var l Long
+	assert.Error(t, l.UnmarshalGraphQL(7.5))
+	assert.Error(t, l.UnmarshalGraphQL("seven"))
+	assert.Error(t, l.UnmarshalGraphQL(true))
+}
+
+func TestSchema_RejectsTooDeepQuery(t *testing.T) {
+	h := newTestHandler(t, &fakeRepo{})
+
+	resp := h.schema.Exec(context.Background(), `{ orders { nodes { items { nmId } } } }`, "", nil)
+	assert.Empty(t, resp.Errors)
+
+	resp = h.schema.Exec(context.Background(), `{ orders { pageInfo { endCursor } nodes { uid } } __schema { types { fields { type { ofType { ofType { name } } } } } } }`, "", nil)
+	assert.NotEmpty(t, resp.Errors)
+}
@@ -0,0 +1,64 @@
+package graphqlapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/shenikar/order-service/internal/service"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Ограничения на сложность запросов
+const (
+	maxDepth       = 6
+	maxQueryLength = 1 << 16
+)
+
+// Handler выполняет GraphQL-запросы, переданные методом POST в формате
+// {"query": "...", "operationName": "...", "variables": {...}}
+type Handler struct {
+	schema       *graphql.Schema
+	orderService *service.OrderService
+}
+
+// NewHandler создает новый экземпляр Handler
+func NewHandler(orderService *service.OrderService) *Handler {
+	return &Handler{
+		schema:       parseSchema(&Resolver{orderService: orderService}),
+		orderService: orderService,
+	}
+}
+
+func parseSchema(resolver *Resolver) *graphql.Schema {
+	return graphql.MustParseSchema(schemaSDL, resolver,
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxQueryLength(maxQueryLength),
+	)
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxQueryLength*2)).Decode(&req); err != nil {
+		http.Error(w, "invalid GraphQL request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Loader живет в пределах одного запроса, чтобы не отдавать устаревшие товары
+	ctx := withLoader(r.Context(), h.orderService)
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
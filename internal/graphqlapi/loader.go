@@ -0,0 +1,94 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// ItemsSource - источник товаров для пакетной загрузки
+type ItemsSource interface {
+	GetItemsByOrderUIDs(orderUIDs []string) (map[string][]models.Item, error)
+}
+
+type loaderKey struct{}
+
+// itemLoader загружает товары заказов одного запроса пакетами. Заказы страницы
+// регистрируются через prime, и первый же запрос товаров любого из них загружает
+// товары всех зарегистрированных заказов одним обращением к БД
+type itemLoader struct {
+	source ItemsSource
+
+	mu      sync.Mutex
+	pending []string
+	items   map[string][]models.Item
+	errs    map[string]error
+}
+
+func newItemLoader(source ItemsSource) *itemLoader {
+	return &itemLoader{
+		source: source,
+		items:  make(map[string][]models.Item),
+		errs:   make(map[string]error),
+	}
+}
+
+// withLoader добавляет в контекст loader на время одного GraphQL-запроса
+func withLoader(ctx context.Context, source ItemsSource) context.Context {
+	return context.WithValue(ctx, loaderKey{}, newItemLoader(source))
+}
+
+// loaderFrom возвращает loader запроса или новый, если контекст его не содержит
+func loaderFrom(ctx context.Context, source ItemsSource) *itemLoader {
+	if loader, ok := ctx.Value(loaderKey{}).(*itemLoader); ok {
+		return loader
+	}
+	return newItemLoader(source)
+}
+
+// prime регистрирует заказы, товары которых могут понадобиться
+func (l *itemLoader) prime(orderUIDs []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, orderUIDs...)
+}
+
+// load возвращает товары заказа, при необходимости загружая товары всех зарегистрированных заказов
+func (l *itemLoader) load(orderUID string) ([]models.Item, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if items, ok := l.items[orderUID]; ok {
+		return items, nil
+	}
+	if err, ok := l.errs[orderUID]; ok {
+		return nil, err
+	}
+
+	batch := []string{orderUID}
+	for _, uid := range l.pending {
+		if _, ok := l.items[uid]; !ok && uid != orderUID {
+			batch = append(batch, uid)
+		}
+	}
+	l.pending = nil
+
+	loaded, err := l.source.GetItemsByOrderUIDs(batch)
+	for _, uid := range batch {
+		if err != nil {
+			l.errs[uid] = err
+			continue
+		}
+		items := loaded[uid]
+		if items == nil {
+			items = []models.Item{}
+		}
+		l.items[uid] = items
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return l.items[orderUID], nil
+}
@@ -0,0 +1,206 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+	"github.com/shenikar/order-service/internal/service"
+)
+
+// Resolver - корневой резолвер запросов
+type Resolver struct {
+	orderService *service.OrderService
+}
+
+type orderFilterInput struct {
+	CustomerID      *string
+	DeliveryService *string
+	CreatedFrom     *graphql.Time
+	CreatedTo       *graphql.Time
+}
+
+// Order возвращает заказ по UID или null, если заказа нет
+func (r *Resolver) Order(ctx context.Context, args struct{ UID graphql.ID }) (*orderResolver, error) {
+	order, err := r.orderService.GetOrderByUID(ctx, string(args.UID))
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &orderResolver{order: order, itemsLoaded: true}, nil
+}
+
+// Orders возвращает страницу заказов. Товары не загружаются вместе с заказами:
+// если они запрошены, loader получает их для всей страницы одним запросом
+func (r *Resolver) Orders(ctx context.Context, args struct {
+	Filter *orderFilterInput
+	First  int32
+	After  *string
+}) (*orderConnectionResolver, error) {
+	var pageToken string
+	if args.After != nil {
+		pageToken = *args.After
+	}
+
+	orders, nextPageToken, err := r.orderService.ListOrdersWithoutItems(mapFilter(args.Filter), pageToken, int(args.First))
+	if err != nil {
+		return nil, err
+	}
+
+	loader := loaderFrom(ctx, r.orderService)
+	nodes := make([]*orderResolver, len(orders))
+	uids := make([]string, len(orders))
+	for i := range orders {
+		nodes[i] = &orderResolver{order: &orders[i], loader: loader}
+		uids[i] = orders[i].OrderUID
+	}
+	loader.prime(uids)
+
+	return &orderConnectionResolver{nodes: nodes, endCursor: nextPageToken}, nil
+}
+
+func mapFilter(input *orderFilterInput) models.OrderFilter {
+	var filter models.OrderFilter
+	if input == nil {
+		return filter
+	}
+	if input.CustomerID != nil {
+		filter.CustomerID = *input.CustomerID
+	}
+	if input.DeliveryService != nil {
+		filter.DeliveryService = *input.DeliveryService
+	}
+	if input.CreatedFrom != nil {
+		filter.CreatedFrom = input.CreatedFrom.Time
+	}
+	if input.CreatedTo != nil {
+		filter.CreatedTo = input.CreatedTo.Time
+	}
+	return filter
+}
+
+type orderConnectionResolver struct {
+	nodes     []*orderResolver
+	endCursor string
+}
+
+func (r *orderConnectionResolver) Nodes() []*orderResolver {
+	return r.nodes
+}
+
+func (r *orderConnectionResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{endCursor: r.endCursor}
+}
+
+type pageInfoResolver struct {
+	endCursor string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.endCursor != ""
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	if r.endCursor == "" {
+		return nil
+	}
+	return &r.endCursor
+}
+
+type orderResolver struct {
+	order       *models.Order
+	itemsLoaded bool
+	loader      *itemLoader
+}
+
+func (r *orderResolver) UID() graphql.ID             { return graphql.ID(r.order.OrderUID) }
+func (r *orderResolver) TrackNumber() string         { return r.order.TrackNumber }
+func (r *orderResolver) Entry() string               { return r.order.Entry }
+func (r *orderResolver) Locale() string              { return r.order.Locale }
+func (r *orderResolver) InternalSignature() string   { return r.order.InternalSignature }
+func (r *orderResolver) CustomerID() string          { return r.order.CustomerID }
+func (r *orderResolver) DeliveryService() string     { return r.order.DeliveryService }
+func (r *orderResolver) ShardKey() string            { return r.order.ShardKey }
+func (r *orderResolver) SmID() Long                  { return Long(r.order.SmID) }
+func (r *orderResolver) DateCreated() graphql.Time   { return graphql.Time{Time: r.order.DateCreated} }
+func (r *orderResolver) OofShard() string            { return r.order.OofShard }
+func (r *orderResolver) Delivery() *deliveryResolver { return &deliveryResolver{&r.order.Delivery} }
+func (r *orderResolver) Payment() *paymentResolver   { return &paymentResolver{&r.order.Payment} }
+
+func (r *orderResolver) DuplicateOf() *graphql.ID {
+	if r.order.DuplicateOf == "" {
+		return nil
+	}
+	id := graphql.ID(r.order.DuplicateOf)
+	return &id
+}
+
+func (r *orderResolver) Items() ([]*itemResolver, error) {
+	items := r.order.Items
+	if !r.itemsLoaded {
+		var err error
+		if items, err = r.loader.load(r.order.OrderUID); err != nil {
+			return nil, err
+		}
+	}
+
+	resolvers := make([]*itemResolver, len(items))
+	for i := range items {
+		resolvers[i] = &itemResolver{&items[i]}
+	}
+	return resolvers, nil
+}
+
+type deliveryResolver struct {
+	delivery *models.Delivery
+}
+
+func (r *deliveryResolver) Name() string    { return r.delivery.Name }
+func (r *deliveryResolver) Phone() string   { return r.delivery.Phone }
+func (r *deliveryResolver) Zip() string     { return r.delivery.Zip }
+func (r *deliveryResolver) City() string    { return r.delivery.City }
+func (r *deliveryResolver) Address() string { return r.delivery.Address }
+func (r *deliveryResolver) Region() string  { return r.delivery.Region }
+func (r *deliveryResolver) Email() string   { return r.delivery.Email }
+
+type paymentResolver struct {
+	payment *models.Payment
+}
+
+func (r *paymentResolver) Transaction() string { return r.payment.Transaction }
+func (r *paymentResolver) RequestID() string   { return r.payment.RequestID }
+func (r *paymentResolver) Currency() string    { return r.payment.Currency }
+func (r *paymentResolver) Provider() string    { return r.payment.Provider }
+func (r *paymentResolver) Amount() Long        { return Long(r.payment.Amount) }
+func (r *paymentResolver) Bank() string        { return r.payment.Bank }
+func (r *paymentResolver) DeliveryCost() Long  { return Long(r.payment.DeliveryCost) }
+func (r *paymentResolver) GoodsTotal() Long    { return Long(r.payment.GoodsTotal) }
+func (r *paymentResolver) CustomFee() Long     { return Long(r.payment.CustomFee) }
+
+func (r *paymentResolver) PaidAt() *graphql.Time {
+	if r.payment.PaymentDT == 0 {
+		return nil
+	}
+	return &graphql.Time{Time: time.Unix(r.payment.PaymentDT, 0).UTC()}
+}
+
+type itemResolver struct {
+	item *models.Item
+}
+
+func (r *itemResolver) ChrtID() Long        { return Long(r.item.ChrtID) }
+func (r *itemResolver) TrackNumber() string { return r.item.TrackNumber }
+func (r *itemResolver) Price() Long         { return Long(r.item.Price) }
+func (r *itemResolver) Rid() string         { return r.item.Rid }
+func (r *itemResolver) Name() string        { return r.item.Name }
+func (r *itemResolver) Sale() int32         { return int32(r.item.Sale) }
+func (r *itemResolver) Size() string        { return r.item.Size }
+func (r *itemResolver) TotalPrice() Long    { return Long(r.item.TotalPrice) }
+func (r *itemResolver) NmID() Long          { return Long(r.item.NmID) }
+func (r *itemResolver) Brand() string       { return r.item.Brand }
+func (r *itemResolver) Status() int32       { return int32(r.item.Status) }
@@ -0,0 +1,45 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Long - 64-битное целое для сумм и идентификаторов: встроенный Int в GraphQL 32-битный,
+// а суммы в минорных единицах хранятся в BIGINT
+type Long int64
+
+// ImplementsGraphQLType связывает Long со скаляром Long схемы
+func (Long) ImplementsGraphQLType(name string) bool {
+	return name == "Long"
+}
+
+// UnmarshalGraphQL разбирает Long из аргумента запроса: числа или строки с числом
+func (l *Long) UnmarshalGraphQL(input any) error {
+	switch input := input.(type) {
+	case int32:
+		*l = Long(input)
+	case int64:
+		*l = Long(input)
+	case float64:
+		if input != float64(int64(input)) {
+			return fmt.Errorf("wrong value for Long: %v", input)
+		}
+		*l = Long(input)
+	case string:
+		v, err := strconv.ParseInt(input, 10, 64)
+		if err != nil {
+			return fmt.Errorf("wrong value for Long: %w", err)
+		}
+		*l = Long(v)
+	default:
+		return fmt.Errorf("wrong type for Long: %T", input)
+	}
+	return nil
+}
+
+// MarshalJSON возвращает Long числом JSON
+func (l Long) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int64(l))
+}
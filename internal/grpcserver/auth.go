@@ -0,0 +1,96 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/shenikar/order-service/internal/auth"
+	"github.com/shenikar/order-service/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Методы gRPC API отдают заказы целиком, поэтому доступны только службе поддержки
+var requiredRoles = []string{auth.RoleSupport}
+
+// authenticate проверяет учетные данные из метаданных вызова: x-api-key или authorization.
+// Неудачные попытки учитываются limiter по IP-адресу клиента
+func authenticate(ctx context.Context, authenticator *auth.Authenticator, limiter *ratelimit.AuthLimiter) (context.Context, error) {
+	ip := peerIP(ctx)
+	if retryAfter := limiter.Blocked(ip); retryAfter > 0 {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many failed authentication attempts, retry after %s", retryAfter.Round(time.Second))
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	identity, err := authenticator.Authenticate(first("x-api-key"), first("authorization"))
+	if errors.Is(err, auth.ErrNoCredentials) {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if err != nil {
+		limiter.Fail(ip)
+		slog.WarnContext(ctx, "Rejected gRPC credentials", "client_ip", ip, "error", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	if authenticator.Enabled() && !identity.HasAnyRole(requiredRoles...) {
+		return nil, status.Error(codes.PermissionDenied, "insufficient permissions")
+	}
+	return auth.NewContext(ctx, identity), nil
+}
+
+// unaryAuthInterceptor проверяет учетные данные унарных вызовов
+func unaryAuthInterceptor(authenticator *auth.Authenticator, limiter *ratelimit.AuthLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, authenticator, limiter)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamAuthInterceptor проверяет учетные данные потоковых вызовов
+func streamAuthInterceptor(authenticator *auth.Authenticator, limiter *ratelimit.AuthLimiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), authenticator, limiter)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// peerIP возвращает IP-адрес клиента вызова
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// authenticatedStream подменяет контекст потока контекстом с данными клиента
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
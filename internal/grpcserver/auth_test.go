@@ -0,0 +1,128 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/auth"
+	"github.com/shenikar/order-service/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAuthenticator(t *testing.T, enabled bool) *auth.Authenticator {
+	a, err := auth.New(config.AuthConfig{
+		Enabled: enabled,
+		APIKeys: "support-bot:support:" + auth.HashAPIKey("support-secret") + ",partner-x:partner:" + auth.HashAPIKey("partner-secret"),
+	})
+	require.NoError(t, err)
+	return a
+}
+
+func TestUnaryAuthInterceptor(t *testing.T) {
+	interceptor := unaryAuthInterceptor(newTestAuthenticator(t, true), nil)
+
+	for _, tc := range []struct {
+		name     string
+		md       metadata.MD
+		wantCode codes.Code
+		wantSub  string
+	}{
+		{name: "no credentials", md: metadata.MD{}, wantCode: codes.Unauthenticated},
+		{name: "unknown key", md: metadata.Pairs("x-api-key", "wrong"), wantCode: codes.Unauthenticated},
+		{name: "unsupported scheme", md: metadata.Pairs("authorization", "Basic abc"), wantCode: codes.Unauthenticated},
+		{name: "role without access", md: metadata.Pairs("x-api-key", "partner-secret"), wantCode: codes.PermissionDenied},
+		{name: "support", md: metadata.Pairs("x-api-key", "support-secret"), wantCode: codes.OK, wantSub: "support-bot"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tc.md)
+			var subject string
+			handler := func(ctx context.Context, _ any) (any, error) {
+				identity, ok := auth.FromContext(ctx)
+				require.True(t, ok)
+				subject = identity.Subject
+				return "ok", nil
+			}
+
+			resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+
+			assert.Equal(t, tc.wantCode, status.Code(err))
+			if tc.wantCode == codes.OK {
+				assert.Equal(t, "ok", resp)
+				assert.Equal(t, tc.wantSub, subject)
+			} else {
+				assert.Nil(t, resp)
+				assert.Empty(t, subject, "handler must not be called")
+			}
+		})
+	}
+}
+
+func TestUnaryAuthInterceptor_Disabled(t *testing.T) {
+	interceptor := unaryAuthInterceptor(newTestAuthenticator(t, false), nil)
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+		identity, ok := auth.FromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, auth.MethodNone, identity.Method)
+		return nil, nil
+	})
+
+	assert.NoError(t, err)
+}
+
+// testServerStream - поток вызова с заданным контекстом
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamAuthInterceptor(t *testing.T) {
+	interceptor := streamAuthInterceptor(newTestAuthenticator(t, true), nil)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "support-secret"))
+	err := interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(_ any, ss grpc.ServerStream) error {
+		identity, ok := auth.FromContext(ss.Context())
+		require.True(t, ok, "handler must see the identity in the stream context")
+		assert.Equal(t, "support-bot", identity.Subject)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "partner-secret"))
+	err = interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(any, grpc.ServerStream) error {
+		t.Fatal("handler must not be called")
+		return nil
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestUnaryAuthInterceptor_ThrottlesFailedAttempts(t *testing.T) {
+	limits, err := ratelimit.ParseLimits("auth=0.01:2")
+	require.NoError(t, err)
+	interceptor := unaryAuthInterceptor(newTestAuthenticator(t, true), limits.AuthLimiter())
+
+	call := func(ip, key string) error {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", key))
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) { return nil, nil })
+		return err
+	}
+
+	assert.Equal(t, codes.OK, status.Code(call("10.0.0.1", "support-secret")), "successful calls are not counted")
+	assert.Equal(t, codes.Unauthenticated, status.Code(call("10.0.0.1", "guess-1")))
+	assert.Equal(t, codes.Unauthenticated, status.Code(call("10.0.0.1", "guess-2")))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(call("10.0.0.1", "support-secret")))
+	assert.Equal(t, codes.OK, status.Code(call("10.0.0.2", "support-secret")), "other addresses are not affected")
+}
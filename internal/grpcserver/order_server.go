@@ -0,0 +1,99 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	orderv1 "github.com/shenikar/order-service/api/order/v1"
+	"github.com/shenikar/order-service/internal/mapper"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+	"github.com/shenikar/order-service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// OrderServer реализует gRPC сервис order.v1.OrderService поверх service.OrderService
+type OrderServer struct {
+	orderv1.UnimplementedOrderServiceServer
+	orderService *service.OrderService
+}
+
+// NewOrderServer создает новый экземпляр OrderServer
+func NewOrderServer(orderService *service.OrderService) *OrderServer {
+	return &OrderServer{orderService: orderService}
+}
+
+// GetOrder возвращает заказ по UID
+func (s *OrderServer) GetOrder(ctx context.Context, req *orderv1.GetOrderRequest) (*orderv1.Order, error) {
+	if req.GetOrderUid() == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_uid is required")
+	}
+
+	order, err := s.orderService.GetOrderByUID(ctx, req.GetOrderUid())
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return nil, status.Errorf(codes.NotFound, "order %s not found", req.GetOrderUid())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
+	}
+
+	return mapper.MapModelToProto(order), nil
+}
+
+// ListOrders возвращает страницу заказов
+func (s *OrderServer) ListOrders(_ context.Context, req *orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+	orders, nextPageToken, err := s.orderService.ListOrders(mapFilter(req.GetFilter()), req.GetPageToken(), int(req.GetPageSize()))
+	if errors.Is(err, service.ErrInvalidPageToken) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list orders: %v", err)
+	}
+
+	resp := &orderv1.ListOrdersResponse{
+		Orders:        make([]*orderv1.Order, 0, len(orders)),
+		NextPageToken: nextPageToken,
+	}
+	for i := range orders {
+		resp.Orders = append(resp.Orders, mapper.MapModelToProto(&orders[i]))
+	}
+
+	return resp, nil
+}
+
+// StreamOrders передает клиенту все заказы, подходящие под фильтр
+func (s *OrderServer) StreamOrders(req *orderv1.StreamOrdersRequest, stream orderv1.OrderService_StreamOrdersServer) error {
+	err := s.orderService.StreamOrders(stream.Context(), mapFilter(req.GetFilter()), func(order *models.Order) error {
+		return stream.Send(mapper.MapModelToProto(order))
+	})
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Errorf(codes.Internal, "failed to stream orders: %v", err)
+}
+
+// mapFilter преобразует фильтр запроса в фильтр доменной модели
+func mapFilter(filter *orderv1.OrderFilter) models.OrderFilter {
+	return models.OrderFilter{
+		CustomerID:      filter.GetCustomerId(),
+		DeliveryService: filter.GetDeliveryService(),
+		CreatedFrom:     timeOrZero(filter.GetCreatedFrom()),
+		CreatedTo:       timeOrZero(filter.GetCreatedTo()),
+	}
+}
+
+func timeOrZero(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
@@ -0,0 +1,175 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	orderv1 "github.com/shenikar/order-service/api/order/v1"
+	"github.com/shenikar/order-service/internal/cache"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+	"github.com/shenikar/order-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeRepo реализует только методы, нужные gRPC-серверу
+type fakeRepo struct {
+	repository.OrderRepositoryInterface
+	orders  []models.Order // от новых к старым, как возвращает ListOrders
+	err     error
+	filters []models.OrderFilter
+}
+
+func (r *fakeRepo) GetOrderByUID(uid string) (*models.Order, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, order := range r.orders {
+		if order.OrderUID == uid {
+			return &order, nil
+		}
+	}
+	return nil, repository.ErrOrderNotFound
+}
+
+func (r *fakeRepo) GetItemByOrderUID(uid string) ([]models.Item, error) {
+	return []models.Item{{ChrtID: 1, Price: 181700, Brand: "Vivienne Sabo"}}, nil
+}
+
+func (r *fakeRepo) ListOrders(filter models.OrderFilter, after *models.OrderCursor, limit int) ([]models.Order, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.filters = append(r.filters, filter)
+	start := 0
+	if after != nil {
+		for start < len(r.orders) && r.orders[start].OrderUID != after.OrderUID {
+			start++
+		}
+		start++
+	}
+	end := min(start+limit, len(r.orders))
+	return r.orders[start:end], nil
+}
+
+func (r *fakeRepo) GetItemsByOrderUIDs(uids []string) (map[string][]models.Item, error) {
+	items := make(map[string][]models.Item, len(uids))
+	for _, uid := range uids {
+		items[uid] = []models.Item{{ChrtID: 1}}
+	}
+	return items, nil
+}
+
+func newTestServer(t *testing.T, repo *fakeRepo) *OrderServer {
+	c, err := cache.NewCache(10, time.Minute)
+	require.NoError(t, err)
+	return NewOrderServer(service.NewOrderService(repo, c))
+}
+
+func testOrders(n int) []models.Order {
+	base := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	orders := make([]models.Order, n)
+	for i := range orders {
+		orders[i] = models.Order{
+			OrderUID:    fmt.Sprintf("uid%d", i),
+			CustomerID:  "customer",
+			DateCreated: base.Add(-time.Duration(i) * time.Hour),
+			Payment:     models.Payment{Transaction: fmt.Sprintf("tx%d", i), Currency: "RUB", Amount: 181700},
+		}
+	}
+	return orders
+}
+
+func TestGetOrder(t *testing.T) {
+	srv := newTestServer(t, &fakeRepo{orders: testOrders(1)})
+
+	order, err := srv.GetOrder(context.Background(), &orderv1.GetOrderRequest{OrderUid: "uid0"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "uid0", order.GetOrderUid())
+	assert.Equal(t, int64(181700), order.GetPayment().GetAmount())
+	assert.Equal(t, "RUB", order.GetPayment().GetCurrency())
+	assert.Equal(t, time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC), order.GetDateCreated().AsTime())
+	require.Len(t, order.GetItems(), 1)
+	assert.Equal(t, "Vivienne Sabo", order.GetItems()[0].GetBrand())
+}
+
+func TestGetOrder_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		uid      string
+		repoErr  error
+		wantCode codes.Code
+	}{
+		{name: "empty uid", uid: "", wantCode: codes.InvalidArgument},
+		{name: "not found", uid: "missing", wantCode: codes.NotFound},
+		{name: "db error", uid: "uid0", repoErr: errors.New("connection refused"), wantCode: codes.Internal},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newTestServer(t, &fakeRepo{orders: testOrders(1), err: tc.repoErr})
+
+			_, err := srv.GetOrder(context.Background(), &orderv1.GetOrderRequest{OrderUid: tc.uid})
+
+			assert.Equal(t, tc.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestListOrders_Paging(t *testing.T) {
+	repo := &fakeRepo{orders: testOrders(5)}
+	srv := newTestServer(t, repo)
+
+	var uids []string
+	pageToken := ""
+	pages := 0
+	for {
+		resp, err := srv.ListOrders(context.Background(), &orderv1.ListOrdersRequest{PageSize: 2, PageToken: pageToken})
+		require.NoError(t, err)
+		pages++
+		assert.LessOrEqual(t, len(resp.GetOrders()), 2)
+		for _, order := range resp.GetOrders() {
+			uids = append(uids, order.GetOrderUid())
+			assert.Len(t, order.GetItems(), 1)
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		pageToken = resp.GetNextPageToken()
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []string{"uid0", "uid1", "uid2", "uid3", "uid4"}, uids)
+}
+
+func TestListOrders_Filter(t *testing.T) {
+	repo := &fakeRepo{orders: testOrders(1)}
+	srv := newTestServer(t, repo)
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := srv.ListOrders(context.Background(), &orderv1.ListOrdersRequest{Filter: &orderv1.OrderFilter{
+		CustomerId:      "customer",
+		DeliveryService: "meest",
+		CreatedFrom:     timestamppb.New(from),
+	}})
+
+	require.NoError(t, err)
+	require.Len(t, repo.filters, 1)
+	assert.Equal(t, models.OrderFilter{CustomerID: "customer", DeliveryService: "meest", CreatedFrom: from}, repo.filters[0])
+}
+
+func TestListOrders_Errors(t *testing.T) {
+	srv := newTestServer(t, &fakeRepo{orders: testOrders(1)})
+	_, err := srv.ListOrders(context.Background(), &orderv1.ListOrdersRequest{PageToken: "not-a-token"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	srv = newTestServer(t, &fakeRepo{err: errors.New("connection refused")})
+	_, err = srv.ListOrders(context.Background(), &orderv1.ListOrdersRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
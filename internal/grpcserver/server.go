@@ -0,0 +1,63 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"net"
+
+	orderv1 "github.com/shenikar/order-service/api/order/v1"
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/auth"
+	"github.com/shenikar/order-service/internal/logging"
+	"github.com/shenikar/order-service/internal/ratelimit"
+	"github.com/shenikar/order-service/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+var grpcServer *grpc.Server
+
+// StartServer запускает gRPC сервер на отдельном порту
+func StartServer(cfg *config.Config, authenticator *auth.Authenticator, authLimiter *ratelimit.AuthLimiter, orderService *service.OrderService) {
+	addr := cfg.GetGRPCAddress()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logging.Fatal("Failed to listen for gRPC", "addr", addr, "error", err)
+	}
+
+	grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryAuthInterceptor(authenticator, authLimiter)),
+		grpc.ChainStreamInterceptor(streamAuthInterceptor(authenticator, authLimiter)),
+	)
+	orderv1.RegisterOrderServiceServer(grpcServer, NewOrderServer(orderService))
+	// Reflection позволяет обращаться к сервису через grpcurl без .proto файлов
+	reflection.Register(grpcServer)
+
+	slog.Info("Starting gRPC server", "addr", addr)
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			logging.Fatal("Failed to start gRPC server", "error", err)
+		}
+	}()
+}
+
+// ShutdownServer дожидается завершения активных вызовов,
+// по истечении ctx принудительно закрывает соединения
+func ShutdownServer(ctx context.Context) {
+	if grpcServer == nil {
+		return
+	}
+	slog.Info("Shutting down gRPC server")
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Время кэширования заказа в зависимости от его возраста. Чем старше заказ, тем реже он
+// меняется, но сохраненный заказ все же может измениться (например, позже помечен как дубль),
+// поэтому ответы не помечаются immutable и после истечения max-age проверяются по ETag.
+// Заказы содержат персональные данные и отдаются только аутентифицированным клиентам,
+// поэтому кэшируются только на стороне клиента
+const (
+	recentOrderAge    = time.Hour
+	recentOrderMaxAge = time.Minute
+	dayOrderAge       = 24 * time.Hour
+	dayOrderMaxAge    = time.Hour
+	oldOrderMaxAge    = 24 * time.Hour
+)
+
+// contentETag возвращает сильный ETag по хэшу содержимого ответа
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// orderCacheControl возвращает Cache-Control для заказа, созданного в created
+func orderCacheControl(created, now time.Time) string {
+	age := now.Sub(created)
+	switch {
+	case created.IsZero() || age < recentOrderAge:
+		return maxAge(recentOrderMaxAge)
+	case age < dayOrderAge:
+		return maxAge(dayOrderMaxAge)
+	default:
+		return maxAge(oldOrderMaxAge)
+	}
+}
+
+func maxAge(d time.Duration) string {
+	return "private, max-age=" + strconv.Itoa(int(d.Seconds()))
+}
+
+// notModified проверяет If-None-Match GET-запроса. Last-Modified не отдается: время
+// изменения заказа не хранится, а время создания не меняется при изменении заказа
+func notModified(r *http.Request, etag string) bool {
+	inm := r.Header.Get("If-None-Match")
+	return inm != "" && etagMatches(inm, etag)
+}
+
+// etagMatches сравнивает ETag со списком из If-None-Match (слабое сравнение)
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentETag(t *testing.T) {
+	etag := contentETag([]byte(`{"order_uid":"uid1"}`))
+	assert.Equal(t, etag, contentETag([]byte(`{"order_uid":"uid1"}`)))
+	assert.NotEqual(t, etag, contentETag([]byte(`{"order_uid":"uid2"}`)))
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+}
+
+func TestOrderCacheControl(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "private, max-age=60", orderCacheControl(now.Add(-10*time.Minute), now))
+	assert.Equal(t, "private, max-age=3600", orderCacheControl(now.Add(-5*time.Hour), now))
+	assert.Equal(t, "private, max-age=86400", orderCacheControl(now.Add(-72*time.Hour), now))
+	assert.Equal(t, "private, max-age=60", orderCacheControl(time.Time{}, now))
+}
+
+func TestNotModified(t *testing.T) {
+	etag := `"abc"`
+
+	request := func(headers map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/orders/uid1", nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+
+	assert.False(t, notModified(request(nil), etag))
+	assert.True(t, notModified(request(map[string]string{"If-None-Match": `"xyz", W/"abc"`}), etag))
+	assert.True(t, notModified(request(map[string]string{"If-None-Match": "*"}), etag))
+	assert.False(t, notModified(request(map[string]string{"If-None-Match": `"xyz"`}), etag))
+
+	// Время создания заказа не говорит о его изменениях, поэтому If-Modified-Since не учитывается
+	since := time.Now().Format(http.TimeFormat)
+	assert.False(t, notModified(request(map[string]string{"If-Modified-Since": since}), etag))
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/internal/export"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/projection"
+)
+
+// exportStatusTrailer - trailer с итогом выгрузки: заголовки уже отправлены,
+// поэтому ошибку посреди выгрузки можно сообщить только после тела ответа
+const exportStatusTrailer = "X-Export-Status"
+
+// ExportOrders выгружает заказы в CSV, NDJSON или Parquet
+// @Summary Выгрузить заказы
+// @Description Потоково выгружает заказы, подходящие под фильтр, от новых к старым.
+// @Description CSV и Parquet содержат одну строку на товар с развернутыми колонками доставки и платежа.
+// @Description Имя, телефон, адрес и email получателя выгружаются только при include_pii=true,
+// @Description который доступен только клиентам с полным доступом к данным заказа.
+// @Description Итог выгрузки передается в trailer X-Export-Status: ok или error
+// @Tags orders
+// @Produce text/csv,application/x-ndjson,application/vnd.apache.parquet
+// @Param format query string false "Формат выгрузки" Enums(csv, ndjson, parquet) default(csv)
+// @Param customer_id query string false "Только заказы покупателя"
+// @Param delivery_service query string false "Только заказы службы доставки"
+// @Param created_from query string false "Заказы, созданные не раньше (RFC3339)"
+// @Param created_to query string false "Заказы, созданные раньше (RFC3339)"
+// @Param include_pii query bool false "Выгружать персональные данные получателя" default(false)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /orders/export [get]
+func (h *OrderHandler) ExportOrders(c *gin.Context) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	includePII, err := strconv.ParseBool(c.DefaultQuery("include_pii", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid include_pii"})
+		return
+	}
+	if includePII && !projection.AllowsPII(newOrderView(c, h.piiPolicy).level) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "include_pii requires full access to order data"})
+		return
+	}
+
+	w, err := export.NewWriter(format, c.Writer, export.Options{IncludePII: includePII})
+	if errors.Is(err, export.ErrUnsupportedFormat) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="orders.%s"`, format))
+	c.Header("Trailer", exportStatusTrailer)
+	c.Status(http.StatusOK)
+
+	err = h.orderService.ExportOrders(c.Request.Context(), filter, w.Write)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to export orders", "format", format, "error", err)
+		c.Writer.Header().Set(exportStatusTrailer, "error")
+		return
+	}
+	c.Writer.Header().Set(exportStatusTrailer, "ok")
+}
+
+// parseOrderFilter читает фильтр заказов из параметров запроса
+func parseOrderFilter(c *gin.Context) (models.OrderFilter, error) {
+	filter := models.OrderFilter{
+		CustomerID:      c.Query("customer_id"),
+		DeliveryService: c.Query("delivery_service"),
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTimeQuery(c, "created_from"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTimeQuery(c, "created_to"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseTimeQuery читает необязательный параметр запроса в формате RFC3339
+func parseTimeQuery(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s, expected RFC3339", name)
+	}
+	return t, nil
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/internal/health"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+// NewHealthHandler создает новый экземпляр HealthHandler
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Livez godoc
+// @Summary Проверка жизнеспособности
+// @Description Отвечает, пока процесс работает; состояние зависимостей не проверяется.
+// @Description Перезапускать сервис имеет смысл, только если не отвечает этот маршрут
+// @Tags general
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz godoc
+// @Summary Проверка готовности
+// @Description Проверяет доступность БД, версию схемы, чтение Kafka и завершение прогрева кэша.
+// @Description Возвращает состояние каждого компонента: up, degraded или down.
+// @Description Сервис готов, если ни один компонент не в состоянии down
+// @Tags general
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.checker.Check(c.Request.Context())
+	c.Header("Cache-Control", "no-store")
+	if !report.Ready() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
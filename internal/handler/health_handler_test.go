@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	warm := false
+	checker := health.NewChecker(time.Second)
+	checker.Register("cache", health.Warmup(func() (bool, error) { return warm, nil }))
+	checker.Register("kafka", func(context.Context) health.Result { return health.Up(nil) })
+
+	h := NewHealthHandler(checker)
+	engine := gin.New()
+	engine.GET("/livez", h.Livez)
+	engine.GET("/readyz", h.Readyz)
+
+	get := func(path string) (*httptest.ResponseRecorder, health.Report) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report health.Report
+		if path == "/readyz" {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		}
+		return w, report
+	}
+
+	w, _ := get("/livez")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, report := get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, health.StatusNotReady, report.Status)
+	assert.Equal(t, health.StatusDown, report.Components["cache"].Status)
+	assert.Equal(t, "warm-up in progress", report.Components["cache"].Error)
+	assert.Equal(t, health.StatusUp, report.Components["kafka"].Status)
+
+	warm = true
+	w, report = get("/readyz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, health.StatusReady, report.Status)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/shenikar/order-service/internal/mapper"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/projection"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+// mimeProtobuf - альтернативный MIME-тип Protobuf без префикса x-
+const mimeProtobuf = "application/protobuf"
+
+// orderFormats - форматы ответа с заказом; при Accept: */* или без Accept выбирается JSON
+var orderFormats = []string{
+	binding.MIMEJSON,
+	binding.MIMEMSGPACK,
+	binding.MIMEMSGPACK2,
+	binding.MIMEPROTOBUF,
+	mimeProtobuf,
+}
+
+// msgpackHandle кодирует заказ в MessagePack с именами полей из json-тегов
+// и временем в виде timestamp extension
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// encodeOrder кодирует заказ в формат, согласованный по Accept, и возвращает Content-Type ответа.
+// JSON и MessagePack используют представление view, Protobuf-представление одно для всех версий
+// и описано сообщением Order в api/order/v1/order.proto; недоступные клиенту поля в нем пустые
+func encodeOrder(format string, view orderView, order *models.Order) (string, []byte, error) {
+	switch format {
+	case binding.MIMEJSON:
+		body, err := json.Marshal(view.order(order))
+		return "application/json; charset=utf-8", body, err
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		var body []byte
+		err := codec.NewEncoderBytes(&body, msgpackHandle).Encode(view.order(order))
+		return format, body, err
+	case binding.MIMEPROTOBUF, mimeProtobuf:
+		body, err := proto.Marshal(mapper.MapModelToProto(projection.Apply(view.level, order)))
+		return format, body, err
+	default:
+		return "", nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
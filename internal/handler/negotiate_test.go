@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	orderv1 "github.com/shenikar/order-service/api/order/v1"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/projection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+var fullV1 = orderView{version: APIVersion1, level: projection.LevelFull}
+
+func sampleOrder() *models.Order {
+	return &models.Order{
+		OrderUID:    "uid1",
+		CustomerID:  "test",
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Payment:     models.Payment{Currency: "USD", Amount: 1817},
+		Items:       []models.Item{{ChrtID: 9934930, Name: "Mascaras", NmID: 2389212}},
+	}
+}
+
+func TestEncodeOrder_JSON(t *testing.T) {
+	contentType, body, err := encodeOrder(binding.MIMEJSON, fullV1, sampleOrder())
+	require.NoError(t, err)
+	assert.Equal(t, "application/json; charset=utf-8", contentType)
+
+	var decoded models.Order
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	assert.Equal(t, *sampleOrder(), decoded)
+}
+
+func TestEncodeOrder_JSONV2(t *testing.T) {
+	order := sampleOrder()
+	order.Payment.PaymentDT = 1637907727
+	order.Items[0].Price = 453
+
+	_, body, err := encodeOrder(binding.MIMEJSON, orderView{version: APIVersion2, level: projection.LevelFull}, order)
+	require.NoError(t, err)
+
+	var decoded models.OrderV2
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	assert.Equal(t, order.DateCreated, decoded.CreatedAt)
+	require.NotNil(t, decoded.Payment.PaidAt)
+	assert.Equal(t, time.Unix(1637907727, 0).UTC(), *decoded.Payment.PaidAt)
+	assert.Equal(t, models.Money{Amount: "18.17", Currency: "USD", MinorUnits: 1817}, decoded.Payment.Amount)
+	assert.Equal(t, "4.53", decoded.Items[0].Price.Amount)
+	assert.NotContains(t, string(body), "date_created")
+}
+
+func TestEncodeOrder_MessagePack(t *testing.T) {
+	contentType, body, err := encodeOrder(binding.MIMEMSGPACK, fullV1, sampleOrder())
+	require.NoError(t, err)
+	assert.Equal(t, binding.MIMEMSGPACK, contentType)
+
+	handle := &codec.MsgpackHandle{}
+	handle.RawToString = true
+	var decoded map[string]any
+	require.NoError(t, codec.NewDecoderBytes(body, handle).Decode(&decoded))
+	assert.Equal(t, "uid1", decoded["order_uid"])
+	assert.Equal(t, sampleOrder().DateCreated, decoded["date_created"].(time.Time).UTC())
+	assert.Len(t, decoded["items"], 1)
+}
+
+func TestEncodeOrder_Protobuf(t *testing.T) {
+	contentType, body, err := encodeOrder(binding.MIMEPROTOBUF, fullV1, sampleOrder())
+	require.NoError(t, err)
+	assert.Equal(t, binding.MIMEPROTOBUF, contentType)
+
+	var decoded orderv1.Order
+	require.NoError(t, proto.Unmarshal(body, &decoded))
+	assert.Equal(t, "uid1", decoded.GetOrderUid())
+	assert.Len(t, decoded.GetItems(), 1)
+}
+
+func TestEncodeOrder_RestrictedViews(t *testing.T) {
+	order := sampleOrder()
+	order.Delivery = models.Delivery{Name: "Test Testov", Phone: "+9720000000", Email: "test@gmail.com", Address: "Ploshad Mira 15"}
+
+	_, body, err := encodeOrder(binding.MIMEJSON, orderView{version: APIVersion2, level: projection.LevelMasked}, order)
+	require.NoError(t, err)
+	var masked models.OrderV2
+	require.NoError(t, json.Unmarshal(body, &masked))
+	assert.Equal(t, "+********00", masked.Delivery.Phone)
+	assert.Equal(t, "t***@gmail.com", masked.Delivery.Email)
+	assert.Equal(t, "18.17", masked.Payment.Amount.Amount, "payment is not masked")
+
+	_, body, err = encodeOrder(binding.MIMEJSON, orderView{version: APIVersion1, level: projection.LevelTracking}, order)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"order_uid":"uid1","track_number":"","delivery_service":"","items":[{"chrt_id":9934930,"track_number":"","status":0}]}`, string(body))
+
+	_, body, err = encodeOrder(binding.MIMEPROTOBUF, orderView{version: APIVersion1, level: projection.LevelTracking}, order)
+	require.NoError(t, err)
+	var decoded orderv1.Order
+	require.NoError(t, proto.Unmarshal(body, &decoded))
+	assert.Empty(t, decoded.GetDelivery().GetPhone())
+	assert.Empty(t, decoded.GetCustomerId())
+	assert.Zero(t, decoded.GetPayment().GetAmount())
+}
+
+func TestEncodeOrder_Unsupported(t *testing.T) {
+	_, _, err := encodeOrder("text/xml", fullV1, sampleOrder())
+	assert.Error(t, err)
+}
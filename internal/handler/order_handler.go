@@ -1,31 +1,59 @@
 package handler
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/projection"
 	"github.com/shenikar/order-service/internal/service"
 )
 
+const (
+	defaultDuplicatesLimit = 100
+	maxDuplicatesLimit     = 1000
+)
+
 type OrderHandler struct {
 	orderService *service.OrderService
+	piiPolicy    *projection.Policy
 }
 
 // NewOrderHandler создает новый экземпляр OrderHandler
-func NewOrderHandler(orderService *service.OrderService) *OrderHandler {
+func NewOrderHandler(orderService *service.OrderService, piiPolicy *projection.Policy) *OrderHandler {
 	return &OrderHandler{
 		orderService: orderService,
+		piiPolicy:    piiPolicy,
 	}
 }
 
 // GetOrderByUID получает заказ по OrderUID
 // @Summary Получить заказ по UID
-// @Description Получает заказ с товарами по уникальному идентификатору
+// @Description Получает заказ с товарами по уникальному идентификатору.
+// @Description Формат ответа выбирается по Accept: JSON (по умолчанию), MessagePack или Protobuf
+// @Description (сообщение Order из api/order/v1/order.proto).
+// @Description В /v2 JSON и MessagePack содержат models.OrderV2: время в RFC 3339, суммы - объекты Money.
+// @Description Данные заказа ограничены ролью клиента: аналитики получают маскированные телефон, email
+// @Description и адрес получателя, партнеры - только трек-номера и статусы (models.OrderTracking).
+// @Description Поддерживает условные запросы: ETag вычисляется по содержимому ответа,
+// @Description при совпадении If-None-Match возвращается 304 без тела
 // @Tags orders
+// @Produce json,application/x-msgpack,application/x-protobuf
 // @Param order_uid path string true "Order UID"
+// @Param If-None-Match header string false "ETag ранее полученного ответа"
 // @Success 200 {object} models.Order
+// @Success 304 "Заказ не изменился"
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 406 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /orders/{order_uid} [get]
 func (h *OrderHandler) GetOrderByUID(c *gin.Context) {
 	orderUID := c.Param("order_uid")
@@ -36,13 +64,102 @@ func (h *OrderHandler) GetOrderByUID(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.GetOrderByUID(orderUID)
+	format := c.NegotiateFormat(orderFormats...)
+	if format == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{"error": "Supported formats: JSON, MessagePack, Protobuf"})
+		return
+	}
+
+	order, err := h.orderService.GetOrderByUID(c.Request.Context(), orderUID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, order)
+	contentType, body, err := encodeOrder(format, newOrderView(c, h.piiPolicy), order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode order"})
+		return
+	}
+
+	// ETag вычисляется по закодированному телу, поэтому у каждого формата он свой
+	etag := contentETag(body)
+	c.Writer.Header().Add("Vary", "Accept")
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", orderCacheControl(order.DateCreated, time.Now()))
+	if notModified(c.Request, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// BatchGetOrders получает несколько заказов по списку OrderUID
+// @Summary Получить заказы по списку UID
+// @Description Возвращает заказы с товарами: сначала ищет их в кэше, а недостающие загружает
+// @Description из базы данных одним запросом. UID, которых нет в сервисе, перечисляются в missing.
+// @Description Повторяющиеся UID отбрасываются, заказы возвращаются в порядке запроса.
+// @Description Данные заказов ограничены ролью клиента так же, как в GET /orders/{order_uid}
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param request body models.BatchGetOrdersRequest true "Список UID заказов (не больше 1000)"
+// @Success 200 {object} models.BatchGetOrdersResult
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /orders:batchGet [post]
+func (h *OrderHandler) BatchGetOrders(c *gin.Context) {
+	var req models.BatchGetOrdersRequest
+	// Тело могло быть прочитано ограничением частоты запросов, поэтому берется из контекста
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	orders, missing, err := h.orderService.GetOrdersByUIDs(c.Request.Context(), req.OrderUIDs)
+	if errors.Is(err, service.ErrInvalidBatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to batch get orders", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
+		return
+	}
+
+	view := newOrderView(c, h.piiPolicy)
+	c.JSON(http.StatusOK, batchGetOrdersResponse{Orders: view.orders(orders), Missing: missing})
+}
+
+// GetDuplicateOrders возвращает подозрительные дубли заказов
+// @Summary Получить подозрительные дубли заказов
+// @Description Возвращает группы заказов, которые ссылаются на одну и ту же платежную транзакцию
+// @Tags orders
+// @Produce json
+// @Param limit query int false "Максимальное количество групп" default(100)
+// @Success 200 {array} models.DuplicateGroup
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /orders/duplicates [get]
+func (h *OrderHandler) GetDuplicateOrders(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDuplicatesLimit)))
+	if err != nil || limit <= 0 || limit > maxDuplicatesLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	groups, err := h.orderService.GetDuplicateGroups(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get duplicate orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
 }
 
 // Index godoc
@@ -55,14 +172,3 @@ func (h *OrderHandler) GetOrderByUID(c *gin.Context) {
 func (h *OrderHandler) Index(c *gin.Context) {
 	c.HTML(http.StatusOK, "index.html", nil)
 }
-
-// HealthCheck godoc
-// @Summary Проверка состояния сервиса
-// @Description Возвращает статус сервиса (ok)
-// @Tags general
-// @Produce  json
-// @Success 200 {object} map[string]string
-// @Router /health [get]
-func (h *OrderHandler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
-}
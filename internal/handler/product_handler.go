@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/projection"
+	"github.com/shenikar/order-service/internal/service"
+)
+
+type ProductHandler struct {
+	orderService *service.OrderService
+	statsService *service.StatsService
+	piiPolicy    *projection.Policy
+}
+
+// NewProductHandler создает новый экземпляр ProductHandler
+func NewProductHandler(orderService *service.OrderService, statsService *service.StatsService, piiPolicy *projection.Policy) *ProductHandler {
+	return &ProductHandler{
+		orderService: orderService,
+		statsService: statsService,
+		piiPolicy:    piiPolicy,
+	}
+}
+
+// GetProductOrders возвращает заказы, в которых есть товар
+// @Summary Заказы с товаром
+// @Description Возвращает страницу заказов с товарами от новых к старым, в которых есть товар с артикулом nm_id
+// @Tags products
+// @Produce json
+// @Param nm_id path int true "Артикул товара"
+// @Param page_size query int false "Размер страницы (не больше 500)" default(50)
+// @Param page_token query string false "Токен следующей страницы"
+// @Param created_from query string false "Заказы, созданные не раньше (RFC3339)"
+// @Param created_to query string false "Заказы, созданные раньше (RFC3339)"
+// @Success 200 {object} models.OrderPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{nm_id}/orders [get]
+func (h *ProductHandler) GetProductOrders(c *gin.Context) {
+	nmID, ok := parseNmID(c)
+	if !ok {
+		return
+	}
+	h.listOrders(c, models.OrderFilter{NmID: nmID})
+}
+
+// GetBrandOrders возвращает заказы, в которых есть товары бренда
+// @Summary Заказы с товарами бренда
+// @Description Возвращает страницу заказов с товарами от новых к старым, в которых есть товары бренда
+// @Tags products
+// @Produce json
+// @Param brand path string true "Бренд"
+// @Param page_size query int false "Размер страницы (не больше 500)" default(50)
+// @Param page_token query string false "Токен следующей страницы"
+// @Param created_from query string false "Заказы, созданные не раньше (RFC3339)"
+// @Param created_to query string false "Заказы, созданные раньше (RFC3339)"
+// @Success 200 {object} models.OrderPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /brands/{brand}/orders [get]
+func (h *ProductHandler) GetBrandOrders(c *gin.Context) {
+	h.listOrders(c, models.OrderFilter{Brand: c.Param("brand")})
+}
+
+// GetProductStats возвращает продажи товара
+// @Summary Продажи товара
+// @Description Возвращает количество проданных единиц и заказов, выручку по валютам,
+// @Description распределение единиц по скидке и по размерам. Дубли заказов не учитываются
+// @Tags products
+// @Produce json
+// @Param nm_id path int true "Артикул товара"
+// @Param created_from query string false "Заказы, созданные не раньше (RFC3339)"
+// @Param created_to query string false "Заказы, созданные раньше (RFC3339)"
+// @Success 200 {object} models.ProductStats
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{nm_id}/stats [get]
+func (h *ProductHandler) GetProductStats(c *gin.Context) {
+	nmID, ok := parseNmID(c)
+	if !ok {
+		return
+	}
+	h.productStats(c, models.ProductStatsQuery{NmID: nmID})
+}
+
+// GetBrandStats возвращает продажи бренда
+// @Summary Продажи бренда
+// @Description Возвращает количество проданных единиц и заказов, выручку по валютам,
+// @Description распределение единиц по скидке и по размерам для всех товаров бренда. Дубли заказов не учитываются
+// @Tags products
+// @Produce json
+// @Param brand path string true "Бренд"
+// @Param created_from query string false "Заказы, созданные не раньше (RFC3339)"
+// @Param created_to query string false "Заказы, созданные раньше (RFC3339)"
+// @Success 200 {object} models.ProductStats
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /brands/{brand}/stats [get]
+func (h *ProductHandler) GetBrandStats(c *gin.Context) {
+	h.productStats(c, models.ProductStatsQuery{Brand: c.Param("brand")})
+}
+
+func (h *ProductHandler) listOrders(c *gin.Context, filter models.OrderFilter) {
+	var err error
+	if filter.CreatedFrom, err = parseTimeQuery(c, "created_from"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.CreatedTo, err = parseTimeQuery(c, "created_to"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(service.DefaultPageSize)))
+	if err != nil || pageSize <= 0 || pageSize > service.MaxPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_size"})
+		return
+	}
+
+	orders, nextPageToken, err := h.orderService.ListOrders(filter, c.Query("page_token"), pageSize)
+	if errors.Is(err, service.ErrInvalidPageToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_token"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list orders", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list orders"})
+		return
+	}
+
+	view := newOrderView(c, h.piiPolicy)
+	c.JSON(http.StatusOK, orderPageResponse{Orders: view.orders(orders), NextPageToken: nextPageToken})
+}
+
+func (h *ProductHandler) productStats(c *gin.Context, query models.ProductStatsQuery) {
+	var err error
+	if query.CreatedFrom, err = parseTimeQuery(c, "created_from"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.CreatedTo, err = parseTimeQuery(c, "created_to"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.statsService.GetProductStats(c.Request.Context(), query)
+	if errors.Is(err, service.ErrInvalidStatsQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrNoSales) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No sales found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to get product stats", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// parseNmID читает артикул товара из пути и отвечает 400, если он некорректен
+func parseNmID(c *gin.Context) (int, bool) {
+	nmID, err := strconv.Atoi(c.Param("nm_id"))
+	if err != nil || nmID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid nm_id"})
+		return 0, false
+	}
+	return nmID, true
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/internal/service"
+)
+
+type SearchHandler struct {
+	searchService *service.SearchService
+}
+
+// NewSearchHandler создает новый экземпляр SearchHandler
+func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// SearchOrders ищет заказы
+// @Summary Поиск заказов
+// @Description Ищет заказы по customer_id, имени, телефону, email, городу и адресу получателя,
+// @Description трек-номерам, названиям и брендам товаров. Слова запроса ищутся как начала слов,
+// @Description а также по триграммному сходству, поэтому находятся части слов и слова с опечатками.
+// @Description Совпадения в полях заказа обернуты в <mark></mark>
+// @Tags search
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Param limit query int false "Максимальное количество заказов (не больше 100)" default(20)
+// @Success 200 {array} models.SearchResult
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /search [get]
+func (h *SearchHandler) SearchOrders(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(service.DefaultSearchLimit)))
+	if err != nil || limit <= 0 || limit > service.MaxSearchLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	results, err := h.searchService.SearchOrders(c.Request.Context(), c.Query("q"), limit)
+	if errors.Is(err, service.ErrEmptySearchQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to search orders", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/service"
+)
+
+type StatsHandler struct {
+	statsService *service.StatsService
+}
+
+// NewStatsHandler создает новый экземпляр StatsHandler
+func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetOrderStats возвращает агрегаты заказов
+// @Summary Статистика заказов
+// @Description Возвращает количество заказов, суммы amount, goods_total и delivery_cost
+// @Description и среднее число товаров в заказе, сгруппированные по измерениям group_by.
+// @Description Валюта всегда входит в группировку, суммы указаны в минорных единицах валюты.
+// @Description Дубли заказов не учитываются. При группировке по brand заказ учитывается в каждом бренде, товары которого в нем есть.
+// @Description Если границы периода выровнены по часу и включены почасовые срезы, статистика считается по ним (source=rollup)
+// @Tags stats
+// @Produce json
+// @Param group_by query string false "Измерения через запятую: day, hour, currency, delivery_service, bank, provider, region, brand" default(day)
+// @Param created_from query string false "Заказы, созданные не раньше (RFC3339)"
+// @Param created_to query string false "Заказы, созданные раньше (RFC3339)"
+// @Success 200 {object} models.OrderStatsReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /stats/orders [get]
+func (h *StatsHandler) GetOrderStats(c *gin.Context) {
+	query := models.StatsQuery{
+		GroupBy: strings.Split(c.DefaultQuery("group_by", models.StatsByDay), ","),
+	}
+	var err error
+	if query.CreatedFrom, err = parseTimeQuery(c, "created_from"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.CreatedTo, err = parseTimeQuery(c, "created_to"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.statsService.GetOrderStats(c.Request.Context(), query)
+	if errors.Is(err, service.ErrInvalidStatsQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to get order stats", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
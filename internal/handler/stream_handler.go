@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/shenikar/order-service/internal/feed"
+)
+
+// heartbeatInterval - интервал служебных сообщений, не дающих прокси закрыть простаивающее соединение
+const heartbeatInterval = 15 * time.Second
+
+var upgrader = websocket.Upgrader{}
+
+type StreamHandler struct {
+	hub *feed.Hub
+}
+
+// NewStreamHandler создает новый экземпляр StreamHandler
+func NewStreamHandler(hub *feed.Hub) *StreamHandler {
+	return &StreamHandler{hub: hub}
+}
+
+// StreamOrders отправляет новые заказы по мере их сохранения
+// @Summary Лента новых заказов
+// @Description Отправляет краткие сведения о каждом новом заказе через Server-Sent Events
+// @Description (событие order) или WebSocket, если запрос содержит заголовок Upgrade: websocket.
+// @Description Для возобновления передайте идентификатор последнего события в Last-Event-ID или last_event_id
+// @Tags orders
+// @Produce text/event-stream
+// @Param customer_id query string false "Только заказы покупателя"
+// @Param delivery_service query string false "Только заказы службы доставки"
+// @Param last_event_id query int false "Идентификатор последнего полученного события"
+// @Param Last-Event-ID header int false "Идентификатор последнего полученного события"
+// @Success 200 {object} feed.Event
+// @Failure 400 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /orders/stream [get]
+func (h *StreamHandler) StreamOrders(c *gin.Context) {
+	lastEventID, err := parseLastEventID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+		return
+	}
+	filter := feed.Filter{
+		CustomerID:      c.Query("customer_id"),
+		DeliveryService: c.Query("delivery_service"),
+	}
+
+	sub, err := h.hub.Subscribe(c.Request.Context(), filter, lastEventID)
+	if errors.Is(err, feed.ErrClosed) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Order feed is unavailable"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe to order feed"})
+		return
+	}
+	defer h.hub.Unsubscribe(sub)
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.streamWebSocket(c, sub)
+		return
+	}
+	h.streamSSE(c, sub)
+}
+
+// streamSSE передает события в формате Server-Sent Events
+func (h *StreamHandler) streamSSE(c *gin.Context, sub *feed.Subscription) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Отключаем буферизацию ответа в nginx
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(event feed.Event) {
+		c.Render(-1, sse.Event{
+			Id:    strconv.FormatInt(event.ID, 10),
+			Event: "order",
+			Data:  event.Order,
+		})
+	}
+
+	for _, event := range sub.Backlog() {
+		send(event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			send(event)
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// streamWebSocket передает события JSON-сообщениями по WebSocket
+func (h *StreamHandler) streamWebSocket(c *gin.Context, sub *feed.Subscription) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade уже отправил клиенту ответ с ошибкой
+		slog.WarnContext(c.Request.Context(), "Failed to upgrade order feed connection", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Клиент ничего не отправляет, чтение нужно только для обработки ping/close
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range sub.Backlog() {
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "order feed stopped"))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseLastEventID читает позицию возобновления из заголовка Last-Event-ID
+// (его отправляет EventSource при переподключении) или параметра last_event_id
+func parseLastEventID(c *gin.Context) (int64, error) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid last event id")
+	}
+	return id, nil
+}
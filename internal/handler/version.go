@@ -0,0 +1,29 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+// Версии представления ответов HTTP API
+const (
+	APIVersion1 = "v1" // models.Order
+	APIVersion2 = "v2" // models.OrderV2: время в RFC 3339, суммы - объекты Money
+)
+
+// apiVersionKey - ключ версии API в контексте Gin
+const apiVersionKey = "api_version"
+
+// WithAPIVersion сохраняет версию API в контексте запроса;
+// по ней обработчики выбирают представление заказов в ответе
+func WithAPIVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, version)
+		c.Next()
+	}
+}
+
+// apiVersion возвращает версию API запроса, по умолчанию - v1
+func apiVersion(c *gin.Context) string {
+	if version := c.GetString(apiVersionKey); version != "" {
+		return version
+	}
+	return APIVersion1
+}
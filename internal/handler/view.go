@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/internal/auth"
+	"github.com/shenikar/order-service/internal/mapper"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/projection"
+)
+
+// orderView - представление заказов в ответе: версия API и уровень доступа клиента к данным заказа
+type orderView struct {
+	version string
+	level   string
+}
+
+// newOrderView определяет представление заказов для запроса. Уровень доступа
+// берется из ролей клиента по policy; если аутентификация отключена, заказ отдается целиком
+func newOrderView(c *gin.Context, policy *projection.Policy) orderView {
+	view := orderView{version: apiVersion(c), level: projection.LevelTracking}
+	if identity, ok := auth.IdentityFromGin(c); ok {
+		if identity.Method == auth.MethodNone {
+			view.level = projection.LevelFull
+		} else {
+			view.level = policy.Level(identity.Roles)
+		}
+	}
+	return view
+}
+
+// order возвращает заказ в представлении: models.OrderTracking для уровня tracking,
+// иначе models.Order (v1) или models.OrderV2 (v2) с данными, ограниченными уровнем доступа
+func (v orderView) order(order *models.Order) any {
+	if v.level == projection.LevelTracking {
+		return projection.Tracking(order)
+	}
+	order = projection.Apply(v.level, order)
+	if v.version == APIVersion2 {
+		return mapper.MapOrderToV2(order)
+	}
+	return order
+}
+
+// orders возвращает список заказов в представлении
+func (v orderView) orders(orders []models.Order) []any {
+	result := make([]any, 0, len(orders))
+	for i := range orders {
+		result = append(result, v.order(&orders[i]))
+	}
+	return result
+}
+
+// orderPageResponse - страница заказов в представлении клиента, в v1 с полным доступом совпадает с models.OrderPage
+type orderPageResponse struct {
+	Orders        []any  `json:"orders"`
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+// batchGetOrdersResponse - результат пакетного запроса в представлении клиента,
+// в v1 с полным доступом совпадает с models.BatchGetOrdersResult
+type batchGetOrdersResponse struct {
+	Orders  []any    `json:"orders"`
+	Missing []string `json:"missing"`
+}
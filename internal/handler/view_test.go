@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/internal/auth"
+	"github.com/shenikar/order-service/internal/projection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOrderView(t *testing.T) {
+	policy, err := projection.ParsePolicy("support=full,analyst=masked,partner=tracking")
+	require.NoError(t, err)
+
+	viewFor := func(identity *auth.Identity, version string) orderView {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		if identity != nil {
+			c.Set(auth.IdentityKey, identity)
+		}
+		if version != "" {
+			c.Set(apiVersionKey, version)
+		}
+		return newOrderView(c, policy)
+	}
+
+	assert.Equal(t, orderView{version: APIVersion1, level: projection.LevelFull},
+		viewFor(&auth.Identity{Subject: "anonymous", Method: auth.MethodNone}, ""))
+	assert.Equal(t, orderView{version: APIVersion2, level: projection.LevelMasked},
+		viewFor(&auth.Identity{Subject: "bi", Method: auth.MethodJWT, Roles: []string{auth.RoleAnalyst}}, APIVersion2))
+	assert.Equal(t, orderView{version: APIVersion1, level: projection.LevelTracking},
+		viewFor(&auth.Identity{Subject: "partner-x", Method: auth.MethodAPIKey, Roles: []string{auth.RolePartner}}, APIVersion1))
+	// Без данных клиента доступ минимальный
+	assert.Equal(t, projection.LevelTracking, viewFor(nil, "").level)
+}
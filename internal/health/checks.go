@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Pinger - соединение, доступность которого можно проверить
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Database проверяет доступность БД
+func Database(db Pinger) Check {
+	return func(ctx context.Context) Result {
+		if err := db.PingContext(ctx); err != nil {
+			return Down(err, nil)
+		}
+		return Up(nil)
+	}
+}
+
+// RowQuerier - соединение, выполняющее запросы из одной строки
+type RowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// MigrationDetails - версия схемы БД
+type MigrationDetails struct {
+	Version  uint `json:"version"`
+	Expected uint `json:"expected"`
+	Dirty    bool `json:"dirty"`
+}
+
+// Migrations проверяет, что схема БД не ниже версии expected, примененной при запуске,
+// и последняя миграция завершилась (golang-migrate помечает прерванную миграцию как dirty)
+func Migrations(db RowQuerier, expected uint) Check {
+	return func(ctx context.Context) Result {
+		details := MigrationDetails{Expected: expected}
+		err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").
+			Scan(&details.Version, &details.Dirty)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Down(errors.New("no migrations applied"), details)
+		}
+		if err != nil {
+			return Down(err, nil)
+		}
+		if details.Dirty {
+			return Down(fmt.Errorf("migration %d is dirty", details.Version), details)
+		}
+		if details.Version < expected {
+			return Down(fmt.Errorf("schema version %d is behind expected %d", details.Version, expected), details)
+		}
+		return Up(details)
+	}
+}
+
+// WarmupDetails - состояние прогрева
+type WarmupDetails struct {
+	Done bool `json:"done"`
+}
+
+// Warmup проверяет завершение прогрева, состояние которого возвращает status.
+// Пока прогрев идет, сервис не готов. Неудачный прогрев не мешает обслуживать
+// запросы, поэтому компонент считается работающим с ограничениями
+func Warmup(status func() (done bool, err error)) Check {
+	return func(ctx context.Context) Result {
+		done, err := status()
+		details := WarmupDetails{Done: done}
+		switch {
+		case !done:
+			return Down(errors.New("warm-up in progress"), details)
+		case err != nil:
+			return Degraded(err, details)
+		default:
+			return Up(details)
+		}
+	}
+}
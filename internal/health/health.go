@@ -0,0 +1,128 @@
+// Package health проверяет готовность сервиса обслуживать запросы:
+// доступность БД, версию схемы, состояние чтения Kafka и прогрев кэша
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Состояния компонента
+const (
+	StatusUp       = "up"       // компонент работает
+	StatusDegraded = "degraded" // компонент работает с ограничениями, сервис готов
+	StatusDown     = "down"     // компонент не работает, сервис не готов
+)
+
+// Состояния сервиса в отчете о готовности
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// Result - состояние компонента
+type Result struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	Details    any     `json:"details,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Up возвращает состояние работающего компонента
+func Up(details any) Result {
+	return Result{Status: StatusUp, Details: details}
+}
+
+// Degraded возвращает состояние компонента, который работает с ограничениями
+func Degraded(err error, details any) Result {
+	return Result{Status: StatusDegraded, Error: err.Error(), Details: details}
+}
+
+// Down возвращает состояние неработающего компонента
+func Down(err error, details any) Result {
+	return Result{Status: StatusDown, Error: err.Error(), Details: details}
+}
+
+// Check проверяет состояние компонента. Проверка должна завершаться при отмене ctx
+type Check func(ctx context.Context) Result
+
+// Report - отчет о готовности сервиса с состоянием каждого компонента
+type Report struct {
+	Status     string            `json:"status"`
+	Components map[string]Result `json:"components"`
+}
+
+// Ready сообщает, готов ли сервис: ни один компонент не в состоянии StatusDown
+func (r Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+// Checker выполняет зарегистрированные проверки компонентов
+type Checker struct {
+	timeout time.Duration
+	checks  map[string]Check
+}
+
+// NewChecker создает Checker; каждая проверка ограничена временем timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Register добавляет проверку компонента name
+func (c *Checker) Register(name string, check Check) {
+	c.checks[name] = check
+}
+
+// Check параллельно выполняет все проверки и собирает отчет. Проверка, не уложившаяся
+// в отведенное время, считается неудачной
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, c.checks[name])
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Components: make(map[string]Result, len(names))}
+	for i, name := range names {
+		report.Components[name] = results[i]
+		if results[i].Status == StatusDown {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+// run выполняет проверку, не дожидаясь ее дольше, чем позволяет ctx
+func run(ctx context.Context, check Check) Result {
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() { done <- check(ctx) }()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Down(ctx.Err(), nil)
+	}
+	result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	return result
+}
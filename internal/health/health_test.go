@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) PingContext(ctx context.Context) error { return f(ctx) }
+
+func TestChecker_Check(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Register("database", Database(pingerFunc(func(context.Context) error { return nil })))
+	c.Register("cache", Warmup(func() (bool, error) { return true, errors.New("orders table is empty") }))
+
+	report := c.Check(context.Background())
+	assert.True(t, report.Ready(), "degraded components do not block readiness")
+	assert.Equal(t, StatusUp, report.Components["database"].Status)
+	assert.Equal(t, StatusDegraded, report.Components["cache"].Status)
+	assert.Equal(t, "orders table is empty", report.Components["cache"].Error)
+
+	c.Register("database", Database(pingerFunc(func(context.Context) error { return errors.New("connection refused") })))
+	report = c.Check(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, StatusNotReady, report.Status)
+	assert.Equal(t, Result{Status: StatusDown, Error: "connection refused"}, withoutDuration(report.Components["database"]))
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	c := NewChecker(10 * time.Millisecond)
+	c.Register("stuck", func(context.Context) Result {
+		time.Sleep(time.Second)
+		return Up(nil)
+	})
+
+	start := time.Now()
+	report := c.Check(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, report.Ready())
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Components["stuck"].Error)
+}
+
+func TestWarmup(t *testing.T) {
+	check := Warmup(func() (bool, error) { return false, nil })
+	assert.Equal(t, StatusDown, check(context.Background()).Status)
+
+	check = Warmup(func() (bool, error) { return true, nil })
+	assert.Equal(t, Up(WarmupDetails{Done: true}), check(context.Background()))
+}
+
+func withoutDuration(r Result) Result {
+	r.DurationMs = 0
+	return r
+}
@@ -0,0 +1,212 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// maxLineSize - максимальный размер одной строки NDJSON
+const maxLineSize = 16 << 20
+
+// OrderService - операции сервиса заказов, нужные для импорта
+type OrderService interface {
+	Validate(order *models.Order) error
+	ImportOrders(orders []*models.Order) ([]error, error)
+}
+
+// Reject - строка файла, которая не была импортирована
+type Reject struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	OrderUID string `json:"order_uid,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// Stats - итог импорта
+type Stats struct {
+	Read     int
+	Imported int
+	Rejected int
+}
+
+// Importer читает заказы из NDJSON, проверяет их и сохраняет пачками.
+// Отклоненные строки с причинами пишутся в отчет в формате NDJSON
+type Importer struct {
+	service   OrderService
+	batchSize int
+	report    *json.Encoder
+
+	stats Stats
+	batch []pendingOrder
+}
+
+type pendingOrder struct {
+	order *models.Order
+	file  string
+	line  int
+}
+
+// New создает новый экземпляр Importer
+func New(service OrderService, batchSize int, report io.Writer) *Importer {
+	return &Importer{
+		service:   service,
+		batchSize: batchSize,
+		report:    json.NewEncoder(report),
+	}
+}
+
+// Stats возвращает итог импорта
+func (im *Importer) Stats() Stats {
+	return im.stats
+}
+
+// ImportFile импортирует файл NDJSON, сжатый gzip файл распознается по сигнатуре
+func (im *Importer) ImportFile(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	return im.Import(ctx, path, f)
+}
+
+// Import импортирует заказы из r, name используется в отчете об отклоненных строках
+func (im *Importer) Import(ctx context.Context, name string, r io.Reader) error {
+	input, err := decompress(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line++
+
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		im.stats.Read++
+
+		var order models.Order
+		if err := json.Unmarshal(data, &order); err != nil {
+			if err := im.reject(Reject{File: name, Line: line, Reason: "invalid json: " + err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := im.service.Validate(&order); err != nil {
+			if err := im.reject(Reject{File: name, Line: line, OrderUID: order.OrderUID, Reason: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		im.batch = append(im.batch, pendingOrder{order: &order, file: name, line: line})
+		if len(im.batch) >= im.batchSize {
+			if err := im.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s at line %d: %w", name, line+1, err)
+	}
+
+	return nil
+}
+
+// Flush сохраняет накопленную пачку. Если транзакция пачки не удалась, заказы
+// сохраняются по одному, и в отчет попадают только те, что не удалось сохранить
+func (im *Importer) Flush() error {
+	if len(im.batch) == 0 {
+		return nil
+	}
+	batch := im.batch
+	im.batch = nil
+
+	orders := make([]*models.Order, len(batch))
+	for i, pending := range batch {
+		orders[i] = pending.order
+	}
+
+	orderErrs, err := im.service.ImportOrders(orders)
+	if err != nil {
+		orderErrs = im.importOneByOne(orders, err)
+	}
+
+	for i, pending := range batch {
+		if orderErrs[i] == nil {
+			im.stats.Imported++
+			continue
+		}
+		rejectErr := im.reject(Reject{
+			File:     pending.file,
+			Line:     pending.line,
+			OrderUID: pending.order.OrderUID,
+			Reason:   orderErrs[i].Error(),
+		})
+		if rejectErr != nil {
+			return rejectErr
+		}
+	}
+
+	return nil
+}
+
+// importOneByOne сохраняет заказы отдельными транзакциями после ошибки batchErr
+// транзакции всей пачки и возвращает ошибки по заказам
+func (im *Importer) importOneByOne(orders []*models.Order, batchErr error) []error {
+	orderErrs := make([]error, len(orders))
+	if len(orders) == 1 {
+		orderErrs[0] = batchErr
+		return orderErrs
+	}
+
+	for i, order := range orders {
+		errs, err := im.service.ImportOrders([]*models.Order{order})
+		if err == nil {
+			err = errs[0]
+		}
+		orderErrs[i] = err
+	}
+	return orderErrs
+}
+
+func (im *Importer) reject(reject Reject) error {
+	im.stats.Rejected++
+	if err := im.report.Encode(reject); err != nil {
+		return fmt.Errorf("failed to write rejects report: %w", err)
+	}
+	return nil
+}
+
+// decompress распаковывает gzip, если поток начинается с его сигнатуры
+func decompress(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err == io.EOF {
+		return buffered, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
@@ -0,0 +1,130 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeService struct {
+	batches  [][]string
+	saveErr  error
+	orderErr map[string]error
+	// txErr - ошибка транзакции пачки, в которой есть заказ с этим UID
+	txErr map[string]error
+}
+
+func (s *fakeService) Validate(order *models.Order) error {
+	if order.OrderUID == "" {
+		return errors.New("order_uid is required")
+	}
+	return nil
+}
+
+func (s *fakeService) ImportOrders(orders []*models.Order) ([]error, error) {
+	var uids []string
+	orderErrs := make([]error, len(orders))
+	for i, order := range orders {
+		uids = append(uids, order.OrderUID)
+		orderErrs[i] = s.orderErr[order.OrderUID]
+	}
+	s.batches = append(s.batches, uids)
+	for _, uid := range uids {
+		if err := s.txErr[uid]; err != nil {
+			return nil, err
+		}
+	}
+	return orderErrs, s.saveErr
+}
+
+func readRejects(t *testing.T, report *bytes.Buffer) []Reject {
+	var rejects []Reject
+	for _, line := range strings.Split(strings.TrimSpace(report.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var reject Reject
+		require.NoError(t, json.Unmarshal([]byte(line), &reject))
+		rejects = append(rejects, reject)
+	}
+	return rejects
+}
+
+const input = `{"order_uid":"a"}
+
+{"order_uid":"b"}
+not json
+{"customer_id":"no-uid"}
+{"order_uid":"c"}
+`
+
+func TestImport_BatchesAndRejects(t *testing.T) {
+	svc := &fakeService{orderErr: map[string]error{"c": errors.New("duplicate order")}}
+	var report bytes.Buffer
+	im := New(svc, 2, &report)
+
+	require.NoError(t, im.Import(context.Background(), "orders.ndjson", strings.NewReader(input)))
+	require.NoError(t, im.Flush())
+
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, svc.batches)
+	assert.Equal(t, Stats{Read: 5, Imported: 2, Rejected: 3}, im.Stats())
+
+	rejects := readRejects(t, &report)
+	require.Len(t, rejects, 3)
+	assert.Equal(t, 4, rejects[0].Line)
+	assert.Contains(t, rejects[0].Reason, "invalid json")
+	assert.Equal(t, Reject{File: "orders.ndjson", Line: 5, Reason: "order_uid is required"}, rejects[1])
+	assert.Equal(t, Reject{File: "orders.ndjson", Line: 6, OrderUID: "c", Reason: "duplicate order"}, rejects[2])
+}
+
+func TestImport_FailedBatchRetriedOneByOne(t *testing.T) {
+	svc := &fakeService{txErr: map[string]error{"b": errors.New(`duplicate key value violates unique constraint "items_pkey"`)}}
+	var report bytes.Buffer
+	im := New(svc, 10, &report)
+
+	require.NoError(t, im.Import(context.Background(), "orders.ndjson", strings.NewReader(input)))
+	require.NoError(t, im.Flush())
+
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"a"}, {"b"}, {"c"}}, svc.batches)
+	assert.Equal(t, Stats{Read: 5, Imported: 2, Rejected: 3}, im.Stats())
+	rejects := readRejects(t, &report)
+	require.Len(t, rejects, 3)
+	assert.Equal(t, Reject{File: "orders.ndjson", Line: 3, OrderUID: "b", Reason: `duplicate key value violates unique constraint "items_pkey"`}, rejects[2])
+}
+
+func TestImport_FailedBatchRejectsAllOrders(t *testing.T) {
+	svc := &fakeService{saveErr: errors.New("connection reset")}
+	var report bytes.Buffer
+	im := New(svc, 10, &report)
+
+	require.NoError(t, im.Import(context.Background(), "orders.ndjson", strings.NewReader(input)))
+	require.NoError(t, im.Flush())
+
+	assert.Equal(t, Stats{Read: 5, Imported: 0, Rejected: 5}, im.Stats())
+	rejects := readRejects(t, &report)
+	require.Len(t, rejects, 5)
+	assert.Equal(t, "connection reset", rejects[4].Reason)
+}
+
+func TestImport_Gzip(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	svc := &fakeService{}
+	im := New(svc, 100, &bytes.Buffer{})
+	require.NoError(t, im.Import(context.Background(), "orders.ndjson.gz", &compressed))
+	require.NoError(t, im.Flush())
+
+	assert.Equal(t, [][]string{{"a", "b", "c"}}, svc.batches)
+}
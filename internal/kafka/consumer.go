@@ -2,22 +2,96 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"log"
+	"io"
+	"log/slog"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/codec"
+	"github.com/shenikar/order-service/internal/logging"
+	"github.com/shenikar/order-service/internal/metrics"
 	"github.com/shenikar/order-service/internal/models"
 	"github.com/shenikar/order-service/internal/service"
+	"github.com/shenikar/order-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var DLQWriter *kafka.Writer
 
-// StartConsumer запускает Kafka consumer для обработки сообщений
-func StartConsumer(ctx context.Context, cfg *config.Config, orderService *service.OrderService) *kafka.Reader {
+// retryDelay - пауза перед повторной обработкой сообщения после ошибки сохранения
+const retryDelay = time.Second
+
+// Consumer читает заказы из партиций топика, которые consumer group назначила этому экземпляру
+// сервиса. Позиции чтения хранятся в PostgreSQL и фиксируются в одной транзакции с заказом;
+// при каждом назначении партиции чтение продолжается с позиции из БД, поэтому после сбоя
+// или перебалансировки группы сообщения не обрабатываются повторно
+type Consumer struct {
+	group *kafka.ConsumerGroup
+	done  chan struct{}
+
+	mu      sync.Mutex
+	readers map[int]*partitionReader
+	// Участие в consumer group для проверки готовности
+	joined   bool  // экземпляр хотя бы раз вступил в группу
+	joinErr  error // ошибка последней попытки вступить в группу
+	assigned []int // партиции, назначенные в текущем поколении группы
+}
+
+// setGeneration запоминает партиции, назначенные в новом поколении группы
+func (c *Consumer) setGeneration(partitions []int) {
+	c.mu.Lock()
+	c.joined, c.joinErr, c.assigned = true, nil, partitions
+	c.mu.Unlock()
+}
+
+// setJoinError запоминает ошибку вступления в группу. Назначения прошлого поколения
+// уже недействительны
+func (c *Consumer) setJoinError(err error) {
+	c.mu.Lock()
+	c.joinErr, c.assigned = err, nil
+	c.mu.Unlock()
+}
+
+// partitionReader - reader одной партиции и состояние чтения из нее
+type partitionReader struct {
+	*kafka.Reader
+	partition int
+
+	mu      sync.Mutex
+	running bool
+	lastErr error // последняя ошибка чтения, сбрасывается после успешного чтения
+}
+
+// setRunning отмечает, что чтение партиции запущено или остановлено
+func (r *partitionReader) setRunning(running bool) {
+	r.mu.Lock()
+	r.running = running
+	r.mu.Unlock()
+}
+
+// setError запоминает результат последнего чтения партиции
+func (r *partitionReader) setError(err error) {
+	r.mu.Lock()
+	r.lastErr = err
+	r.mu.Unlock()
+}
+
+// offsetStore - хранилище позиций чтения партиций
+type offsetStore interface {
+	GetOffsets(topic string) ([]models.KafkaOffset, error)
+}
+
+// StartConsumer вступает в consumer group и запускает чтение назначенных партиций
+func StartConsumer(ctx context.Context, cfg *config.Config, orderService *service.OrderService) *Consumer {
 	InitDLQWriter(cfg)
 	dialer := &kafka.Dialer{
 		Timeout:   10 * time.Second,
@@ -26,82 +100,347 @@ func StartConsumer(ctx context.Context, cfg *config.Config, orderService *servic
 
 	// Проверяем и создаём топик при необходимости
 	if err := ensureTopic(cfg, dialer); err != nil {
-		log.Fatalf("failed to ensure topic exists: %v", err)
+		logging.Fatal("Failed to ensure topic exists", "topic", cfg.Kafka.Topic, "error", err)
 	}
 
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:        cfg.Kafka.Brokers,
-		Topic:          cfg.Kafka.Topic,
-		GroupID:        cfg.Kafka.GroupID,
-		StartOffset:    kafka.FirstOffset,
-		Dialer:         dialer,
-		MinBytes:       1,
-		MaxBytes:       10e6,
-		CommitInterval: 0,
+	decoders, err := codec.NewRegistry(cfg.Kafka.MessageFormat)
+	if err != nil {
+		logging.Fatal("Failed to create message decoders", "error", err)
+	}
+
+	// Группа только распределяет партиции между экземплярами сервиса;
+	// позиции чтения берутся из БД, а не из закоммиченных offset'ов группы
+	group, err := kafka.NewConsumerGroup(kafka.ConsumerGroupConfig{
+		ID:      cfg.Kafka.GroupID,
+		Brokers: cfg.Kafka.Brokers,
+		Dialer:  dialer,
+		Topics:  []string{cfg.Kafka.Topic},
+		// Добавленные в топик партиции распределяются без перезапуска сервиса
+		WatchPartitionChanges: true,
+		StartOffset:           kafka.FirstOffset,
 	})
+	if err != nil {
+		logging.Fatal("Failed to create Kafka consumer group", "group", cfg.Kafka.GroupID, "error", err)
+	}
 
+	consumer := &Consumer{
+		group:   group,
+		done:    make(chan struct{}),
+		readers: make(map[int]*partitionReader),
+	}
 	go func() {
-		for {
-			msg, err := reader.FetchMessage(ctx)
-			if err != nil {
-				if errors.Is(err, context.Canceled) {
-					log.Println("Kafka consumer context canceled, stopping")
-					return
-				}
-				if errors.Is(err, context.DeadlineExceeded) {
-					log.Println("Kafka consumer context deadline exceeded, stopping")
-					return
-				}
-				log.Printf("Failed to read message: %v", err)
-				continue
-			}
+		defer close(consumer.done)
+		consumer.run(ctx, cfg, dialer, decoders, orderService)
+	}()
+	go consumer.reportLag(ctx)
 
-			order := &models.Order{}
-			if err := json.Unmarshal(msg.Value, order); err != nil {
-				log.Printf("Invalid JSON, ignoring: %v", err)
-				_ = reader.CommitMessages(ctx, msg)
-				continue
-			}
+	return consumer
+}
 
-			// Валидация всех полей через validator
-			if !orderService.ValidateOrder(order) {
-				log.Printf("Invalid order data, ignoring: %+v", order)
-				sendToDLQ(msg.Value)
-				_ = reader.CommitMessages(ctx, msg)
-				continue
+// run получает от consumer group назначения партиций и читает каждую назначенную партицию
+// до конца поколения группы (перебалансировки) или отмены ctx
+func (c *Consumer) run(ctx context.Context, cfg *config.Config, dialer *kafka.Dialer, decoders *codec.Registry, orderService *service.OrderService) {
+	for {
+		gen, err := c.group.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, kafka.ErrGroupClosed) {
+				slog.Info("Kafka consumer group closed, stopping", "group", cfg.Kafka.GroupID)
+				return
 			}
-
-			// Сохранение в БД
-			if err := orderService.SaveOrder(order); err != nil {
-				log.Printf("Failed to save order: %v", err)
-				// Не коммитим сообщение — оно будет прочитано снова, можно будет восстановить обработку
-				continue
+			slog.Error("Failed to join Kafka consumer group", "group", cfg.Kafka.GroupID, "error", err)
+			c.setJoinError(err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
 			}
+			continue
+		}
 
-			// Коммитим сообщение после успешной обработки
-			if err := reader.CommitMessages(ctx, msg); err != nil {
-				log.Printf("Failed to commit message %s: %v", order.OrderUID, err)
-			} else {
-				log.Printf("Order processed: %s", order.OrderUID)
+		assignments := gen.Assignments[cfg.Kafka.Topic]
+		partitions := make([]int, 0, len(assignments))
+		for _, assignment := range assignments {
+			partitions = append(partitions, assignment.ID)
+		}
+		slog.Info("Kafka partitions assigned", "group", gen.GroupID, "generation", gen.ID, "partitions", partitions)
+		c.setGeneration(partitions)
+
+		for _, assignment := range assignments {
+			gen.Start(func(genCtx context.Context) {
+				// Чтение останавливается и при завершении сервиса, и при перебалансировке
+				ctx, cancel := context.WithCancel(ctx)
+				defer cancel()
+				stop := context.AfterFunc(genCtx, cancel)
+				defer stop()
+
+				c.consumePartition(ctx, cfg, dialer, decoders, orderService, assignment)
+			})
+		}
+	}
+}
+
+// consumePartition читает назначенную партицию с позиции, сохраненной в БД, до отмены ctx
+func (c *Consumer) consumePartition(
+	ctx context.Context, cfg *config.Config, dialer *kafka.Dialer, decoders *codec.Registry,
+	orderService *service.OrderService, assignment kafka.PartitionAssignment,
+) {
+	startOffset, err := loadStartOffset(ctx, orderService, cfg.Kafka.Topic, assignment)
+	if err != nil {
+		return
+	}
+
+	reader, err := newPartitionReader(cfg, dialer, assignment.ID, startOffset)
+	if err != nil {
+		slog.Error("Failed to set offset", "topic", cfg.Kafka.Topic, "partition", assignment.ID, "offset", startOffset, "error", err)
+		return
+	}
+	c.addReader(reader)
+	defer c.removeReader(reader)
+
+	slog.Info("Kafka partition consumer started", "topic", cfg.Kafka.Topic, "partition", assignment.ID, "offset", startOffset)
+	consume(ctx, reader, decoders, orderService)
+}
+
+// loadStartOffset возвращает позицию, с которой нужно читать партицию. Позиция читается
+// при каждом назначении, потому что до перебалансировки партицию мог читать другой экземпляр.
+// Ошибки чтения из БД повторяются до отмены ctx, чтобы не начать чтение с неверной позиции
+func loadStartOffset(ctx context.Context, store offsetStore, topic string, assignment kafka.PartitionAssignment) (int64, error) {
+	for {
+		stored, err := store.GetOffsets(topic)
+		if err == nil {
+			return startOffset(stored, assignment), nil
+		}
+		slog.ErrorContext(ctx, "Failed to load stored offsets", "topic", topic, "partition", assignment.ID, "error", err)
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// startOffset возвращает сохраненную в БД позицию партиции, а если ее нет - закоммиченную
+// в consumer group (при переходе с версии, которая фиксировала offset'ы в группе) или начало партиции
+func startOffset(stored []models.KafkaOffset, assignment kafka.PartitionAssignment) int64 {
+	for _, offset := range stored {
+		if offset.Partition == assignment.ID {
+			return offset.NextOffset
+		}
+	}
+	return assignment.Offset
+}
+
+// newPartitionReader создает reader партиции, установленный на позицию offset
+func newPartitionReader(cfg *config.Config, dialer *kafka.Dialer, partition int, offset int64) (*partitionReader, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   cfg.Kafka.Brokers,
+		Topic:     cfg.Kafka.Topic,
+		Partition: partition,
+		Dialer:    dialer,
+		MinBytes:  1,
+		MaxBytes:  10e6,
+		// Отставание запрашивается у брокера периодически, а не только при чтении сообщений
+		ReadLagInterval: lagReportInterval,
+	})
+	if err := reader.SetOffset(offset); err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	return &partitionReader{Reader: reader, partition: partition, running: true}, nil
+}
+
+// addReader регистрирует reader назначенной партиции
+func (c *Consumer) addReader(reader *partitionReader) {
+	c.mu.Lock()
+	c.readers[reader.partition] = reader
+	c.mu.Unlock()
+}
+
+// removeReader закрывает reader отозванной партиции. Отставание партиции больше
+// не публикуется: ее читает другой экземпляр сервиса
+func (c *Consumer) removeReader(reader *partitionReader) {
+	c.mu.Lock()
+	if c.readers[reader.partition] == reader {
+		delete(c.readers, reader.partition)
+	}
+	c.mu.Unlock()
+
+	reader.setRunning(false)
+	metrics.KafkaConsumerLag.DeleteLabelValues(reader.Config().Topic, strconv.Itoa(reader.partition))
+	if err := reader.Close(); err != nil {
+		slog.Error("Failed to close Kafka reader", "partition", reader.partition, "error", err)
+	}
+}
+
+// partitionReaders возвращает reader'ы назначенных партиций в порядке номеров партиций
+func (c *Consumer) partitionReaders() []*partitionReader {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	readers := make([]*partitionReader, 0, len(c.readers))
+	for _, reader := range c.readers {
+		readers = append(readers, reader)
+	}
+	slices.SortFunc(readers, func(a, b *partitionReader) int { return a.partition - b.partition })
+	return readers
+}
+
+// consume обрабатывает сообщения одной партиции до отмены контекста
+func consume(ctx context.Context, reader *partitionReader, decoders *codec.Registry, orderService *service.OrderService) {
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				slog.Info("Kafka partition consumer stopped", "partition", reader.partition)
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				slog.Info("Kafka consumer context deadline exceeded, stopping", "partition", reader.partition)
+				return
 			}
+			if errors.Is(err, io.EOF) {
+				slog.Info("Kafka reader closed, stopping", "partition", reader.partition)
+				return
+			}
+			slog.Error("Failed to read message", "partition", reader.partition, "error", err)
+			reader.setError(err)
+			continue
+		}
+		reader.setError(nil)
+
+		handleMessage(ctx, reader, decoders, orderService, msg)
+	}
+}
+
+// handleMessage декодирует, проверяет и сохраняет заказ из сообщения msg. Span обработки
+// продолжает трассировку, контекст которой передан в заголовках сообщения
+func handleMessage(ctx context.Context, reader *partitionReader, decoders *codec.Registry, orderService *service.OrderService, msg kafka.Message) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier{headers: &msg.Headers})
+	ctx, span := tracer.Start(ctx, "process "+msg.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingDestinationName(msg.Topic),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(msg.Partition)),
+			semconv.MessagingKafkaOffset(int(msg.Offset)),
+		),
+	)
+	var err error
+	defer func() { tracing.End(span, err) }()
 
+	logger := slog.With("topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset)
+	metrics.KafkaMessagesConsumedTotal.WithLabelValues(msg.Topic, strconv.Itoa(msg.Partition)).Inc()
+
+	offset := models.KafkaOffset{
+		Topic:      msg.Topic,
+		Partition:  msg.Partition,
+		NextOffset: msg.Offset + 1,
+	}
+
+	// Формат и версия схемы определяются заголовками сообщения
+	_, decodeSpan := tracer.Start(ctx, "order.decode")
+	order, err := decoders.Decode(
+		headerValue(msg, codec.HeaderContentType),
+		headerValue(msg, codec.HeaderSchemaVersion),
+		msg.Value,
+	)
+	tracing.End(decodeSpan, err)
+	if errors.Is(err, codec.ErrSchemaViolation) {
+		// Сообщение разобрано, но не соответствует JSON Schema своей версии - это ошибка валидации
+		logger.WarnContext(ctx, "Order violates JSON Schema, ignoring", "error", err)
+		rejectInvalid(ctx, logger, orderService, msg, offset, err)
+		return
+	}
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to decode message, ignoring", "error", err)
+		metrics.KafkaDecodeFailuresTotal.WithLabelValues(msg.Topic).Inc()
+		sendToDLQ(ctx, logger, msg, dlqReasonDecode)
+		skipMessage(ctx, logger, orderService, offset)
+		return
+	}
+	span.SetAttributes(attribute.String("order.uid", order.OrderUID))
+	logger = logger.With("order_uid", order.OrderUID)
+
+	// Валидация всех полей через validator
+	// Персональные данные получателя скрываются в журнале через models.Delivery.LogValue
+	_, validateSpan := tracer.Start(ctx, "order.validate")
+	err = orderService.Validate(order)
+	tracing.End(validateSpan, err)
+	if err != nil {
+		logger.WarnContext(ctx, "Invalid order, ignoring", "error", err, "order", order)
+		rejectInvalid(ctx, logger, orderService, msg, offset, err)
+		return
+	}
+
+	// Сохранение в БД вместе с позицией чтения
+	start := time.Now()
+	err = orderService.SaveOrderWithOffset(ctx, order, offset)
+	saveDuration := time.Since(start).Seconds()
+	if err != nil {
+		if errors.Is(err, service.ErrDuplicateOrder) {
+			metrics.OrderSaveDuration.WithLabelValues("duplicate").Observe(saveDuration)
+			logger.InfoContext(ctx, "Duplicate order, ignoring", "error", err)
+			err = nil
+			skipMessage(ctx, logger, orderService, offset)
+			return
 		}
-	}()
+		metrics.OrderSaveDuration.WithLabelValues("error").Observe(saveDuration)
+		countSaveFailure(offset, err)
+		logger.ErrorContext(ctx, "Failed to save order", "error", err)
+		// Позиция не сохранена — перечитываем сообщение после паузы
+		retryMessage(ctx, logger, reader.Reader, msg)
+		return
+	}
 
-	return reader
+	metrics.OrderSaveDuration.WithLabelValues("ok").Observe(saveDuration)
+	observeCommitted(order.DateCreated)
+	logger.InfoContext(ctx, "Order processed")
 }
 
-// StopConsumer корректно завершает работу Kafka consumer
-func StopConsumer(reader *kafka.Reader, cancel context.CancelFunc) {
+// rejectInvalid учитывает нарушенные правила валидации, отправляет сообщение в DLQ
+// с причиной validation и сохраняет позицию после него
+func rejectInvalid(ctx context.Context, logger *slog.Logger, orderService *service.OrderService, msg kafka.Message, offset models.KafkaOffset, err error) {
+	for _, rule := range validationRules(err) {
+		metrics.OrderValidationFailuresTotal.WithLabelValues(rule).Inc()
+	}
+	sendToDLQ(ctx, logger, msg, dlqReasonValidation)
+	skipMessage(ctx, logger, orderService, offset)
+}
+
+// skipMessage сохраняет позицию чтения после сообщения, которое не будет сохранено
+func skipMessage(ctx context.Context, logger *slog.Logger, orderService *service.OrderService, offset models.KafkaOffset) {
+	if err := orderService.SaveOffset(offset); err != nil {
+		metrics.KafkaOffsetCommitFailuresTotal.WithLabelValues(offset.Topic, strconv.Itoa(offset.Partition), commitStageSkip).Inc()
+		logger.ErrorContext(ctx, "Failed to save offset", "next_offset", offset.NextOffset, "error", err)
+	}
+}
+
+// retryMessage возвращает reader к сообщению msg, чтобы обработать его повторно
+func retryMessage(ctx context.Context, logger *slog.Logger, reader *kafka.Reader, msg kafka.Message) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(retryDelay):
+	}
+	if err := reader.SetOffset(msg.Offset); err != nil {
+		logger.ErrorContext(ctx, "Failed to rewind partition", "error", err)
+	}
+}
+
+// StopConsumer корректно завершает работу Kafka consumer: выходит из consumer group
+// и дожидается остановки чтения всех партиций
+func StopConsumer(consumer *Consumer, cancel context.CancelFunc) {
 	if cancel != nil {
 		cancel()
 	}
-	if reader != nil {
-		log.Println("Shutting down Kafka consumer")
-		if err := reader.Close(); err != nil {
-			log.Printf("Failed to close Kafka reader: %v", err)
-		}
+	if consumer == nil {
+		return
+	}
+	slog.Info("Shutting down Kafka consumer")
+	if err := consumer.group.Close(); err != nil {
+		slog.Error("Failed to close Kafka consumer group", "error", err)
 	}
+	<-consumer.done
 }
 
 // ensureTopic проверяет, что топик существует, и создаёт его при необходимости
@@ -112,7 +451,7 @@ func ensureTopic(cfg *config.Config, dialer *kafka.Dialer) error {
 	}
 	defer func() {
 		if err := conn.Close(); err != nil {
-			log.Printf("Failed to close connection: %v", err)
+			slog.Error("Failed to close Kafka connection", "error", err)
 		}
 	}()
 
@@ -127,7 +466,7 @@ func ensureTopic(cfg *config.Config, dialer *kafka.Dialer) error {
 	}
 	defer func() {
 		if err := ctrlConn.Close(); err != nil {
-			log.Printf("Failed to close controller connection: %v", err)
+			slog.Error("Failed to close Kafka controller connection", "error", err)
 		}
 	}()
 	return ctrlConn.CreateTopics(kafka.TopicConfig{
@@ -146,18 +485,49 @@ func InitDLQWriter(cfg *config.Config) {
 	}
 }
 
-// sendToDLQ отправляет сообщение в DLQ
-func sendToDLQ(data []byte) {
+// headerValue возвращает значение заголовка сообщения или пустую строку
+func headerValue(msg kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if strings.EqualFold(header.Key, key) {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// sendToDLQ отправляет сообщение в DLQ, сохраняя заголовки формата и версии схемы.
+// Контекст трассировки в заголовках заменяется на span отправки, дочерний к обработке сообщения.
+// reason - причина отправки для метрики
+func sendToDLQ(ctx context.Context, logger *slog.Logger, msg kafka.Message, reason string) {
 	if DLQWriter == nil {
-		log.Println("DLQ writer not initialized")
+		metrics.KafkaDLQMessagesTotal.WithLabelValues(reason, "failed").Inc()
+		logger.ErrorContext(ctx, "DLQ writer not initialized")
 		return
 	}
-	err := DLQWriter.WriteMessages(context.Background(),
+
+	ctx, span := tracer.Start(ctx, "send "+DLQWriter.Topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(DLQWriter.Topic),
+		),
+	)
+	headers := append([]kafka.Header(nil), msg.Headers...)
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{headers: &headers})
+
+	err := DLQWriter.WriteMessages(context.WithoutCancel(ctx),
 		kafka.Message{
-			Value: data,
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: headers,
 		},
 	)
+	tracing.End(span, err)
 	if err != nil {
-		log.Printf("Failed to send message to DLQ: %v", err)
+		metrics.KafkaDLQMessagesTotal.WithLabelValues(reason, "failed").Inc()
+		logger.ErrorContext(ctx, "Failed to send message to DLQ", "error", err)
+		return
 	}
+	metrics.KafkaDLQMessagesTotal.WithLabelValues(reason, "sent").Inc()
 }
@@ -0,0 +1,114 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOffsetStore возвращает сохраненные позиции после failures ошибок
+type fakeOffsetStore struct {
+	offsets  []models.KafkaOffset
+	failures int
+	calls    int
+}
+
+func (s *fakeOffsetStore) GetOffsets(topic string) ([]models.KafkaOffset, error) {
+	s.calls++
+	if s.failures > 0 {
+		s.failures--
+		return nil, errors.New("connection refused")
+	}
+	return s.offsets, nil
+}
+
+func TestStartOffset(t *testing.T) {
+	stored := []models.KafkaOffset{
+		{Topic: "orders", Partition: 0, NextOffset: 43},
+		{Topic: "orders", Partition: 2, NextOffset: 7},
+	}
+
+	tests := []struct {
+		name       string
+		assignment kafka.PartitionAssignment
+		expected   int64
+	}{
+		{name: "stored offset wins over group offset", assignment: kafka.PartitionAssignment{ID: 0, Offset: 10}, expected: 43},
+		{name: "group offset when nothing is stored", assignment: kafka.PartitionAssignment{ID: 1, Offset: 10}, expected: 10},
+		{name: "new partition starts from the beginning", assignment: kafka.PartitionAssignment{ID: 3, Offset: kafka.FirstOffset}, expected: kafka.FirstOffset},
+		{name: "stored offset of another partition", assignment: kafka.PartitionAssignment{ID: 2, Offset: kafka.FirstOffset}, expected: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, startOffset(stored, tt.assignment))
+		})
+	}
+}
+
+func TestLoadStartOffset_RetriesStoreErrors(t *testing.T) {
+	store := &fakeOffsetStore{
+		offsets:  []models.KafkaOffset{{Topic: "orders", Partition: 1, NextOffset: 100}},
+		failures: 1,
+	}
+
+	offset, err := loadStartOffset(context.Background(), store, "orders", kafka.PartitionAssignment{ID: 1, Offset: 5})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), offset)
+	assert.Equal(t, 2, store.calls)
+}
+
+func TestLoadStartOffset_StopsOnCancel(t *testing.T) {
+	store := &fakeOffsetStore{failures: 100}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := loadStartOffset(ctx, store, "orders", kafka.PartitionAssignment{ID: 1})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, store.calls)
+}
+
+func TestNewPartitionReader_SeeksToOffset(t *testing.T) {
+	cfg := &config.Config{Kafka: config.KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "orders"}}
+
+	reader, err := newPartitionReader(cfg, kafka.DefaultDialer, 2, 43)
+	require.NoError(t, err)
+	defer func() { _ = reader.Close() }()
+
+	assert.Equal(t, 2, reader.partition)
+	assert.Equal(t, int64(43), reader.Offset())
+	assert.True(t, reader.running)
+}
+
+func TestConsumer_Readers(t *testing.T) {
+	cfg := &config.Config{Kafka: config.KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "orders"}}
+	c := &Consumer{readers: make(map[int]*partitionReader)}
+
+	var readers []*partitionReader
+	for _, partition := range []int{2, 0, 1} {
+		reader, err := newPartitionReader(cfg, kafka.DefaultDialer, partition, kafka.FirstOffset)
+		require.NoError(t, err)
+		c.addReader(reader)
+		readers = append(readers, reader)
+	}
+
+	got := c.partitionReaders()
+	require.Len(t, got, 3)
+	assert.Equal(t, []int{0, 1, 2}, []int{got[0].partition, got[1].partition, got[2].partition})
+
+	// Партиция отозвана при перебалансировке
+	c.removeReader(readers[0])
+	assert.Len(t, c.partitionReaders(), 2)
+	assert.False(t, readers[0].running)
+
+	for _, reader := range readers[1:] {
+		c.removeReader(reader)
+	}
+	assert.Empty(t, c.partitionReaders())
+}
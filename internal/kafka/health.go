@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shenikar/order-service/internal/health"
+)
+
+// PartitionHealth - состояние чтения партиции
+type PartitionHealth struct {
+	Partition int    `json:"partition"`
+	Running   bool   `json:"running"`
+	Offset    int64  `json:"offset"`
+	Lag       int64  `json:"lag"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthCheck возвращает проверку чтения топика. Компонент в состоянии degraded, если экземпляр
+// еще не вступил в consumer group или последняя попытка вступить завершилась ошибкой, если
+// назначенная партиция не читается, чтение завершилось ошибкой или отставание больше maxLag
+// сообщений (0 - отставание не проверяется). Сервис при этом остается готовым: заказы отдаются
+// из БД, а kafka-go сам переподключается к брокерам
+func (c *Consumer) HealthCheck(maxLag int64) health.Check {
+	return func(ctx context.Context) health.Result {
+		c.mu.Lock()
+		joined, joinErr, assigned := c.joined, c.joinErr, c.assigned
+		c.mu.Unlock()
+
+		var problems []string
+		switch {
+		case joinErr != nil:
+			problems = append(problems, "consumer group: "+joinErr.Error())
+		case !joined:
+			problems = append(problems, "consumer group: not joined yet")
+		}
+
+		readers := make(map[int]*partitionReader)
+		for _, r := range c.partitionReaders() {
+			readers[r.partition] = r
+		}
+		// Экземпляров сервиса может быть больше, чем партиций, поэтому отсутствие
+		// назначенных партиций - нормальное состояние
+		for _, partition := range assigned {
+			if _, ok := readers[partition]; !ok {
+				readers[partition] = nil
+			}
+		}
+		numbers := make([]int, 0, len(readers))
+		for partition := range readers {
+			numbers = append(numbers, partition)
+		}
+		slices.Sort(numbers)
+
+		partitions := make([]PartitionHealth, 0, len(numbers))
+		for _, partition := range numbers {
+			state := PartitionHealth{Partition: partition}
+			if r := readers[partition]; r != nil {
+				r.mu.Lock()
+				state.Running = r.running
+				state.Offset = r.Offset()
+				state.Lag = r.Lag()
+				if r.lastErr != nil {
+					state.Error = r.lastErr.Error()
+				}
+				r.mu.Unlock()
+			}
+			partitions = append(partitions, state)
+
+			switch {
+			case !state.Running:
+				problems = append(problems, fmt.Sprintf("partition %d: not consumed", state.Partition))
+			case state.Error != "":
+				problems = append(problems, fmt.Sprintf("partition %d: %s", state.Partition, state.Error))
+			case maxLag > 0 && state.Lag > maxLag:
+				problems = append(problems, fmt.Sprintf("partition %d: lag %d exceeds %d", state.Partition, state.Lag, maxLag))
+			}
+		}
+
+		if len(problems) > 0 {
+			return health.Degraded(errors.New(strings.Join(problems, "; ")), partitions)
+		}
+		return health.Up(partitions)
+	}
+}
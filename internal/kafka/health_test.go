@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumer_HealthCheck(t *testing.T) {
+	cfg := &config.Config{Kafka: config.KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "orders"}}
+	c := &Consumer{readers: make(map[int]*partitionReader)}
+	check := c.HealthCheck(100)
+
+	result := check(context.Background())
+	assert.Equal(t, health.StatusDegraded, result.Status)
+	assert.Equal(t, "consumer group: not joined yet", result.Error)
+
+	c.setGeneration(nil)
+	assert.Equal(t, health.StatusUp, check(context.Background()).Status, "no partitions assigned to this instance")
+
+	c.setGeneration([]int{0, 1})
+	reader, err := newPartitionReader(cfg, kafka.DefaultDialer, 0, kafka.FirstOffset)
+	require.NoError(t, err)
+	defer func() { _ = reader.Close() }()
+	c.addReader(reader)
+
+	result = check(context.Background())
+	assert.Equal(t, health.StatusDegraded, result.Status)
+	assert.Equal(t, "partition 1: not consumed", result.Error)
+	assert.Equal(t, []PartitionHealth{
+		{Partition: 0, Running: true, Offset: kafka.FirstOffset},
+		{Partition: 1},
+	}, result.Details)
+
+	c.setGeneration([]int{0})
+	assert.Equal(t, health.StatusUp, check(context.Background()).Status)
+
+	reader.setError(errors.New("connection refused"))
+	result = check(context.Background())
+	assert.Equal(t, health.StatusDegraded, result.Status, "read errors do not make the pod unready")
+	assert.Equal(t, "partition 0: connection refused", result.Error)
+}
+
+func TestConsumer_HealthCheck_JoinError(t *testing.T) {
+	c := &Consumer{readers: make(map[int]*partitionReader)}
+	c.setGeneration([]int{0})
+	c.setJoinError(errors.New("dial tcp: connection refused"))
+
+	result := c.HealthCheck(0)(context.Background())
+	assert.Equal(t, health.StatusDegraded, result.Status)
+	assert.Equal(t, "consumer group: dial tcp: connection refused", result.Error)
+	assert.Empty(t, result.Details)
+
+	c.setGeneration(nil)
+	assert.Equal(t, health.StatusUp, c.HealthCheck(0)(context.Background()).Status, "join error is cleared by the next generation")
+}
@@ -0,0 +1,91 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shenikar/order-service/internal/codec"
+	"github.com/shenikar/order-service/internal/metrics"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+)
+
+// lagReportInterval - период обновления отставания партиций; с тем же периодом
+// reader запрашивает у брокера последний offset, поэтому отставание растет и тогда,
+// когда обработка сообщений остановилась
+const lagReportInterval = 15 * time.Second
+
+// Причины отправки сообщения в DLQ
+const (
+	dlqReasonDecode     = "decode"
+	dlqReasonValidation = "validation"
+)
+
+// Этапы, на которых сохраняется позиция чтения
+const (
+	commitStageSave = "save" // вместе с заказом
+	commitStageSkip = "skip" // без заказа, после пропущенного сообщения
+)
+
+// reportLag обновляет метрику отставания партиций до отмены контекста
+func (c *Consumer) reportLag(ctx context.Context) {
+	ticker := time.NewTicker(lagReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.recordLag()
+		}
+	}
+}
+
+// recordLag записывает в метрику отставание каждой партиции из статистики reader'а.
+// Stats сбрасывает накопленные счетчики reader'а, поэтому больше нигде не вызывается
+func (c *Consumer) recordLag() {
+	for _, reader := range c.partitionReaders() {
+		stats := reader.Stats()
+		metrics.KafkaConsumerLag.WithLabelValues(stats.Topic, strconv.Itoa(reader.partition)).Set(float64(stats.Lag))
+	}
+}
+
+// validationRules возвращает правила валидации, которые не прошел заказ, без повторов:
+// теги validator или ключевые слова JSON Schema, если сообщение не прошло проверку схемы
+func validationRules(err error) []string {
+	if rules := codec.SchemaViolationRules(err); len(rules) > 0 {
+		return rules
+	}
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return []string{"unknown"}
+	}
+	rules := make([]string, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		if !slices.Contains(rules, fieldErr.Tag()) {
+			rules = append(rules, fieldErr.Tag())
+		}
+	}
+	return rules
+}
+
+// countSaveFailure учитывает неудачное сохранение заказа вместе с позицией чтения: ошибки
+// записи позиции - в kafka_offset_commit_failures_total, остальные - в order_save_failures_total
+func countSaveFailure(offset models.KafkaOffset, err error) {
+	partition := strconv.Itoa(offset.Partition)
+	if errors.Is(err, repository.ErrSaveOffset) {
+		metrics.KafkaOffsetCommitFailuresTotal.WithLabelValues(offset.Topic, partition, commitStageSave).Inc()
+		return
+	}
+	metrics.OrderSaveFailuresTotal.WithLabelValues(offset.Topic, partition).Inc()
+}
+
+// observeCommitted записывает время от создания заказа до его сохранения.
+// Отрицательное время из-за расхождения часов считается нулевым
+func observeCommitted(dateCreated time.Time) {
+	metrics.OrderEndToEndLatency.Observe(max(time.Since(dateCreated), 0).Seconds())
+}
@@ -0,0 +1,65 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+	"github.com/shenikar/order-service/internal/codec"
+	"github.com/shenikar/order-service/internal/metrics"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationRules(t *testing.T) {
+	type order struct {
+		UID   string `validate:"required"`
+		Track string `validate:"required"`
+		Email string `validate:"omitempty,email"`
+	}
+	err := validator.New().Struct(order{Email: "not-an-email"})
+
+	assert.Equal(t, []string{"required", "email"}, validationRules(err))
+	assert.Equal(t, []string{"unknown"}, validationRules(errors.New("boom")))
+
+	schemaValidator, err := codec.NewSchemaValidator()
+	require.NoError(t, err)
+	err = schemaValidator.Validate([]byte(`{"order_uid": 42, "payment": {"amount": -1}}`), 2)
+	require.ErrorIs(t, err, codec.ErrSchemaViolation)
+	assert.Subset(t, validationRules(err), []string{"required", "type"})
+	assert.NotContains(t, validationRules(err), "unknown")
+}
+
+func TestConsumer_RecordLag(t *testing.T) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     "lag-test",
+		Partition: 3,
+	})
+	defer func() { _ = reader.Close() }()
+	metrics.KafkaConsumerLag.WithLabelValues("lag-test", "3").Set(42)
+
+	c := &Consumer{readers: map[int]*partitionReader{3: {Reader: reader, partition: 3}}}
+	c.recordLag()
+
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.KafkaConsumerLag.WithLabelValues("lag-test", "3")))
+}
+
+func TestCountSaveFailure(t *testing.T) {
+	offset := models.KafkaOffset{Topic: "save-test", Partition: 1}
+	commitFailures := metrics.KafkaOffsetCommitFailuresTotal.WithLabelValues("save-test", "1", commitStageSave)
+	saveFailures := metrics.OrderSaveFailuresTotal.WithLabelValues("save-test", "1")
+
+	countSaveFailure(offset, errors.New(`duplicate key value violates unique constraint "items_pkey"`))
+	assert.Equal(t, 0.0, testutil.ToFloat64(commitFailures))
+	assert.Equal(t, 1.0, testutil.ToFloat64(saveFailures))
+
+	countSaveFailure(offset, fmt.Errorf("%w: connection reset", repository.ErrSaveOffset))
+	assert.Equal(t, 1.0, testutil.ToFloat64(commitFailures))
+	assert.Equal(t, 1.0, testutil.ToFloat64(saveFailures))
+}
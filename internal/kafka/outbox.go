@@ -0,0 +1,176 @@
+package kafka
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/feed"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+)
+
+// Заголовки сообщений с событиями
+const (
+	HeaderEventType = "event-type"
+	HeaderDedupeKey = "dedupe-key"
+)
+
+// Очистка outbox от опубликованных событий
+const (
+	// outboxCleanupInterval - период удаления старых опубликованных событий
+	outboxCleanupInterval = time.Hour
+	// outboxCleanupBatchSize - количество событий, удаляемых одним запросом
+	outboxCleanupBatchSize = 1000
+)
+
+// outboxStore - хранилище событий outbox
+type outboxStore interface {
+	PublishPending(ctx context.Context, limit int, publish func([]models.OutboxEvent) error) (int, error)
+	DeletePublished(ctx context.Context, before time.Time, keepLast, limit int) (int64, error)
+}
+
+// messageWriter - запись сообщений в Kafka
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// OutboxRelay публикует события из таблицы outbox в Kafka и удаляет опубликованные
+// события старше срока хранения
+type OutboxRelay struct {
+	writer    messageWriter
+	store     outboxStore
+	batchSize int
+	retention time.Duration // 0 - опубликованные события не удаляются
+	now       func() time.Time
+	done      chan struct{}
+}
+
+// StartOutboxRelay запускает фоновую публикацию событий из outbox.
+// Доставка at-least-once: событие помечается опубликованным только после успешной записи в Kafka,
+// получатели должны устранять дубли по заголовку dedupe-key
+func StartOutboxRelay(ctx context.Context, cfg *config.Config, repo *repository.OutboxRepository) *OutboxRelay {
+	writer := &kafka.Writer{
+		Addr:                   kafka.TCP(cfg.Kafka.Brokers...),
+		Topic:                  cfg.Kafka.EventsTopic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+	}
+	relay := newOutboxRelay(writer, repo, cfg.Outbox.BatchSize,
+		time.Duration(cfg.Outbox.RetentionDays)*24*time.Hour)
+
+	go relay.run(ctx, time.Duration(cfg.Outbox.PollInterval)*time.Millisecond)
+
+	return relay
+}
+
+// newOutboxRelay создает OutboxRelay; публикация запускается методом run
+func newOutboxRelay(writer messageWriter, store outboxStore, batchSize int, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		writer:    writer,
+		store:     store,
+		batchSize: batchSize,
+		retention: retention,
+		now:       time.Now,
+		done:      make(chan struct{}),
+	}
+}
+
+// run публикует события с периодом interval и раз в outboxCleanupInterval удаляет старые
+// опубликованные события, пока не отменен ctx
+func (r *OutboxRelay) run(ctx context.Context, interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	cleanupTicker := time.NewTicker(outboxCleanupInterval)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Outbox relay context canceled, stopping")
+			return
+		case <-cleanupTicker.C:
+			r.cleanup(ctx)
+		case <-ticker.C:
+			r.publishPending(ctx)
+		}
+	}
+}
+
+// publishPending публикует пачками все накопленные события. При ошибке публикация
+// прекращается до следующего запуска, неопубликованные события будут отправлены повторно
+func (r *OutboxRelay) publishPending(ctx context.Context) {
+	for {
+		n, err := r.store.PublishPending(ctx, r.batchSize, func(events []models.OutboxEvent) error {
+			return r.publish(ctx, events)
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Failed to publish outbox events", "error", err)
+			}
+			return
+		}
+		if n < r.batchSize {
+			return
+		}
+	}
+}
+
+// cleanup удаляет события, опубликованные раньше срока хранения. Последние feed.MaxReplay
+// событий сохраняются, чтобы лента заказов могла дослать их при возобновлении подписки
+func (r *OutboxRelay) cleanup(ctx context.Context) {
+	if r.retention <= 0 {
+		return
+	}
+	before := r.now().Add(-r.retention)
+	var total int64
+	for {
+		deleted, err := r.store.DeletePublished(ctx, before, feed.MaxReplay, outboxCleanupBatchSize)
+		total += deleted
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Failed to delete published outbox events", "error", err)
+			}
+			break
+		}
+		if deleted < outboxCleanupBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		slog.Info("Deleted published outbox events", "count", total, "published_before", before)
+	}
+}
+
+// publish отправляет события в Kafka, ключ сообщения - идентификатор заказа
+func (r *OutboxRelay) publish(ctx context.Context, events []models.OutboxEvent) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		messages = append(messages, kafka.Message{
+			Key:   []byte(event.AggregateID),
+			Value: event.Payload,
+			Headers: []kafka.Header{
+				{Key: HeaderEventType, Value: []byte(event.EventType)},
+				{Key: HeaderDedupeKey, Value: []byte(event.DedupeKey)},
+			},
+		})
+	}
+	return r.writer.WriteMessages(ctx, messages...)
+}
+
+// StopOutboxRelay дожидается завершения публикации и закрывает writer
+func StopOutboxRelay(relay *OutboxRelay) {
+	if relay == nil {
+		return
+	}
+	<-relay.done
+	if err := relay.writer.Close(); err != nil {
+		slog.Error("Failed to close outbox writer", "error", err)
+	}
+}
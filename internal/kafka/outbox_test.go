@@ -0,0 +1,146 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/shenikar/order-service/internal/feed"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutboxStore хранит события в памяти и, как OutboxRepository, помечает их
+// опубликованными только после успешного publish
+type fakeOutboxStore struct {
+	events    []models.OutboxEvent
+	published map[int64]bool
+
+	deleteCalls []deleteCall
+	deleteLeft  int64
+}
+
+type deleteCall struct {
+	before          time.Time
+	keepLast, limit int
+}
+
+func (s *fakeOutboxStore) PublishPending(_ context.Context, limit int, publish func([]models.OutboxEvent) error) (int, error) {
+	var pending []models.OutboxEvent
+	for _, event := range s.events {
+		if !s.published[event.ID] && len(pending) < limit {
+			pending = append(pending, event)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+	if err := publish(pending); err != nil {
+		return 0, err
+	}
+	for _, event := range pending {
+		s.published[event.ID] = true
+	}
+	return len(pending), nil
+}
+
+func (s *fakeOutboxStore) DeletePublished(_ context.Context, before time.Time, keepLast, limit int) (int64, error) {
+	s.deleteCalls = append(s.deleteCalls, deleteCall{before: before, keepLast: keepLast, limit: limit})
+	deleted := min(s.deleteLeft, int64(limit))
+	s.deleteLeft -= deleted
+	return deleted, nil
+}
+
+// fakeWriter запоминает отправленные сообщения и возвращает ошибку первые failures раз
+type fakeWriter struct {
+	failures int
+	calls    int
+	messages []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.calls++
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("kafka unavailable")
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error { return nil }
+
+func newFakeOutboxStore(n int) *fakeOutboxStore {
+	store := &fakeOutboxStore{published: make(map[int64]bool)}
+	for i := 1; i <= n; i++ {
+		store.events = append(store.events, models.OutboxEvent{
+			ID:          int64(i),
+			EventType:   models.EventTypeOrderAccepted,
+			AggregateID: "uid" + strconv.Itoa(i),
+			DedupeKey:   "OrderAccepted:uid" + strconv.Itoa(i),
+			Payload:     []byte(`{}`),
+		})
+	}
+	return store
+}
+
+func TestOutboxRelay_PublishPending(t *testing.T) {
+	store := newFakeOutboxStore(5)
+	writer := &fakeWriter{}
+	relay := newOutboxRelay(writer, store, 2, 0)
+
+	relay.publishPending(context.Background())
+
+	assert.Equal(t, 3, writer.calls, "events are published in batches until the outbox is drained")
+	require.Len(t, writer.messages, 5)
+	msg := writer.messages[0]
+	assert.Equal(t, "uid1", string(msg.Key))
+	assert.Equal(t, []kafka.Header{
+		{Key: HeaderEventType, Value: []byte(models.EventTypeOrderAccepted)},
+		{Key: HeaderDedupeKey, Value: []byte("OrderAccepted:uid1")},
+	}, msg.Headers)
+}
+
+func TestOutboxRelay_RetriesAfterWriteFailure(t *testing.T) {
+	store := newFakeOutboxStore(3)
+	writer := &fakeWriter{failures: 1}
+	relay := newOutboxRelay(writer, store, 10, 0)
+
+	relay.publishPending(context.Background())
+	assert.Empty(t, writer.messages)
+	assert.Empty(t, store.published, "events stay pending when Kafka write fails")
+
+	relay.publishPending(context.Background())
+	assert.Len(t, writer.messages, 3)
+	assert.Len(t, store.published, 3)
+}
+
+func TestOutboxRelay_Cleanup(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := newFakeOutboxStore(0)
+	store.deleteLeft = outboxCleanupBatchSize + 10
+	relay := newOutboxRelay(&fakeWriter{}, store, 10, 7*24*time.Hour)
+	relay.now = func() time.Time { return now }
+
+	relay.cleanup(context.Background())
+
+	require.Len(t, store.deleteCalls, 2, "deletes in batches until fewer than a batch is left")
+	for _, call := range store.deleteCalls {
+		assert.Equal(t, now.Add(-7*24*time.Hour), call.before)
+		assert.Equal(t, feed.MaxReplay, call.keepLast, "feed replay window is kept")
+		assert.Equal(t, outboxCleanupBatchSize, call.limit)
+	}
+}
+
+func TestOutboxRelay_CleanupDisabled(t *testing.T) {
+	store := newFakeOutboxStore(0)
+	relay := newOutboxRelay(&fakeWriter{}, store, 10, 0)
+
+	relay.cleanup(context.Background())
+
+	assert.Empty(t, store.deleteCalls)
+}
@@ -0,0 +1,45 @@
+package kafka
+
+import (
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("github.com/shenikar/order-service/internal/kafka")
+
+// headerCarrier позволяет читать и записывать контекст трассировки в заголовки сообщения Kafka
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+// Get возвращает значение заголовка key или пустую строку
+func (c headerCarrier) Get(key string) string {
+	for _, header := range *c.headers {
+		if strings.EqualFold(header.Key, key) {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set заменяет значение заголовка key, а если его нет - добавляет заголовок
+func (c headerCarrier) Set(key, value string) {
+	for i, header := range *c.headers {
+		if strings.EqualFold(header.Key, key) {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys возвращает ключи всех заголовков
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, header := range *c.headers {
+		keys = append(keys, header.Key)
+	}
+	return keys
+}
@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHeaderCarrier_Propagation(t *testing.T) {
+	propagator := propagation.TraceContext{}
+	headers := []kafka.Header{
+		{Key: "content-type", Value: []byte("application/json")},
+		{Key: "Traceparent", Value: []byte("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")},
+	}
+
+	ctx := propagator.Extract(context.Background(), headerCarrier{headers: &headers})
+	remote := trace.SpanContextFromContext(ctx)
+	assert.True(t, remote.IsRemote())
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", remote.TraceID().String())
+
+	// Контекст дочернего span'а заменяет исходный traceparent, а не дублирует его
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	child := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    remote.TraceID(),
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+	propagator.Inject(trace.ContextWithSpanContext(ctx, child), headerCarrier{headers: &headers})
+
+	assert.Len(t, headers, 2)
+	assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-00f067aa0ba902b7-01", string(headers[1].Value))
+	assert.Equal(t, []string{"content-type", "Traceparent"}, headerCarrier{headers: &headers}.Keys())
+}
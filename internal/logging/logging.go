@@ -0,0 +1,76 @@
+// Package logging настраивает структурированный журнал log/slog: уровень, формат (JSON или текст)
+// и поля из контекста - идентификатор запроса и идентификаторы трассировки
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/shenikar/order-service/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Форматы журнала
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New создает журнал, который пишет в w записи не ниже уровня cfg.Level в формате cfg.Format
+func New(w io.Writer, cfg config.LogConfig) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", cfg.Level)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch cfg.Format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: expected json or text", cfg.Format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Fatal пишет ошибку в журнал и завершает процесс
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// contextHandler добавляет в записи идентификатор запроса и идентификаторы трассировки
+// из контекста, переданного в slog.InfoContext и аналогичные функции
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle дополняет запись полями из ctx и передает ее дальше
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs возвращает обработчик с дополнительными полями
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup возвращает обработчик, помещающий следующие поля в группу name
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, config.LogConfig{Level: "verbose", Format: FormatJSON})
+	assert.ErrorContains(t, err, "invalid log level")
+
+	_, err = New(&bytes.Buffer{}, config.LogConfig{Level: "info", Format: "xml"})
+	assert.ErrorContains(t, err, "invalid log format")
+}
+
+func TestNew_LevelAndFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, config.LogConfig{Level: "warn", Format: FormatText})
+	require.NoError(t, err)
+
+	logger.Info("skipped")
+	logger.Warn("written", "order_uid", "uid1")
+
+	assert.NotContains(t, buf.String(), "skipped")
+	assert.Contains(t, buf.String(), "level=WARN msg=written order_uid=uid1")
+}
+
+func TestNew_ContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, config.LogConfig{Level: "info", Format: FormatJSON})
+	require.NoError(t, err)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = WithRequestID(ctx, "req-1")
+
+	logger.With("component", "test").InfoContext(ctx, "hello")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "hello", record["msg"])
+	assert.Equal(t, "test", record["component"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", record["span_id"])
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, RequestIDFromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "client value is kept", header: "abc-123.x:y_z", expected: "abc-123.x:y_z"},
+		{name: "missing value is generated"},
+		{name: "invalid characters", header: "abc\"def"},
+		{name: "too long", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderRequestID, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(HeaderRequestID)
+			assert.Equal(t, id, w.Body.String(), "context and response header carry the same id")
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, id)
+			} else {
+				assert.Len(t, id, 32)
+				assert.NotEqual(t, tt.header, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID - заголовок с идентификатором запроса
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength - максимальная длина идентификатора запроса, принятого от клиента
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID возвращает контекст с идентификатором запроса id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext возвращает идентификатор запроса из контекста или пустую строку
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID возвращает middleware, которое сохраняет идентификатор запроса в контексте запроса
+// и возвращает его в заголовке X-Request-ID. Идентификатор берется из одноименного заголовка
+// запроса, а если его нет или он некорректен - генерируется
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(HeaderRequestID, id)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validRequestID проверяет идентификатор от клиента: он попадает в журнал, поэтому
+// допускаются только латинские буквы, цифры и символы - _ . :
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID генерирует случайный идентификатор запроса
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
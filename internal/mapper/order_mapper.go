@@ -18,6 +18,7 @@ func MapOrderDBToModel(dbo models.OrderDB) models.Order {
 		SmID:              dbo.SmID,
 		DateCreated:       dbo.DateCreated,
 		OofShard:          dbo.OofShard,
+		DuplicateOf:       dbo.DuplicateOf,
 		Delivery: models.Delivery{
 			Name:    dbo.DeliveryName,
 			Phone:   dbo.DeliveryPhone,
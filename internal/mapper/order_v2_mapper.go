@@ -0,0 +1,69 @@
+package mapper
+
+import (
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// MapOrderToV2 преобразует Order в представление API v2
+func MapOrderToV2(order *models.Order) models.OrderV2 {
+	currency := order.Payment.Currency
+	v2 := models.OrderV2{
+		OrderUID:          order.OrderUID,
+		TrackNumber:       order.TrackNumber,
+		Entry:             order.Entry,
+		Locale:            order.Locale,
+		InternalSignature: order.InternalSignature,
+		CustomerID:        order.CustomerID,
+		DeliveryService:   order.DeliveryService,
+		ShardKey:          order.ShardKey,
+		SmID:              order.SmID,
+		CreatedAt:         order.DateCreated,
+		OofShard:          order.OofShard,
+		Delivery:          order.Delivery,
+		Payment: models.PaymentV2{
+			Transaction:  order.Payment.Transaction,
+			RequestID:    order.Payment.RequestID,
+			Provider:     order.Payment.Provider,
+			Bank:         order.Payment.Bank,
+			Amount:       models.NewMoney(int64(order.Payment.Amount), currency),
+			DeliveryCost: models.NewMoney(int64(order.Payment.DeliveryCost), currency),
+			GoodsTotal:   models.NewMoney(int64(order.Payment.GoodsTotal), currency),
+			CustomFee:    models.NewMoney(int64(order.Payment.CustomFee), currency),
+		},
+		Items:       make([]models.ItemV2, 0, len(order.Items)),
+		DuplicateOf: order.DuplicateOf,
+	}
+	if order.Payment.PaymentDT > 0 {
+		paidAt := time.Unix(order.Payment.PaymentDT, 0).UTC()
+		v2.Payment.PaidAt = &paidAt
+	}
+
+	for _, item := range order.Items {
+		v2.Items = append(v2.Items, models.ItemV2{
+			ChrtID:      item.ChrtID,
+			TrackNumber: item.TrackNumber,
+			Price:       models.NewMoney(int64(item.Price), currency),
+			Rid:         item.Rid,
+			Name:        item.Name,
+			SalePercent: item.Sale,
+			Size:        item.Size,
+			TotalPrice:  models.NewMoney(int64(item.TotalPrice), currency),
+			NmID:        item.NmID,
+			Brand:       item.Brand,
+			Status:      item.Status,
+		})
+	}
+
+	return v2
+}
+
+// MapOrdersToV2 преобразует список заказов в представление API v2
+func MapOrdersToV2(orders []models.Order) []models.OrderV2 {
+	result := make([]models.OrderV2, 0, len(orders))
+	for i := range orders {
+		result = append(result, MapOrderToV2(&orders[i]))
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package mapper
+
+import (
+	orderv1 "github.com/shenikar/order-service/api/order/v1"
+	"github.com/shenikar/order-service/internal/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MapProtoToModel преобразует Protobuf-сообщение в Order
+func MapProtoToModel(po *orderv1.Order) models.Order {
+	order := models.Order{
+		OrderUID:          po.GetOrderUid(),
+		TrackNumber:       po.GetTrackNumber(),
+		Entry:             po.GetEntry(),
+		Locale:            po.GetLocale(),
+		InternalSignature: po.GetInternalSignature(),
+		CustomerID:        po.GetCustomerId(),
+		DeliveryService:   po.GetDeliveryService(),
+		ShardKey:          po.GetShardkey(),
+		SmID:              int(po.GetSmId()),
+		OofShard:          po.GetOofShard(),
+		Delivery: models.Delivery{
+			Name:    po.GetDelivery().GetName(),
+			Phone:   po.GetDelivery().GetPhone(),
+			Zip:     po.GetDelivery().GetZip(),
+			City:    po.GetDelivery().GetCity(),
+			Address: po.GetDelivery().GetAddress(),
+			Region:  po.GetDelivery().GetRegion(),
+			Email:   po.GetDelivery().GetEmail(),
+		},
+		Payment: models.Payment{
+			Transaction:  po.GetPayment().GetTransaction(),
+			RequestID:    po.GetPayment().GetRequestId(),
+			Currency:     po.GetPayment().GetCurrency(),
+			Provider:     po.GetPayment().GetProvider(),
+			Amount:       int(po.GetPayment().GetAmount()),
+			PaymentDT:    po.GetPayment().GetPaymentDt(),
+			Bank:         po.GetPayment().GetBank(),
+			DeliveryCost: int(po.GetPayment().GetDeliveryCost()),
+			GoodsTotal:   int(po.GetPayment().GetGoodsTotal()),
+			CustomFee:    int(po.GetPayment().GetCustomFee()),
+		},
+		Items: make([]models.Item, 0, len(po.GetItems())),
+	}
+	if po.GetDateCreated() != nil {
+		order.DateCreated = po.GetDateCreated().AsTime()
+	}
+
+	for _, pi := range po.GetItems() {
+		order.Items = append(order.Items, models.Item{
+			ChrtID:      int(pi.GetChrtId()),
+			TrackNumber: pi.GetTrackNumber(),
+			Price:       int(pi.GetPrice()),
+			Rid:         pi.GetRid(),
+			Name:        pi.GetName(),
+			Sale:        int(pi.GetSale()),
+			Size:        pi.GetSize(),
+			TotalPrice:  int(pi.GetTotalPrice()),
+			NmID:        int(pi.GetNmId()),
+			Brand:       pi.GetBrand(),
+			Status:      int(pi.GetStatus()),
+		})
+	}
+
+	return order
+}
+
+// MapModelToProto преобразует Order в Protobuf-сообщение
+func MapModelToProto(order *models.Order) *orderv1.Order {
+	po := &orderv1.Order{
+		OrderUid:          order.OrderUID,
+		TrackNumber:       order.TrackNumber,
+		Entry:             order.Entry,
+		Locale:            order.Locale,
+		InternalSignature: order.InternalSignature,
+		CustomerId:        order.CustomerID,
+		DeliveryService:   order.DeliveryService,
+		Shardkey:          order.ShardKey,
+		SmId:              int64(order.SmID),
+		DateCreated:       timestamppb.New(order.DateCreated),
+		OofShard:          order.OofShard,
+		Delivery: &orderv1.Delivery{
+			Name:    order.Delivery.Name,
+			Phone:   order.Delivery.Phone,
+			Zip:     order.Delivery.Zip,
+			City:    order.Delivery.City,
+			Address: order.Delivery.Address,
+			Region:  order.Delivery.Region,
+			Email:   order.Delivery.Email,
+		},
+		Payment: &orderv1.Payment{
+			Transaction:  order.Payment.Transaction,
+			RequestId:    order.Payment.RequestID,
+			Currency:     order.Payment.Currency,
+			Provider:     order.Payment.Provider,
+			Amount:       int64(order.Payment.Amount),
+			PaymentDt:    order.Payment.PaymentDT,
+			Bank:         order.Payment.Bank,
+			DeliveryCost: int64(order.Payment.DeliveryCost),
+			GoodsTotal:   int64(order.Payment.GoodsTotal),
+			CustomFee:    int64(order.Payment.CustomFee),
+		},
+		Items: make([]*orderv1.Item, 0, len(order.Items)),
+	}
+
+	for _, item := range order.Items {
+		po.Items = append(po.Items, &orderv1.Item{
+			ChrtId:      int64(item.ChrtID),
+			TrackNumber: item.TrackNumber,
+			Price:       int64(item.Price),
+			Rid:         item.Rid,
+			Name:        item.Name,
+			Sale:        int32(item.Sale),
+			Size:        item.Size,
+			TotalPrice:  int64(item.TotalPrice),
+			NmId:        int64(item.NmID),
+			Brand:       item.Brand,
+			Status:      int32(item.Status),
+		})
+	}
+
+	return po
+}
@@ -16,6 +16,106 @@ var (
 		},
 		[]string{"method", "path", "status"},
 	)
+
+	// OrderDuplicatesTotal - счетчик заказов, совпавших по содержимому с ранее сохраненными
+	OrderDuplicatesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_duplicates_total",
+			Help: "Total number of orders detected as content duplicates",
+		},
+		[]string{"action"},
+	)
+
+	// HttpRequestsThrottledTotal - счетчик запросов, отклоненных ограничением частоты
+	HttpRequestsThrottledTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_throttled_total",
+			Help: "Total number of HTTP requests rejected by the rate limiter",
+		},
+		[]string{"group", "key_type"},
+	)
+
+	// KafkaMessagesConsumedTotal - счетчик сообщений, прочитанных из Kafka
+	KafkaMessagesConsumedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_messages_consumed_total",
+			Help: "Total number of messages fetched from Kafka",
+		},
+		[]string{"topic", "partition"},
+	)
+
+	// KafkaDecodeFailuresTotal - счетчик сообщений, которые не удалось декодировать
+	KafkaDecodeFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_decode_failures_total",
+			Help: "Total number of Kafka messages that failed to decode",
+		},
+		[]string{"topic"},
+	)
+
+	// OrderValidationFailuresTotal - счетчик заказов, не прошедших проверку, по правилам валидации
+	OrderValidationFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_validation_failures_total",
+			Help: "Total number of orders rejected by validation, by failed rule",
+		},
+		[]string{"rule"},
+	)
+
+	// KafkaDLQMessagesTotal - счетчик сообщений, отправленных в DLQ
+	KafkaDLQMessagesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_dlq_messages_total",
+			Help: "Total number of messages sent to the dead letter queue",
+		},
+		[]string{"reason", "status"},
+	)
+
+	// OrderSaveDuration - время сохранения заказа вместе с позицией чтения
+	OrderSaveDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "order_save_duration_seconds",
+			Help:    "Duration of saving an order together with its Kafka offset",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"result"},
+	)
+
+	// OrderSaveFailuresTotal - счетчик неудачных сохранений заказов из Kafka, кроме ошибок записи позиции чтения
+	OrderSaveFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_save_failures_total",
+			Help: "Total number of failed order saves from Kafka, excluding offset write failures",
+		},
+		[]string{"topic", "partition"},
+	)
+
+	// KafkaOffsetCommitFailuresTotal - счетчик неудачных сохранений позиции чтения
+	KafkaOffsetCommitFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_offset_commit_failures_total",
+			Help: "Total number of failed Kafka offset commits to the database",
+		},
+		[]string{"topic", "partition", "stage"},
+	)
+
+	// KafkaConsumerLag - отставание чтения партиции от последнего сообщения в ней
+	KafkaConsumerLag = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_consumer_lag",
+			Help: "Number of messages in the partition not yet read by the consumer",
+		},
+		[]string{"topic", "partition"},
+	)
+
+	// OrderEndToEndLatency - время от создания заказа (date_created) до его сохранения
+	OrderEndToEndLatency = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "order_end_to_end_latency_seconds",
+			Help:    "Time from order date_created to its commit in the database",
+			Buckets: []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600, 10800},
+		},
+	)
 )
 
 // PrometheusHandler возвращает обработчик для Gin
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventTypeOrderAccepted - тип события о принятом заказе
+const EventTypeOrderAccepted = "OrderAccepted"
+
+// OutboxEvent - событие в таблице outbox, ожидающее публикации в Kafka
+type OutboxEvent struct {
+	ID          int64      `db:"id"`
+	EventType   string     `db:"event_type"`
+	AggregateID string     `db:"aggregate_id"`
+	DedupeKey   string     `db:"dedupe_key"`
+	Payload     []byte     `db:"payload"`
+	CreatedAt   time.Time  `db:"created_at"`
+	PublishedAt *time.Time `db:"published_at"`
+}
+
+// OrderAcceptedEvent - полезная нагрузка события OrderAccepted
+type OrderAcceptedEvent struct {
+	OrderUID        string    `json:"order_uid"`
+	CustomerID      string    `json:"customer_id"`
+	DeliveryService string    `json:"delivery_service"`
+	Currency        string    `json:"currency"`
+	Amount          int       `json:"amount"`
+	GoodsTotal      int       `json:"goods_total"`
+	DeliveryCost    int       `json:"delivery_cost"`
+	CustomFee       int       `json:"custom_fee"`
+	ItemsCount      int       `json:"items_count"`
+	DateCreated     time.Time `json:"date_created"`
+}
+
+// NewOrderAcceptedOutboxEvent формирует outbox-событие о принятом заказе
+func NewOrderAcceptedOutboxEvent(order *Order) (*OutboxEvent, error) {
+	payload, err := json.Marshal(OrderAcceptedEvent{
+		OrderUID:        order.OrderUID,
+		CustomerID:      order.CustomerID,
+		DeliveryService: order.DeliveryService,
+		Currency:        order.Payment.Currency,
+		Amount:          order.Payment.Amount,
+		GoodsTotal:      order.Payment.GoodsTotal,
+		DeliveryCost:    order.Payment.DeliveryCost,
+		CustomFee:       order.Payment.CustomFee,
+		ItemsCount:      len(order.Items),
+		DateCreated:     order.DateCreated,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", EventTypeOrderAccepted, err)
+	}
+
+	return &OutboxEvent{
+		EventType:   EventTypeOrderAccepted,
+		AggregateID: order.OrderUID,
+		DedupeKey:   EventTypeOrderAccepted + ":" + order.OrderUID,
+		Payload:     payload,
+	}, nil
+}
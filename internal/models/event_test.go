@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrderAcceptedOutboxEvent(t *testing.T) {
+	order := &Order{
+		OrderUID:        "uid1",
+		CustomerID:      "customer",
+		DeliveryService: "meest",
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Payment:         Payment{Currency: "USD", Amount: 1817, GoodsTotal: 317, DeliveryCost: 1500},
+		Items:           []Item{{ChrtID: 1}, {ChrtID: 2}},
+	}
+
+	event, err := NewOrderAcceptedOutboxEvent(order)
+	assert.NoError(t, err)
+	assert.Equal(t, EventTypeOrderAccepted, event.EventType)
+	assert.Equal(t, "uid1", event.AggregateID)
+	assert.Equal(t, "OrderAccepted:uid1", event.DedupeKey)
+
+	var payload OrderAcceptedEvent
+	assert.NoError(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, "customer", payload.CustomerID)
+	assert.Equal(t, "meest", payload.DeliveryService)
+	assert.Equal(t, 1817, payload.Amount)
+	assert.Equal(t, 2, payload.ItemsCount)
+	assert.True(t, payload.DateCreated.Equal(order.DateCreated))
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// OrderFilter - условия отбора заказов, пустые поля не ограничивают выборку
+type OrderFilter struct {
+	CustomerID      string
+	DeliveryService string
+	CreatedFrom     time.Time // включительно
+	CreatedTo       time.Time // не включительно
+	NmID            int       // заказы, в которых есть товар с этим артикулом
+	Brand           string    // заказы, в которых есть товар этого бренда
+}
+
+// OrderPage - страница заказов и токен следующей страницы
+type OrderPage struct {
+	Orders        []Order `json:"orders"`
+	NextPageToken string  `json:"next_page_token,omitempty"`
+}
+
+// BatchGetOrdersRequest - запрос нескольких заказов по UID
+type BatchGetOrdersRequest struct {
+	OrderUIDs []string `json:"order_uids" binding:"required"`
+}
+
+// BatchGetOrdersResult - найденные заказы и UID, которых нет в сервисе
+type BatchGetOrdersResult struct {
+	Orders  []Order  `json:"orders"`
+	Missing []string `json:"missing"`
+}
+
+// OrderCursor - позиция последнего прочитанного заказа при чтении от новых к старым
+type OrderCursor struct {
+	DateCreated time.Time `json:"date_created"`
+	OrderUID    string    `json:"order_uid"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "log/slog"
+
+// redacted заменяет персональные данные в журнале
+const redacted = "[REDACTED]"
+
+// LogValue скрывает в журнале персональные данные получателя: остаются только город и регион
+func (d Delivery) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("name", redact(d.Name)),
+		slog.String("phone", redact(d.Phone)),
+		slog.String("zip", redact(d.Zip)),
+		slog.String("city", d.City),
+		slog.String("address", redact(d.Address)),
+		slog.String("region", d.Region),
+		slog.String("email", redact(d.Email)),
+	)
+}
+
+// LogValue выводит в журнал основные поля заказа; доставка выводится через Delivery.LogValue
+func (o Order) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("order_uid", o.OrderUID),
+		slog.String("track_number", o.TrackNumber),
+		slog.String("entry", o.Entry),
+		slog.String("delivery_service", o.DeliveryService),
+		slog.Time("date_created", o.DateCreated),
+		slog.Any("delivery", o.Delivery),
+		slog.String("currency", o.Payment.Currency),
+		slog.Int("amount", o.Payment.Amount),
+		slog.Int("items", len(o.Items)),
+	)
+}
+
+// redact скрывает непустое значение, пустое оставляет пустым, чтобы было видно отсутствие поля
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redacted
+}
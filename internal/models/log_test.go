@@ -0,0 +1,51 @@
+package models
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderLogValue_RedactsDelivery(t *testing.T) {
+	order := &Order{
+		OrderUID: "uid1",
+		Delivery: Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: Payment{Currency: "USD", Amount: 1817},
+		Items:   []Item{{ChrtID: 1}},
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("order", "order", order)
+	out := buf.String()
+
+	for _, pii := range []string{"Test Testov", "+9720000000", "2639809", "Ploshad Mira", "test@gmail.com"} {
+		assert.NotContains(t, out, pii)
+	}
+	assert.Contains(t, out, "order.order_uid=uid1")
+	assert.Contains(t, out, "order.delivery.name=[REDACTED]")
+	assert.Contains(t, out, `order.delivery.city="Kiryat Mozkin"`)
+	assert.Contains(t, out, "order.amount=1817")
+	assert.Contains(t, out, "order.items=1")
+}
+
+func TestDeliveryLogValue_EmptyFieldsStayEmpty(t *testing.T) {
+	value := Delivery{City: "Moscow"}.LogValue()
+
+	for _, attr := range value.Group() {
+		if attr.Key == "city" {
+			assert.Equal(t, "Moscow", attr.Value.String())
+			continue
+		}
+		assert.Empty(t, attr.Value.String(), attr.Key)
+	}
+}
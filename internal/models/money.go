@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// defaultCurrencyScale - количество знаков после запятой для большинства валют
+const defaultCurrencyScale = 2
+
+// currencyScales - валюты ISO 4217, у которых масштаб минорных единиц отличается от 2
+var currencyScales = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyScale возвращает количество минорных разрядов валюты по ISO 4217.
+// Все денежные суммы заказа хранятся в минорных единицах (копейки, центы и т.д.)
+func CurrencyScale(currency string) int {
+	if scale, ok := currencyScales[strings.ToUpper(currency)]; ok {
+		return scale
+	}
+	return defaultCurrencyScale
+}
+
+// Money - денежная сумма: минорные единицы валюты и их десятичная запись
+type Money struct {
+	Amount     string `json:"amount"` // десятичная запись с масштабом валюты, например "1817.00"
+	Currency   string `json:"currency"`
+	MinorUnits int64  `json:"minor_units"`
+}
+
+// NewMoney создает сумму из минорных единиц валюты
+func NewMoney(minorUnits int64, currency string) Money {
+	return Money{
+		Amount:     FormatMinorUnits(minorUnits, currency),
+		Currency:   currency,
+		MinorUnits: minorUnits,
+	}
+}
+
+// FormatMinorUnits записывает сумму в минорных единицах десятичной дробью
+// с масштабом валюты: 181700 RUB - "1817.00", 1817 JPY - "1817"
+func FormatMinorUnits(minorUnits int64, currency string) string {
+	sign := ""
+	abs := uint64(minorUnits)
+	if minorUnits < 0 {
+		sign = "-"
+		abs = uint64(-(minorUnits + 1)) + 1
+	}
+	digits := strconv.FormatUint(abs, 10)
+
+	scale := CurrencyScale(currency)
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
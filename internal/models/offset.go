@@ -0,0 +1,9 @@
+package models
+
+// KafkaOffset - позиция чтения партиции Kafka.
+// NextOffset - смещение следующего сообщения, которое нужно прочитать
+type KafkaOffset struct {
+	Topic      string `db:"topic"`
+	Partition  int    `db:"partition"`
+	NextOffset int64  `db:"next_offset"`
+}
@@ -1,20 +1,24 @@
 package models
 
+import "time"
+
 type Order struct {
-	OrderUID          string   `json:"order_uid" db:"order_uid" validate:"required"`
-	TrackNumber       string   `json:"track_number" db:"track_number" validate:"required"`
-	Entry             string   `json:"entry" db:"entry" validate:"required"`
-	Locale            string   `json:"locale" db:"locale" validate:"required"`
-	InternalSignature string   `json:"internal_signature" db:"internal_signature"`
-	CustomerID        string   `json:"customer_id" db:"customer_id" validate:"required"`
-	DeliveryService   string   `json:"delivery_service" db:"delivery_service"`
-	ShardKey          string   `json:"shardkey" db:"shardkey"`
-	SmID              int      `json:"sm_id" db:"sm_id"`
-	DateCreated       string   `json:"date_created" db:"date_created" validate:"required"`
-	OofShard          string   `json:"oof_shard" db:"oof_shard"`
-	Delivery          Delivery `json:"delivery" validate:"required"`
-	Payment           Payment  `json:"payment" validate:"required"`
-	Items             []Item   `json:"items" validate:"required,dive,required"`
+	OrderUID          string    `json:"order_uid" db:"order_uid" validate:"required"`
+	TrackNumber       string    `json:"track_number" db:"track_number" validate:"required"`
+	Entry             string    `json:"entry" db:"entry" validate:"required"`
+	Locale            string    `json:"locale" db:"locale" validate:"required"`
+	InternalSignature string    `json:"internal_signature" db:"internal_signature"`
+	CustomerID        string    `json:"customer_id" db:"customer_id" validate:"required"`
+	DeliveryService   string    `json:"delivery_service" db:"delivery_service"`
+	ShardKey          string    `json:"shardkey" db:"shardkey"`
+	SmID              int       `json:"sm_id" db:"sm_id"`
+	DateCreated       time.Time `json:"date_created" db:"date_created" validate:"required"`
+	OofShard          string    `json:"oof_shard" db:"oof_shard"`
+	Delivery          Delivery  `json:"delivery" validate:"required"`
+	Payment           Payment   `json:"payment" validate:"required"`
+	Items             []Item    `json:"items" validate:"required,dive,required"`
+	Fingerprint       string    `json:"-" db:"fingerprint"`
+	DuplicateOf       string    `json:"duplicate_of,omitempty" db:"duplicate_of"`
 }
 
 type Delivery struct {
@@ -56,3 +60,9 @@ type Item struct {
 	Brand       string `json:"brand" db:"brand"`
 	Status      int    `json:"status" db:"status"`
 }
+
+// DuplicateGroup - группа заказов с одной и той же платежной транзакцией
+type DuplicateGroup struct {
+	Transaction string   `json:"transaction"`
+	OrderUIDs   []string `json:"order_uids"`
+}
@@ -1,19 +1,22 @@
 package models
 
+import "time"
+
 // вспомогательная структура для чтения из БД
 type OrderDB struct {
 	// Orders
-	OrderUID          string `db:"order_uid"`
-	TrackNumber       string `db:"track_number"`
-	Entry             string `db:"entry"`
-	Locale            string `db:"locale"`
-	InternalSignature string `db:"internal_signature"`
-	CustomerID        string `db:"customer_id"`
-	DeliveryService   string `db:"delivery_service"`
-	ShardKey          string `db:"shardkey"`
-	SmID              int    `db:"sm_id"`
-	DateCreated       string `db:"date_created"`
-	OofShard          string `db:"oof_shard"`
+	OrderUID          string    `db:"order_uid"`
+	TrackNumber       string    `db:"track_number"`
+	Entry             string    `db:"entry"`
+	Locale            string    `db:"locale"`
+	InternalSignature string    `db:"internal_signature"`
+	CustomerID        string    `db:"customer_id"`
+	DeliveryService   string    `db:"delivery_service"`
+	ShardKey          string    `db:"shardkey"`
+	SmID              int       `db:"sm_id"`
+	DateCreated       time.Time `db:"date_created"`
+	OofShard          string    `db:"oof_shard"`
+	DuplicateOf       string    `db:"duplicate_of"`
 
 	// Delivery
 	DeliveryName    string `db:"name"`
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrder_DateCreatedRFC3339RoundTrip(t *testing.T) {
+	raw := []byte(`{"order_uid":"uid1","date_created":"2021-11-26T06:22:19+03:00"}`)
+
+	var order Order
+	assert.NoError(t, json.Unmarshal(raw, &order))
+	assert.True(t, order.DateCreated.Equal(time.Date(2021, 11, 26, 3, 22, 19, 0, time.UTC)))
+
+	data, err := json.Marshal(order)
+	assert.NoError(t, err)
+
+	var decoded Order
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.DateCreated.Equal(order.DateCreated))
+	assert.Contains(t, string(data), `"date_created":"2021-11-26T06:22:19+03:00"`)
+}
+
+func TestCurrencyScale(t *testing.T) {
+	assert.Equal(t, 2, CurrencyScale("USD"))
+	assert.Equal(t, 2, CurrencyScale("rub"))
+	assert.Equal(t, 0, CurrencyScale("JPY"))
+	assert.Equal(t, 3, CurrencyScale("KWD"))
+	assert.Equal(t, 2, CurrencyScale("unknown"))
+}
+
+func TestFormatMinorUnits(t *testing.T) {
+	assert.Equal(t, "1817.00", FormatMinorUnits(181700, "RUB"))
+	assert.Equal(t, "0.05", FormatMinorUnits(5, "USD"))
+	assert.Equal(t, "0.00", FormatMinorUnits(0, "USD"))
+	assert.Equal(t, "1817", FormatMinorUnits(1817, "JPY"))
+	assert.Equal(t, "1.817", FormatMinorUnits(1817, "KWD"))
+	assert.Equal(t, "-12.50", FormatMinorUnits(-1250, "EUR"))
+}
+
+func TestNewMoney(t *testing.T) {
+	assert.Equal(t, Money{Amount: "18.17", Currency: "USD", MinorUnits: 1817}, NewMoney(1817, "USD"))
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// OrderV2 - представление заказа в API v2. В отличие от Order время передается
+// в RFC 3339, а денежные суммы - объектами Money с валютой платежа
+type OrderV2 struct {
+	OrderUID          string    `json:"order_uid"`
+	TrackNumber       string    `json:"track_number"`
+	Entry             string    `json:"entry"`
+	Locale            string    `json:"locale"`
+	InternalSignature string    `json:"internal_signature"`
+	CustomerID        string    `json:"customer_id"`
+	DeliveryService   string    `json:"delivery_service"`
+	ShardKey          string    `json:"shardkey"`
+	SmID              int       `json:"sm_id"`
+	CreatedAt         time.Time `json:"created_at"`
+	OofShard          string    `json:"oof_shard"`
+	Delivery          Delivery  `json:"delivery"`
+	Payment           PaymentV2 `json:"payment"`
+	Items             []ItemV2  `json:"items"`
+	DuplicateOf       string    `json:"duplicate_of,omitempty"`
+}
+
+// PaymentV2 - платеж заказа в API v2
+type PaymentV2 struct {
+	Transaction  string     `json:"transaction"`
+	RequestID    string     `json:"request_id"`
+	Provider     string     `json:"provider"`
+	Bank         string     `json:"bank"`
+	PaidAt       *time.Time `json:"paid_at"` // null, если время платежа неизвестно
+	Amount       Money      `json:"amount"`
+	DeliveryCost Money      `json:"delivery_cost"`
+	GoodsTotal   Money      `json:"goods_total"`
+	CustomFee    Money      `json:"custom_fee"`
+}
+
+// ItemV2 - товар заказа в API v2, цены в валюте платежа
+type ItemV2 struct {
+	ChrtID      int    `json:"chrt_id"`
+	TrackNumber string `json:"track_number"`
+	Price       Money  `json:"price"`
+	Rid         string `json:"rid"`
+	Name        string `json:"name"`
+	SalePercent int    `json:"sale_percent"`
+	Size        string `json:"size"`
+	TotalPrice  Money  `json:"total_price"`
+	NmID        int    `json:"nm_id"`
+	Brand       string `json:"brand"`
+	Status      int    `json:"status"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// SearchField - текстовое поле заказа, по которому ведется поиск
+type SearchField struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// SearchHit - заказ, найденный поисковым запросом, с его текстовыми полями
+type SearchHit struct {
+	OrderUID    string
+	DateCreated time.Time
+	Rank        float64
+	Fields      []SearchField
+}
+
+// SearchResult - найденный заказ с подсвеченными совпадениями
+type SearchResult struct {
+	OrderUID    string            `json:"order_uid"`
+	DateCreated time.Time         `json:"date_created"`
+	Rank        float64           `json:"rank"`
+	Highlights  []SearchHighlight `json:"highlights"`
+}
+
+// SearchHighlight - значение поля, в котором совпавшие слова обернуты в <mark></mark>
+type SearchHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// Измерения группировки статистики заказов
+const (
+	StatsByDay             = "day"
+	StatsByHour            = "hour"
+	StatsByCurrency        = "currency"
+	StatsByDeliveryService = "delivery_service"
+	StatsByBank            = "bank"
+	StatsByProvider        = "provider"
+	StatsByRegion          = "region"
+	StatsByBrand           = "brand"
+)
+
+// Источники статистики заказов
+const (
+	StatsSourceLive   = "live"   // агрегаты посчитаны по таблицам заказов
+	StatsSourceRollup = "rollup" // агрегаты посчитаны по предрасчитанным почасовым срезам
+)
+
+// StatsQuery - параметры запроса статистики заказов
+type StatsQuery struct {
+	GroupBy     []string
+	CreatedFrom time.Time // включительно
+	CreatedTo   time.Time // не включительно
+}
+
+// OrderStats - агрегаты заказов одной группы. Заполнены только поля измерений
+// из StatsQuery.GroupBy, суммы указаны в минорных единицах валюты группы
+type OrderStats struct {
+	Period          *time.Time `json:"period,omitempty" db:"period"`
+	Currency        string     `json:"currency" db:"currency"`
+	DeliveryService *string    `json:"delivery_service,omitempty" db:"delivery_service"`
+	Bank            *string    `json:"bank,omitempty" db:"bank"`
+	Provider        *string    `json:"provider,omitempty" db:"provider"`
+	Region          *string    `json:"region,omitempty" db:"region"`
+	Brand           *string    `json:"brand,omitempty" db:"brand"`
+	Orders          int64      `json:"orders" db:"orders"`
+	Amount          int64      `json:"amount" db:"amount"`
+	GoodsTotal      int64      `json:"goods_total" db:"goods_total"`
+	DeliveryCost    int64      `json:"delivery_cost" db:"delivery_cost"`
+	AvgItems        float64    `json:"avg_items" db:"avg_items"`
+}
+
+// OrderStatsReport - ответ на запрос статистики заказов
+type OrderStatsReport struct {
+	Source      string       `json:"source"`
+	RefreshedAt *time.Time   `json:"refreshed_at,omitempty"` // время обновления срезов для source=rollup
+	Rows        []OrderStats `json:"rows"`
+}
+
+// ProductStatsQuery - товар (NmID) или бренд (Brand) и период для статистики продаж
+type ProductStatsQuery struct {
+	NmID        int
+	Brand       string
+	CreatedFrom time.Time // включительно
+	CreatedTo   time.Time // не включительно
+}
+
+// ProductStats - продажи товара или бренда. Единица - одна позиция заказа,
+// выручка - сумма total_price позиций в минорных единицах валюты заказа
+type ProductStats struct {
+	NmID             int              `json:"nm_id,omitempty"`
+	Brand            string           `json:"brand,omitempty"`
+	Units            int64            `json:"units"`
+	Orders           int64            `json:"orders"`
+	Revenue          []CurrencyAmount `json:"revenue"`
+	SaleDistribution []SaleBucket     `json:"sale_distribution"`
+	Sizes            []SizeStats      `json:"sizes"`
+}
+
+// CurrencyAmount - сумма в минорных единицах валюты
+type CurrencyAmount struct {
+	Currency string `json:"currency" db:"currency"`
+	Amount   int64  `json:"amount" db:"amount"`
+}
+
+// SaleBucket - количество проданных единиц с одной и той же скидкой в процентах
+type SaleBucket struct {
+	Sale  int   `json:"sale" db:"sale"`
+	Units int64 `json:"units" db:"units"`
+}
+
+// SizeStats - продажи одного размера
+type SizeStats struct {
+	Size  string `json:"size" db:"size"`
+	Units int64  `json:"units" db:"units"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// OrderTracking - трек-номера и статусы заказа без данных получателя и платежа.
+// Это представление заказа для клиентов с доступом только к отслеживанию доставки
+type OrderTracking struct {
+	OrderUID        string         `json:"order_uid"`
+	TrackNumber     string         `json:"track_number"`
+	DeliveryService string         `json:"delivery_service"`
+	Items           []ItemTracking `json:"items"`
+}
+
+// ItemTracking - трек-номер и статус товара
+type ItemTracking struct {
+	ChrtID      int    `json:"chrt_id"`
+	TrackNumber string `json:"track_number"`
+	Status      int    `json:"status"`
+}
@@ -0,0 +1,160 @@
+// Package projection ограничивает данные заказа в ответах API в зависимости от роли клиента:
+// служба поддержки видит заказ целиком, аналитики - с маскированными контактами
+// и адресом получателя, партнеры - только трек-номера и статусы
+package projection
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// Уровни доступа к данным заказа, от самого широкого к самому узкому
+const (
+	LevelFull     = "full"     // заказ целиком
+	LevelMasked   = "masked"   // телефон, email и адрес получателя маскированы
+	LevelTracking = "tracking" // только трек-номера и статусы товаров
+)
+
+// levelRank - ширина доступа уровня; чем больше, тем больше данных видит клиент
+var levelRank = map[string]int{
+	LevelTracking: 0,
+	LevelMasked:   1,
+	LevelFull:     2,
+}
+
+// Policy сопоставляет роли клиентов с уровнями доступа к данным заказа
+type Policy struct {
+	levels map[string]string
+}
+
+// ParsePolicy разбирает политику вида "role=level,role2=level2"
+func ParsePolicy(spec string) (*Policy, error) {
+	p := &Policy{levels: make(map[string]string)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, level, ok := strings.Cut(entry, "=")
+		role, level = strings.TrimSpace(role), strings.TrimSpace(level)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid pii policy entry %q: expected role=level", entry)
+		}
+		if _, known := levelRank[level]; !known {
+			return nil, fmt.Errorf("unknown pii access level %q for role %q", level, role)
+		}
+		p.levels[role] = level
+	}
+	return p, nil
+}
+
+// Level возвращает самый широкий уровень доступа среди ролей клиента.
+// Роли, которых нет в политике, получают LevelTracking
+func (p *Policy) Level(roles []string) string {
+	best := LevelTracking
+	for _, role := range roles {
+		if level, ok := p.levels[role]; ok && levelRank[level] > levelRank[best] {
+			best = level
+		}
+	}
+	return best
+}
+
+// AllowsPII сообщает, разрешено ли уровню видеть персональные данные получателя без маскирования
+func AllowsPII(level string) bool {
+	return level == LevelFull
+}
+
+// Apply возвращает копию заказа, ограниченную уровнем доступа. Для LevelTracking
+// в копии остаются только поля из Tracking, остальные обнуляются
+func Apply(level string, order *models.Order) *models.Order {
+	switch level {
+	case LevelFull:
+		return order
+	case LevelMasked:
+		masked := *order
+		masked.Delivery.Phone = maskPhone(order.Delivery.Phone)
+		masked.Delivery.Email = maskEmail(order.Delivery.Email)
+		masked.Delivery.Address = maskWords(order.Delivery.Address)
+		return &masked
+	default:
+		tracking := &models.Order{
+			OrderUID:        order.OrderUID,
+			TrackNumber:     order.TrackNumber,
+			DeliveryService: order.DeliveryService,
+			Items:           make([]models.Item, 0, len(order.Items)),
+		}
+		for _, item := range order.Items {
+			tracking.Items = append(tracking.Items, models.Item{
+				ChrtID:      item.ChrtID,
+				TrackNumber: item.TrackNumber,
+				Status:      item.Status,
+			})
+		}
+		return tracking
+	}
+}
+
+// Tracking возвращает трек-номера и статусы заказа - представление для LevelTracking
+func Tracking(order *models.Order) models.OrderTracking {
+	tracking := models.OrderTracking{
+		OrderUID:        order.OrderUID,
+		TrackNumber:     order.TrackNumber,
+		DeliveryService: order.DeliveryService,
+		Items:           make([]models.ItemTracking, 0, len(order.Items)),
+	}
+	for _, item := range order.Items {
+		tracking.Items = append(tracking.Items, models.ItemTracking{
+			ChrtID:      item.ChrtID,
+			TrackNumber: item.TrackNumber,
+			Status:      item.Status,
+		})
+	}
+	return tracking
+}
+
+// maskPhone оставляет ведущий "+" и две последние цифры: +9720000000 -> +********00
+func maskPhone(phone string) string {
+	runes := []rune(phone)
+	digits := 0
+	for i := len(runes) - 1; i >= 0; i-- {
+		if !unicode.IsDigit(runes[i]) {
+			continue
+		}
+		digits++
+		if digits > 2 {
+			runes[i] = '*'
+		}
+	}
+	return string(runes)
+}
+
+// maskEmail оставляет первый символ имени и домен: test@gmail.com -> t***@gmail.com
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return maskWords(email)
+	}
+	first := []rune(local)[0]
+	return string(first) + "***@" + domain
+}
+
+// maskWords оставляет первый символ каждого слова: Ploshad Mira 15 -> P****** M*** 1*
+func maskWords(s string) string {
+	runes := []rune(s)
+	wordStart := true
+	for i, r := range runes {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) {
+			wordStart = true
+			continue
+		}
+		if !wordStart {
+			runes[i] = '*'
+		}
+		wordStart = false
+	}
+	return string(runes)
+}
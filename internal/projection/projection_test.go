@@ -0,0 +1,102 @@
+package projection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleOrder() *models.Order {
+	return &models.Order{
+		OrderUID:        "b563feb7b2b84b6test",
+		TrackNumber:     "WBILMTESTTRACK",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Delivery: models.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: models.Payment{Transaction: "b563feb7b2b84b6test", Currency: "USD", Amount: 1817},
+		Items: []models.Item{
+			{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453, Name: "Mascaras", NmID: 2389212, Status: 202},
+		},
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("support=full, analyst=masked,partner=tracking")
+	require.NoError(t, err)
+
+	assert.Equal(t, LevelFull, policy.Level([]string{"support"}))
+	assert.Equal(t, LevelMasked, policy.Level([]string{"analyst"}))
+	assert.Equal(t, LevelTracking, policy.Level([]string{"partner"}))
+	// Из нескольких ролей выбирается самый широкий доступ
+	assert.Equal(t, LevelFull, policy.Level([]string{"partner", "support"}))
+	// Неизвестные роли и клиенты без ролей получают минимальный доступ
+	assert.Equal(t, LevelTracking, policy.Level([]string{"admin"}))
+	assert.Equal(t, LevelTracking, policy.Level(nil))
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	for _, spec := range []string{"support", "=full", "support=everything"} {
+		_, err := ParsePolicy(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestApply_Full(t *testing.T) {
+	order := sampleOrder()
+	assert.Same(t, order, Apply(LevelFull, order))
+}
+
+func TestApply_Masked(t *testing.T) {
+	order := sampleOrder()
+	masked := Apply(LevelMasked, order)
+
+	assert.Equal(t, "+********00", masked.Delivery.Phone)
+	assert.Equal(t, "t***@gmail.com", masked.Delivery.Email)
+	assert.Equal(t, "P****** M*** 1*", masked.Delivery.Address)
+	// Остальные поля доставки, платеж и товары не меняются
+	assert.Equal(t, "Test Testov", masked.Delivery.Name)
+	assert.Equal(t, "Kiryat Mozkin", masked.Delivery.City)
+	assert.Equal(t, order.Payment, masked.Payment)
+	assert.Equal(t, order.Items, masked.Items)
+	// Исходный заказ (например, из кэша) не изменяется
+	assert.Equal(t, "+9720000000", order.Delivery.Phone)
+}
+
+func TestApply_Tracking(t *testing.T) {
+	order := sampleOrder()
+	tracking := Apply(LevelTracking, order)
+
+	assert.Equal(t, &models.Order{
+		OrderUID:        "b563feb7b2b84b6test",
+		TrackNumber:     "WBILMTESTTRACK",
+		DeliveryService: "meest",
+		Items:           []models.Item{{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Status: 202}},
+	}, tracking)
+	// Неизвестный уровень трактуется как самый узкий
+	assert.Equal(t, tracking, Apply("unknown", order))
+}
+
+func TestTracking(t *testing.T) {
+	assert.Equal(t, models.OrderTracking{
+		OrderUID:        "b563feb7b2b84b6test",
+		TrackNumber:     "WBILMTESTTRACK",
+		DeliveryService: "meest",
+		Items:           []models.ItemTracking{{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Status: 202}},
+	}, Tracking(sampleOrder()))
+}
+
+func TestMaskEmail_WithoutAt(t *testing.T) {
+	assert.Equal(t, "n*******", maskEmail("nobody42"))
+}
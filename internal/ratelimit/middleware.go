@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/internal/auth"
+	"github.com/shenikar/order-service/internal/metrics"
+)
+
+// keyTypeIP - клиент определен по IP-адресу
+const keyTypeIP = "ip"
+
+// Middleware возвращает middleware, ограничивающее частоту запросов клиентов к группе
+// маршрутов group. Все маршруты группы делят одни корзины, поэтому middleware создается
+// один раз на группу. Для групп без ограничения возвращается middleware, которое пропускает все.
+// Ставится после auth.Require: аутентифицированные клиенты различаются по API-ключу или
+// subject токена, остальные - по IP-адресу
+func (ls Limits) Middleware(group string) gin.HandlerFunc {
+	return ls.MiddlewareWithCost(group, nil)
+}
+
+// MiddlewareWithCost работает как Middleware, но запрос расходует cost(c) токенов:
+// например, пакетный запрос - по токену на каждый запрошенный заказ. Если cost равен nil,
+// запрос расходует один токен
+func (ls Limits) MiddlewareWithCost(group string, cost func(c *gin.Context) int) gin.HandlerFunc {
+	limit, ok := ls[group]
+	if !ok || limit.Rate <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	limiter := NewLimiter(limit)
+
+	return func(c *gin.Context) {
+		n := 1
+		if cost != nil {
+			n = cost(c)
+		}
+		key, keyType := clientKey(c)
+		allowed, retryAfter := limiter.AllowN(key, n)
+		if !allowed {
+			metrics.HttpRequestsThrottledTotal.WithLabelValues(group, keyType).Inc()
+			tooManyRequests(c, retryAfter)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthLimiter ограничивает неудачные попытки аутентификации с одного IP-адреса,
+// чтобы API-ключи и токены нельзя было подбирать перебором. Успешные запросы
+// лимит не расходуют. Общий для HTTP и gRPC API; nil не ограничивает попытки
+type AuthLimiter struct {
+	limiter *Limiter // nil, если ограничение отключено
+}
+
+// AuthLimiter создает ограничитель неудачных попыток аутентификации по лимиту группы auth
+func (ls Limits) AuthLimiter() *AuthLimiter {
+	limit, ok := ls[GroupAuth]
+	if !ok || limit.Rate <= 0 {
+		return &AuthLimiter{}
+	}
+	return &AuthLimiter{limiter: NewLimiter(limit)}
+}
+
+// Blocked возвращает, через сколько клиенту с адресом ip можно повторить попытку
+// аутентификации. Ноль - попытка разрешена
+func (a *AuthLimiter) Blocked(ip string) time.Duration {
+	if a == nil || a.limiter == nil {
+		return 0
+	}
+	return a.limiter.Wait(keyTypeIP + ":" + ip)
+}
+
+// Fail учитывает неудачную попытку аутентификации клиента с адресом ip
+func (a *AuthLimiter) Fail(ip string) {
+	if a != nil && a.limiter != nil {
+		a.limiter.Allow(keyTypeIP + ":" + ip)
+	}
+}
+
+// Middleware возвращает middleware, которое ставится перед auth.Require: отклоняет запросы
+// с IP-адреса, исчерпавшего попытки, и учитывает ответы 401 как неудачные попытки
+func (a *AuthLimiter) Middleware() gin.HandlerFunc {
+	if a == nil || a.limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if retryAfter := a.Blocked(ip); retryAfter > 0 {
+			metrics.HttpRequestsThrottledTotal.WithLabelValues(GroupAuth, keyTypeIP).Inc()
+			tooManyRequests(c, retryAfter)
+			return
+		}
+		c.Next()
+		if c.Writer.Status() == http.StatusUnauthorized {
+			a.Fail(ip)
+		}
+	}
+}
+
+// tooManyRequests отвечает 429 с заголовком Retry-After
+func tooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+}
+
+// clientKey возвращает ключ корзины клиента и способ, которым клиент определен
+func clientKey(c *gin.Context) (string, string) {
+	if identity, ok := auth.IdentityFromGin(c); ok && identity.Method != auth.MethodNone {
+		return identity.Method + ":" + identity.Subject, identity.Method
+	}
+	return keyTypeIP + ":" + c.ClientIP(), keyTypeIP
+}
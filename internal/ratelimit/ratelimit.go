@@ -0,0 +1,173 @@
+// Package ratelimit ограничивает частоту запросов клиентов алгоритмом token bucket.
+// Клиент определяется по API-ключу или JWT, а без аутентификации - по IP-адресу
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval - как часто удаляются корзины клиентов, которые давно не обращались
+const sweepInterval = time.Minute
+
+// Группы ограничений
+const (
+	GroupOrders  = "orders"  // отдельные заказы
+	GroupBatch   = "batch"   // пакетное получение заказов, токен - один запрошенный заказ
+	GroupReports = "reports" // списки и статистика
+	GroupExport  = "export"  // выгрузки и поток заказов
+	GroupSearch  = "search"  // поиск и GraphQL
+	GroupAuth    = "auth"    // неудачные попытки аутентификации с одного IP-адреса
+)
+
+// groups - известные группы ограничений
+var groups = []string{GroupOrders, GroupBatch, GroupReports, GroupExport, GroupSearch, GroupAuth}
+
+// Limit - ограничение группы маршрутов: Rate запросов в секунду в среднем
+// и не больше Burst запросов подряд. Нулевой Rate отключает ограничение
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Limits - ограничения по группам маршрутов
+type Limits map[string]Limit
+
+// ParseLimits разбирает ограничения вида "group=rate:burst,group2=rate:burst".
+// rate - запросов в секунду, может быть дробным: 0.5 - один запрос в две секунды
+func ParseLimits(spec string) (Limits, error) {
+	limits := make(Limits)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, value, ok := strings.Cut(entry, "=")
+		rateValue, burstValue, okValue := strings.Cut(value, ":")
+		group = strings.TrimSpace(group)
+		if !ok || !okValue || group == "" {
+			return nil, fmt.Errorf("invalid rate limit %q: expected group=rate:burst", entry)
+		}
+		if !slices.Contains(groups, group) {
+			return nil, fmt.Errorf("unknown rate limit group %q: expected one of %s", group, strings.Join(groups, ", "))
+		}
+		rate, err := strconv.ParseFloat(rateValue, 64)
+		if err != nil || rate < 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return nil, fmt.Errorf("invalid rate in rate limit %q", entry)
+		}
+		burst, err := strconv.Atoi(burstValue)
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid burst in rate limit %q", entry)
+		}
+		limits[group] = Limit{Rate: rate, Burst: burst}
+	}
+	return limits, nil
+}
+
+// bucket - корзина токенов клиента
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter хранит корзины токенов клиентов одной группы маршрутов
+type Limiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter создает Limiter с ограничением limit
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow расходует токен клиента key. Если токенов нет, возвращает false
+// и время, через которое появится следующий токен
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	return l.AllowN(key, 1)
+}
+
+// AllowN расходует n токенов клиента key. Запрос дороже Burst расходует всю полную корзину.
+// Если токенов не хватает, возвращает false и время, через которое их станет достаточно
+func (l *Limiter) AllowN(key string, n int) (bool, time.Duration) {
+	if l.limit.Rate <= 0 {
+		return true, 0
+	}
+	cost := float64(min(max(n, 1), l.limit.Burst))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key)
+	if b.tokens >= cost {
+		b.tokens -= cost
+		return true, 0
+	}
+	return false, l.wait(b, cost)
+}
+
+// Wait возвращает, через сколько у клиента key появится токен, не расходуя его.
+// Ноль - токен уже есть
+func (l *Limiter) Wait(key string) time.Duration {
+	if l.limit.Rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return l.wait(b, 1)
+}
+
+// refill возвращает корзину клиента key, пополненную на момент now. Вызывается под l.mu
+func (l *Limiter) refill(key string) *bucket {
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate)
+	b.last = now
+	return b
+}
+
+// wait возвращает, через сколько в корзине станет cost токенов
+func (l *Limiter) wait(b *bucket, cost float64) time.Duration {
+	return time.Duration((cost - b.tokens) / l.limit.Rate * float64(time.Second))
+}
+
+// sweep удаляет корзины, которые успели наполниться полностью: для таких клиентов
+// новая корзина ничем не отличается от старой. Вызывается под l.mu
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(float64(l.limit.Burst) / l.limit.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
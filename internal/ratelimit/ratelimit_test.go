@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(limit Limit) (*Limiter, *time.Time) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(limit)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, now := newTestLimiter(Limit{Rate: 2, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("partner")
+		assert.True(t, ok, "request %d fits into burst", i)
+	}
+	ok, retryAfter := l.Allow("partner")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	ok, _ = l.Allow("support")
+	assert.True(t, ok, "clients have separate buckets")
+
+	*now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("partner")
+	assert.True(t, ok)
+	ok, _ = l.Allow("partner")
+	assert.False(t, ok)
+
+	*now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		ok, _ = l.Allow("partner")
+		assert.True(t, ok, "bucket refills up to burst only")
+	}
+	ok, _ = l.Allow("partner")
+	assert.False(t, ok)
+}
+
+func TestLimiter_AllowN(t *testing.T) {
+	l, now := newTestLimiter(Limit{Rate: 10, Burst: 100})
+
+	ok, _ := l.AllowN("partner", 60)
+	assert.True(t, ok)
+	ok, retryAfter := l.AllowN("partner", 60)
+	assert.False(t, ok, "request costs more than the tokens left")
+	assert.Equal(t, 2*time.Second, retryAfter)
+
+	*now = now.Add(2 * time.Second)
+	ok, _ = l.AllowN("partner", 60)
+	assert.True(t, ok)
+
+	*now = now.Add(time.Hour)
+	ok, _ = l.AllowN("partner", 1000)
+	assert.True(t, ok, "cost is capped at burst")
+	ok, _ = l.AllowN("partner", 0)
+	assert.False(t, ok, "request costs at least one token")
+}
+
+func TestLimiter_SweepsIdleBuckets(t *testing.T) {
+	l, now := newTestLimiter(Limit{Rate: 1, Burst: 5})
+
+	l.Allow("idle")
+	*now = now.Add(2 * time.Minute)
+	l.Allow("active")
+
+	assert.NotContains(t, l.buckets, "idle")
+	assert.Contains(t, l.buckets, "active")
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("orders=20:40, export=0.1:3,")
+	require.NoError(t, err)
+	assert.Equal(t, Limits{
+		"orders": {Rate: 20, Burst: 40},
+		"export": {Rate: 0.1, Burst: 3},
+	}, limits)
+
+	for _, spec := range []string{"orders", "orders=20", "=1:1", "unknown=1:1", "order=20:40", "orders=x:1", "orders=-1:1", "orders=1:0", "orders=Inf:1"} {
+		_, err := ParseLimits(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func serve(handlers ...gin.HandlerFunc) func(apiKey, ip string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/orders/:order_uid", append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })...)
+
+	return func(apiKey, ip string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/orders/b563feb7b2b84b6test", nil)
+		r.RemoteAddr = ip + ":40000"
+		if apiKey != "" {
+			r.Header.Set(auth.HeaderAPIKey, apiKey)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		return w
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a, err := auth.New(config.AuthConfig{
+		Enabled: true,
+		APIKeys: "support-bot:support:" + auth.HashAPIKey("support-secret") + ",partner-x:partner:" + auth.HashAPIKey("partner-secret"),
+	})
+	require.NoError(t, err)
+	limits := Limits{"orders": {Rate: 0.5, Burst: 1}}
+	do := serve(a.Require(), limits.Middleware("orders"))
+
+	assert.Equal(t, http.StatusOK, do("partner-secret", "10.0.0.1").Code)
+	w := do("partner-secret", "10.0.0.2")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "same API key from another IP shares the bucket")
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, do("support-secret", "10.0.0.1").Code)
+}
+
+func TestMiddleware_ByIP(t *testing.T) {
+	limits := Limits{"orders": {Rate: 1, Burst: 1}}
+	do := serve(limits.Middleware("orders"))
+
+	assert.Equal(t, http.StatusOK, do("", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, do("", "10.0.0.2").Code)
+}
+
+func TestMiddleware_Unlimited(t *testing.T) {
+	limits := Limits{"orders": {Rate: 0, Burst: 1}}
+	for _, group := range []string{"orders", "search"} {
+		do := serve(limits.Middleware(group))
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, do("", "10.0.0.1").Code, group)
+		}
+	}
+}
+
+func TestMiddlewareWithCost(t *testing.T) {
+	limits := Limits{GroupBatch: {Rate: 1, Burst: 10}}
+	do := serve(limits.MiddlewareWithCost(GroupBatch, func(c *gin.Context) int { return 4 }))
+
+	assert.Equal(t, http.StatusOK, do("", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, do("", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("", "10.0.0.1").Code)
+}
+
+func TestAuthLimiter(t *testing.T) {
+	a, err := auth.New(config.AuthConfig{
+		Enabled: true,
+		APIKeys: "partner-x:partner:" + auth.HashAPIKey("partner-secret"),
+	})
+	require.NoError(t, err)
+
+	limits := Limits{GroupAuth: {Rate: 0.1, Burst: 2}}
+	do := serve(limits.AuthLimiter().Middleware(), a.Require())
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, do("partner-secret", "10.0.0.1").Code, "successful requests are not counted")
+	}
+	assert.Equal(t, http.StatusUnauthorized, do("guess-1", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("guess-2", "10.0.0.1").Code)
+
+	w := do("partner-secret", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "IP is blocked after failed attempts")
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusUnauthorized, do("guess-1", "10.0.0.2").Code, "other IPs are not blocked")
+}
+
+func TestAuthLimiter_Disabled(t *testing.T) {
+	var nilLimiter *AuthLimiter
+	for _, l := range []*AuthLimiter{nilLimiter, Limits{}.AuthLimiter()} {
+		l.Fail("10.0.0.1")
+		assert.Zero(t, l.Blocked("10.0.0.1"))
+	}
+}
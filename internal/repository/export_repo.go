@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/shenikar/order-service/internal/mapper"
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// exportFetchSize - количество строк, читаемых из курсора за один FETCH
+const exportFetchSize = 1000
+
+// exportRow - строка выгрузки: заказ с доставкой, платежом и одним товаром
+type exportRow struct {
+	models.OrderDB
+	HasItem         bool   `db:"has_item"`
+	ItemChrtID      int    `db:"item_chrt_id"`
+	ItemTrackNumber string `db:"item_track_number"`
+	ItemPrice       int    `db:"item_price"`
+	ItemRid         string `db:"item_rid"`
+	ItemName        string `db:"item_name"`
+	ItemSale        int    `db:"item_sale"`
+	ItemSize        string `db:"item_size"`
+	ItemTotalPrice  int    `db:"item_total_price"`
+	ItemNmID        int    `db:"item_nm_id"`
+	ItemBrand       string `db:"item_brand"`
+	ItemStatus      int    `db:"item_status"`
+}
+
+// ExportOrders передает в fn заказы с товарами, подходящие под filter, от новых к старым.
+// Заказы читаются серверным курсором порциями по exportFetchSize строк в одной
+// read-only транзакции, поэтому выгрузка согласована и не держит всю выборку в памяти
+func (r *OrderRepository) ExportOrders(ctx context.Context, filter models.OrderFilter, fn func(order *models.Order) error) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin tx: %w", err)
+	}
+
+	// Транзакция только читает данные, поэтому всегда откатывается
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			slog.Error("Failed to rollback tx", "error", rbErr)
+		}
+	}()
+
+	query := "SELECT " + orderColumns + `,
+               i.chrt_id IS NOT NULL AS has_item,
+               COALESCE(i.chrt_id, 0) AS item_chrt_id, COALESCE(i.track_number, '') AS item_track_number,
+               COALESCE(i.price, 0) AS item_price, COALESCE(i.rid, '') AS item_rid,
+               COALESCE(i.name, '') AS item_name, COALESCE(i.sale, 0) AS item_sale,
+               COALESCE(i.size, '') AS item_size, COALESCE(i.total_price, 0) AS item_total_price,
+               COALESCE(i.nm_id, 0) AS item_nm_id, COALESCE(i.brand, '') AS item_brand,
+               COALESCE(i.status, 0) AS item_status` + orderJoins + `
+        LEFT JOIN items i ON i.order_uid = o.order_uid`
+
+	conditions, args := filterConditions(filter)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY o.date_created DESC, o.order_uid DESC, i.chrt_id"
+
+	if _, err := tx.ExecContext(ctx, "DECLARE orders_export NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	var current *models.Order
+	for {
+		var rows []exportRow
+		if err := tx.SelectContext(ctx, &rows, fmt.Sprintf("FETCH %d FROM orders_export", exportFetchSize)); err != nil {
+			return fmt.Errorf("failed to fetch exported orders: %w", err)
+		}
+
+		for _, row := range rows {
+			if current == nil || current.OrderUID != row.OrderUID {
+				// Строки одного заказа идут подряд, смена UID означает, что заказ собран
+				if current != nil {
+					if err := fn(current); err != nil {
+						return err
+					}
+				}
+				order := mapper.MapOrderDBToModel(row.OrderDB)
+				order.Items = []models.Item{}
+				current = &order
+			}
+			if row.HasItem {
+				current.Items = append(current.Items, models.Item{
+					OrderUID:    row.OrderUID,
+					ChrtID:      row.ItemChrtID,
+					TrackNumber: row.ItemTrackNumber,
+					Price:       row.ItemPrice,
+					Rid:         row.ItemRid,
+					Name:        row.ItemName,
+					Sale:        row.ItemSale,
+					Size:        row.ItemSize,
+					TotalPrice:  row.ItemTotalPrice,
+					NmID:        row.ItemNmID,
+					Brand:       row.ItemBrand,
+					Status:      row.ItemStatus,
+				})
+			}
+		}
+
+		if len(rows) < exportFetchSize {
+			break
+		}
+	}
+
+	if current != nil {
+		return fn(current)
+	}
+	return nil
+}
@@ -1,23 +1,72 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"slices"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 	"github.com/shenikar/order-service/internal/mapper"
 	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type OrderRepositoryInterface interface {
 	SaveOrder(order *models.Order) error
+	ImportOrders(orders []*models.Order) error
+	SaveOrderWithOffset(ctx context.Context, order *models.Order, offset models.KafkaOffset) error
+	SaveOffset(offset models.KafkaOffset) error
+	GetOffsets(topic string) ([]models.KafkaOffset, error)
 	GetOrderByUID(orderUID string) (*models.Order, error)
 	GetItemByOrderUID(orderUID string) ([]models.Item, error)
 	GetAllOrders() ([]models.Order, error)
+	ListOrders(filter models.OrderFilter, after *models.OrderCursor, limit int) ([]models.Order, error)
+	GetItemsByOrderUIDs(orderUIDs []string) (map[string][]models.Item, error)
+	GetOrdersByUIDs(orderUIDs []string) ([]models.Order, error)
+	ExportOrders(ctx context.Context, filter models.OrderFilter, fn func(order *models.Order) error) error
+	FindDuplicate(orderUID, fingerprint string) (string, error)
+	GetOrdersWithoutFingerprint(after *models.OrderCursor, limit int) ([]models.Order, error)
+	SetFingerprint(orderUID, fingerprint string) (string, error)
+	GetDuplicateGroups(limit int) ([]models.DuplicateGroup, error)
 }
 
+// ErrOrderNotFound возвращается, если заказа с указанным UID нет в базе данных
+var ErrOrderNotFound = errors.New("order not found")
+
+// ErrFingerprintConflict возвращается, если заказ с тем же содержимым сохранили одновременно
+// с этим и не пометили дублем: проверку на дубль нужно повторить
+var ErrFingerprintConflict = errors.New("order with the same fingerprint saved concurrently")
+
+// ErrSaveOffset возвращается, если не удалось записать позицию чтения партиции
+var ErrSaveOffset = errors.New("failed to save offset")
+
+// fingerprintOriginalIndex - уникальный индекс отпечатков заказов, не помеченных дублями
+const fingerprintOriginalIndex = "idx_orders_fingerprint_original"
+
+// orderColumns - колонки заказа с доставкой и платежом
+const orderColumns = `o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+               o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
+               COALESCE(o.duplicate_of, '') AS duplicate_of,
+               d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+               p.transaction, p.request_id, p.currency, p.provider, p.amount, p.payment_dt, p.bank,
+               p.delivery_cost, p.goods_total, p.custom_fee`
+
+// orderJoins - таблицы заказа с доставкой и платежом
+const orderJoins = `
+        FROM orders o
+        JOIN deliveries d ON o.order_uid = d.order_uid
+        JOIN payments p ON o.order_uid = p.order_uid`
+
+// orderSelect - общая часть запросов заказа с доставкой и платежом
+const orderSelect = "SELECT " + orderColumns + orderJoins
+
 type OrderRepository struct {
 	db *sqlx.DB
 }
@@ -29,7 +78,58 @@ func NewOrderRepository(dbConn *sqlx.DB) *OrderRepository {
 
 // SaveOrder сохраняет заказ в базе данных
 func (r *OrderRepository) SaveOrder(order *models.Order) error {
-	tx, err := r.db.Beginx()
+	ctx := context.Background()
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		return saveOrderTx(ctx, tx, order, true)
+	})
+}
+
+// ImportOrders сохраняет пачку исторических заказов в одной транзакции. События OrderAccepted
+// не создаются: загруженные из архива заказы уже обработаны получателями событий
+func (r *OrderRepository) ImportOrders(orders []*models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		for _, order := range orders {
+			if err := saveOrderTx(ctx, tx, order, false); err != nil {
+				return fmt.Errorf("order %s: %w", order.OrderUID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// SaveOrderWithOffset сохраняет заказ и позицию чтения Kafka в одной транзакции.
+// Каждый запрос транзакции получает span, дочерний к span'у из ctx
+func (r *OrderRepository) SaveOrderWithOffset(ctx context.Context, order *models.Order, offset models.KafkaOffset) error {
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := saveOrderTx(ctx, tx, order, true); err != nil {
+			return err
+		}
+		return saveOffset(ctx, tx, offset)
+	})
+}
+
+// SaveOffset сохраняет позицию чтения Kafka без заказа (например, для пропущенных сообщений)
+func (r *OrderRepository) SaveOffset(offset models.KafkaOffset) error {
+	return saveOffset(context.Background(), r.db, offset)
+}
+
+// GetOffsets возвращает сохраненные позиции чтения партиций топика
+func (r *OrderRepository) GetOffsets(topic string) ([]models.KafkaOffset, error) {
+	var offsets []models.KafkaOffset
+	err := r.db.Select(&offsets, `SELECT topic, partition, next_offset FROM kafka_offsets WHERE topic = $1`, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offsets for topic %s: %w", topic, err)
+	}
+	return offsets, nil
+}
+
+// inTx выполняет fn в транзакции и фиксирует ее, если fn не вернула ошибку
+func (r *OrderRepository) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin tx: %w", err)
 	}
@@ -37,25 +137,67 @@ func (r *OrderRepository) SaveOrder(order *models.Order) error {
 	// Безопасный rollback
 	defer func() {
 		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
-			log.Printf("failed to rollback tx: %v", rbErr)
+			slog.Error("Failed to rollback tx", "error", rbErr)
 		}
 	}()
 
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	// Фиксируем транзакцию
+	_, span := tracer.Start(ctx, "COMMIT", trace.WithSpanKind(trace.SpanKindClient))
+	err = tx.Commit()
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// saveOffset сохраняет позицию чтения партиции. Позиция только растет: экземпляр, у которого
+// партицию забрали при перебалансировке, может дописать уже обработанное сообщение позже нового
+// владельца партиции и не должен вернуть позицию назад
+func saveOffset(ctx context.Context, db sqlx.ExtContext, offset models.KafkaOffset) error {
+	_, err := namedExec(ctx, db, "INSERT", "kafka_offsets", `
+		INSERT INTO kafka_offsets (topic, partition, next_offset)
+		VALUES (:topic, :partition, :next_offset)
+		ON CONFLICT (topic, partition) DO UPDATE
+		SET next_offset = GREATEST(kafka_offsets.next_offset, EXCLUDED.next_offset), updated_at = now()`, offset)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSaveOffset, err)
+	}
+	return nil
+}
+
+// saveOrderTx сохраняет заказ со связанными сущностями в рамках транзакции.
+// Если publish, для нового заказа записывается событие OrderAccepted. Для дублей событие
+// не записывается: получатели учитывали бы выручку заказа дважды
+func saveOrderTx(ctx context.Context, tx *sqlx.Tx, order *models.Order, publish bool) error {
 	// Сохраняем заказ
-	_, err = tx.NamedExec(`
+	res, err := namedExec(ctx, tx, "INSERT", "orders", `
 		INSERT INTO orders (
 			order_uid, track_number, entry, locale, internal_signature, 
 			customer_id, delivery_service, shardkey, sm_id, 
-			date_created, oof_shard
+			date_created, oof_shard, fingerprint, duplicate_of
 		) VALUES (
 			:order_uid, :track_number, :entry, :locale, :internal_signature, 
 			:customer_id, :delivery_service, :shardkey, :sm_id,
-			:date_created, :oof_shard
+			:date_created, :oof_shard, NULLIF(:fingerprint, ''), NULLIF(:duplicate_of, '')
 		) ON CONFLICT (order_uid) DO NOTHING`, order)
+	if isFingerprintConflict(err) {
+		return fmt.Errorf("failed to save order %s: %w", order.OrderUID, ErrFingerprintConflict)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to save order: %w", err)
 	}
 
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
 	// Обновляем OrderUID для связанных сущностей
 	order.Delivery.OrderUID = order.OrderUID
 	order.Payment.OrderUID = order.OrderUID
@@ -64,7 +206,7 @@ func (r *OrderRepository) SaveOrder(order *models.Order) error {
 	}
 
 	// Сохраняем доставку
-	_, err = tx.NamedExec(`
+	_, err = namedExec(ctx, tx, "INSERT", "deliveries", `
 		INSERT INTO deliveries (
 			order_uid, name, phone, zip, city, address, region, email
 		) VALUES (
@@ -75,7 +217,7 @@ func (r *OrderRepository) SaveOrder(order *models.Order) error {
 	}
 
 	// Сохраняем платеж
-	_, err = tx.NamedExec(`
+	_, err = namedExec(ctx, tx, "INSERT", "payments", `
 		INSERT INTO payments (
 			order_uid, transaction, request_id, currency, provider, 
 			amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
@@ -89,7 +231,7 @@ func (r *OrderRepository) SaveOrder(order *models.Order) error {
 
 	// Сохраняем товары
 	for _, item := range order.Items {
-		_, err = tx.NamedExec(`
+		_, err = namedExec(ctx, tx, "INSERT", "items", `
 			INSERT INTO items (
 				order_uid, chrt_id, track_number, price, rid, name, sale, 
 				size, total_price, nm_id, brand, status
@@ -102,9 +244,22 @@ func (r *OrderRepository) SaveOrder(order *models.Order) error {
 		}
 	}
 
-	// Фиксируем транзакцию
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit tx: %w", err)
+	// Поисковый документ и событие о принятом заказе пишем в той же транзакции,
+	// повторно полученные заказы их не порождают
+	if inserted == 0 {
+		return nil
+	}
+	if err := insertSearchDocument(ctx, tx, order); err != nil {
+		return err
+	}
+	if publish && order.DuplicateOf == "" {
+		event, err := models.NewOrderAcceptedOutboxEvent(order)
+		if err != nil {
+			return err
+		}
+		if err := insertOutboxEvent(ctx, tx, event); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -112,14 +267,7 @@ func (r *OrderRepository) SaveOrder(order *models.Order) error {
 
 // GetAllOrders возвращает все заказы из базы данных
 func (r *OrderRepository) GetAllOrders() ([]models.Order, error) {
-	query := `SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
-               o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
-               d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
-               p.transaction, p.request_id, p.currency, p.provider, p.amount, p.payment_dt, p.bank,
-               p.delivery_cost, p.goods_total, p.custom_fee
-        FROM orders o
-        JOIN deliveries d ON o.order_uid = d.order_uid
-        JOIN payments p ON o.order_uid = p.order_uid`
+	query := orderSelect
 
 	var dbOrders []models.OrderDB
 	if err := r.db.Select(&dbOrders, query); err != nil {
@@ -145,18 +293,14 @@ func (r *OrderRepository) GetItemByOrderUID(orderUID string) ([]models.Item, err
 
 // GetOrderByUID возвращает заказ по его уникальному идентификатору
 func (r *OrderRepository) GetOrderByUID(orderUID string) (*models.Order, error) {
-	query := `SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
-               o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
-               d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
-               p.transaction, p.request_id, p.currency, p.provider, p.amount, p.payment_dt, p.bank,
-               p.delivery_cost, p.goods_total, p.custom_fee
-        	FROM orders o
-        	JOIN deliveries d ON o.order_uid = d.order_uid
-        	JOIN payments p ON o.order_uid = p.order_uid
-        	WHERE o.order_uid = $1`
+	query := orderSelect + ` WHERE o.order_uid = $1`
 
 	var dbo models.OrderDB
-	if err := r.db.Get(&dbo, query, orderUID); err != nil {
+	err := r.db.Get(&dbo, query, orderUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get order by UID %s: %w", orderUID, ErrOrderNotFound)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to get order by UID %s: %w", orderUID, err)
 	}
 
@@ -170,3 +314,232 @@ func (r *OrderRepository) GetOrderByUID(orderUID string) (*models.Order, error)
 
 	return &order, nil
 }
+
+// ListOrders возвращает до limit заказов без товаров, подходящих под filter,
+// от новых к старым. Если after задан, чтение продолжается после этой позиции
+func (r *OrderRepository) ListOrders(filter models.OrderFilter, after *models.OrderCursor, limit int) ([]models.Order, error) {
+	conditions, args := filterConditions(filter)
+	if after != nil {
+		args = append(args, after.DateCreated, after.OrderUID)
+		conditions = append(conditions, fmt.Sprintf("(o.date_created, o.order_uid) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := orderSelect
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY o.date_created DESC, o.order_uid DESC LIMIT $%d", len(args))
+
+	var dbOrders []models.OrderDB
+	if err := r.db.Select(&dbOrders, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return mapper.MapOrdersDBToModels(dbOrders), nil
+}
+
+// filterConditions формирует условия WHERE по фильтру и их аргументы
+func filterConditions(filter models.OrderFilter) ([]string, []any) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.CustomerID != "" {
+		addCondition("o.customer_id = $%d", filter.CustomerID)
+	}
+	if filter.DeliveryService != "" {
+		addCondition("o.delivery_service = $%d", filter.DeliveryService)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		addCondition("o.date_created >= $%d", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		addCondition("o.date_created < $%d", filter.CreatedTo)
+	}
+	if filter.NmID != 0 {
+		addCondition("EXISTS (SELECT 1 FROM items fi WHERE fi.order_uid = o.order_uid AND fi.nm_id = $%d)", filter.NmID)
+	}
+	if filter.Brand != "" {
+		addCondition("EXISTS (SELECT 1 FROM items fi WHERE fi.order_uid = o.order_uid AND fi.brand = $%d)", filter.Brand)
+	}
+
+	return conditions, args
+}
+
+// GetOrdersByUIDs возвращает найденные заказы с товарами одним запросом: товары каждого
+// заказа собираются в JSON-массив. Отсутствующие в базе UID пропускаются
+func (r *OrderRepository) GetOrdersByUIDs(orderUIDs []string) ([]models.Order, error) {
+	if len(orderUIDs) == 0 {
+		return []models.Order{}, nil
+	}
+
+	query := "SELECT " + orderColumns + `,
+               COALESCE((SELECT json_agg(i ORDER BY i.chrt_id) FROM items i WHERE i.order_uid = o.order_uid), '[]') AS items` +
+		orderJoins + `
+        WHERE o.order_uid = ANY($1)`
+
+	var rows []struct {
+		models.OrderDB
+		Items []byte `db:"items"`
+	}
+	if err := r.db.Select(&rows, query, orderUIDs); err != nil {
+		return nil, fmt.Errorf("failed to get %d orders: %w", len(orderUIDs), err)
+	}
+
+	orders := make([]models.Order, 0, len(rows))
+	for _, row := range rows {
+		order := mapper.MapOrderDBToModel(row.OrderDB)
+		if err := json.Unmarshal(row.Items, &order.Items); err != nil {
+			return nil, fmt.Errorf("failed to decode items of order %s: %w", order.OrderUID, err)
+		}
+		for i := range order.Items {
+			order.Items[i].OrderUID = order.OrderUID
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// GetItemsByOrderUIDs возвращает товары нескольких заказов одним запросом, ключ - UID заказа
+func (r *OrderRepository) GetItemsByOrderUIDs(orderUIDs []string) (map[string][]models.Item, error) {
+	byOrder := make(map[string][]models.Item, len(orderUIDs))
+	if len(orderUIDs) == 0 {
+		return byOrder, nil
+	}
+
+	query := `SELECT order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+        FROM items WHERE order_uid = ANY($1) ORDER BY order_uid, chrt_id`
+
+	var items []models.Item
+	if err := r.db.Select(&items, query, orderUIDs); err != nil {
+		return nil, fmt.Errorf("failed to get items for %d orders: %w", len(orderUIDs), err)
+	}
+
+	for _, item := range items {
+		byOrder[item.OrderUID] = append(byOrder[item.OrderUID], item)
+	}
+
+	return byOrder, nil
+}
+
+// FindDuplicate возвращает UID ранее сохраненного заказа с тем же отпечатком содержимого,
+// не помеченного дублем, или пустую строку, если такого заказа нет
+func (r *OrderRepository) FindDuplicate(orderUID, fingerprint string) (string, error) {
+	query := `SELECT order_uid FROM orders
+		WHERE fingerprint = $1 AND duplicate_of IS NULL AND order_uid <> $2
+		LIMIT 1`
+
+	var duplicateOf string
+	err := r.db.Get(&duplicateOf, query, fingerprint, orderUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find duplicate for order %s: %w", orderUID, err)
+	}
+
+	return duplicateOf, nil
+}
+
+// GetOrdersWithoutFingerprint возвращает до limit заказов с товарами без отпечатка содержимого
+// от старых к новым. Если after задан, чтение продолжается после этой позиции
+func (r *OrderRepository) GetOrdersWithoutFingerprint(after *models.OrderCursor, limit int) ([]models.Order, error) {
+	query := `SELECT o.order_uid FROM orders o WHERE o.fingerprint IS NULL`
+	args := []any{}
+	if after != nil {
+		args = append(args, after.DateCreated, after.OrderUID)
+		query += " AND (o.date_created, o.order_uid) > ($1, $2)"
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY o.date_created, o.order_uid LIMIT $%d", len(args))
+
+	var orderUIDs []string
+	if err := r.db.Select(&orderUIDs, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get orders without fingerprint: %w", err)
+	}
+
+	orders, err := r.GetOrdersByUIDs(orderUIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// GetOrdersByUIDs не сохраняет порядок, а по последнему заказу строится курсор
+	slices.SortFunc(orders, func(a, b models.Order) int {
+		if c := a.DateCreated.Compare(b.DateCreated); c != 0 {
+			return c
+		}
+		return strings.Compare(a.OrderUID, b.OrderUID)
+	})
+
+	return orders, nil
+}
+
+// SetFingerprint сохраняет отпечаток заказа, у которого его еще нет, и помечает заказ дублем,
+// если уже есть заказ с тем же содержимым. Возвращает UID оригинала или пустую строку
+func (r *OrderRepository) SetFingerprint(orderUID, fingerprint string) (string, error) {
+	query := `UPDATE orders
+		SET fingerprint = $2,
+			duplicate_of = (
+				SELECT original.order_uid FROM orders original
+				WHERE original.fingerprint = $2 AND original.duplicate_of IS NULL AND original.order_uid <> $1
+				LIMIT 1
+			)
+		WHERE order_uid = $1 AND fingerprint IS NULL
+		RETURNING COALESCE(duplicate_of, '')`
+
+	var duplicateOf string
+	err := r.db.Get(&duplicateOf, query, orderUID, fingerprint)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Отпечаток уже заполнен другим экземпляром сервиса
+		return "", nil
+	}
+	if isFingerprintConflict(err) {
+		return "", fmt.Errorf("failed to set fingerprint of order %s: %w", orderUID, ErrFingerprintConflict)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to set fingerprint of order %s: %w", orderUID, err)
+	}
+
+	return duplicateOf, nil
+}
+
+// isFingerprintConflict проверяет, что err - нарушение уникальности отпечатков оригиналов
+func isFingerprintConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == fingerprintOriginalIndex
+}
+
+// GetDuplicateGroups возвращает платежные транзакции, на которые приходится больше одного заказа
+func (r *OrderRepository) GetDuplicateGroups(limit int) ([]models.DuplicateGroup, error) {
+	query := `SELECT p.transaction, json_agg(p.order_uid ORDER BY o.date_created, o.order_uid) AS order_uids
+		FROM payments p
+		JOIN orders o ON o.order_uid = p.order_uid
+		GROUP BY p.transaction
+		HAVING COUNT(*) > 1
+		ORDER BY MAX(o.date_created) DESC
+		LIMIT $1`
+
+	var rows []struct {
+		Transaction string `db:"transaction"`
+		OrderUIDs   []byte `db:"order_uids"`
+	}
+	if err := r.db.Select(&rows, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to get duplicate groups: %w", err)
+	}
+
+	groups := make([]models.DuplicateGroup, 0, len(rows))
+	for _, row := range rows {
+		group := models.DuplicateGroup{Transaction: row.Transaction}
+		if err := json.Unmarshal(row.OrderUIDs, &group.OrderUIDs); err != nil {
+			return nil, fmt.Errorf("failed to decode order UIDs for transaction %s: %w", row.Transaction, err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
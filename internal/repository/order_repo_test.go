@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOrder() *models.Order {
+	return &models.Order{
+		OrderUID:    "uid1",
+		TrackNumber: "WBILMTESTTRACK",
+		CustomerID:  "customer",
+		DateCreated: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		Payment:     models.Payment{Transaction: "tx1", Currency: "RUB", Amount: 181700},
+		Items:       []models.Item{{ChrtID: 1, Price: 181700, TotalPrice: 181700}},
+	}
+}
+
+// expectOrderInserts ожидает запись заказа со связанными сущностями; inserted - вставлен ли заказ
+func expectOrderInserts(mock sqlmock.Sqlmock, inserted bool) {
+	rows := int64(0)
+	if inserted {
+		rows = 1
+	}
+	mock.ExpectExec(`INSERT INTO orders`).WillReturnResult(sqlmock.NewResult(0, rows))
+	mock.ExpectExec(`INSERT INTO deliveries`).WillReturnResult(sqlmock.NewResult(0, rows))
+	mock.ExpectExec(`INSERT INTO payments`).WillReturnResult(sqlmock.NewResult(0, rows))
+	mock.ExpectExec(`INSERT INTO items`).WillReturnResult(sqlmock.NewResult(0, rows))
+}
+
+func TestSaveOrderWithOffset_WritesOutboxEventInSameTx(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOrderRepository(db)
+	offset := models.KafkaOffset{Topic: "orders", Partition: 0, NextOffset: 43}
+
+	mock.ExpectBegin()
+	expectOrderInserts(mock, true)
+	mock.ExpectExec(`INSERT INTO order_search`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO outbox_events`).
+		WithArgs(models.EventTypeOrderAccepted, "uid1", "OrderAccepted:uid1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO kafka_offsets`).
+		WithArgs("orders", 0, int64(43)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SaveOrderWithOffset(context.Background(), testOrder(), offset))
+}
+
+func TestSaveOrderWithOffset_OutboxFailureRollsBackOrder(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOrderRepository(db)
+
+	mock.ExpectBegin()
+	expectOrderInserts(mock, true)
+	mock.ExpectExec(`INSERT INTO order_search`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnError(errors.New("disk full"))
+	mock.ExpectRollback()
+
+	err := repo.SaveOrderWithOffset(context.Background(), testOrder(), models.KafkaOffset{Topic: "orders"})
+	assert.ErrorContains(t, err, "failed to save outbox event")
+}
+
+func TestSaveOrderWithOffset_ExistingOrderWritesNoEvent(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOrderRepository(db)
+
+	mock.ExpectBegin()
+	expectOrderInserts(mock, false)
+	mock.ExpectExec(`INSERT INTO kafka_offsets`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SaveOrderWithOffset(context.Background(), testOrder(), models.KafkaOffset{Topic: "orders"}))
+}
+
+func TestSaveOrderWithOffset_DuplicateWritesNoEvent(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOrderRepository(db)
+	order := testOrder()
+	order.DuplicateOf = "uid0"
+
+	mock.ExpectBegin()
+	expectOrderInserts(mock, true)
+	mock.ExpectExec(`INSERT INTO order_search`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO kafka_offsets`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SaveOrderWithOffset(context.Background(), order, models.KafkaOffset{Topic: "orders"}))
+}
+
+func TestImportOrders_WritesNoOutboxEvent(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOrderRepository(db)
+
+	mock.ExpectBegin()
+	expectOrderInserts(mock, true)
+	mock.ExpectExec(`INSERT INTO order_search`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.ImportOrders([]*models.Order{testOrder()}))
+}
+
+func TestSaveOrderWithOffset_FingerprintConflict(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOrderRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO orders`).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: fingerprintOriginalIndex})
+	mock.ExpectRollback()
+
+	err := repo.SaveOrderWithOffset(context.Background(), testOrder(), models.KafkaOffset{Topic: "orders"})
+	assert.ErrorIs(t, err, ErrFingerprintConflict)
+}
+
+func TestSetFingerprint(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOrderRepository(db)
+
+	mock.ExpectQuery(`UPDATE orders\s+SET fingerprint = \$2`).
+		WithArgs("uid2", "fp").
+		WillReturnRows(sqlmock.NewRows([]string{"duplicate_of"}).AddRow("uid1"))
+	mock.ExpectQuery(`UPDATE orders\s+SET fingerprint = \$2`).
+		WithArgs("uid3", "fp").
+		WillReturnRows(sqlmock.NewRows([]string{"duplicate_of"}))
+	mock.ExpectQuery(`UPDATE orders\s+SET fingerprint = \$2`).
+		WithArgs("uid4", "fp").
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: fingerprintOriginalIndex})
+
+	duplicateOf, err := repo.SetFingerprint("uid2", "fp")
+	require.NoError(t, err)
+	assert.Equal(t, "uid1", duplicateOf)
+
+	// Отпечаток уже заполнен другим экземпляром сервиса
+	duplicateOf, err = repo.SetFingerprint("uid3", "fp")
+	require.NoError(t, err)
+	assert.Empty(t, duplicateOf)
+
+	_, err = repo.SetFingerprint("uid4", "fp")
+	assert.ErrorIs(t, err, ErrFingerprintConflict)
+}
+
+func TestSaveOffset_NeverMovesBackwards(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOrderRepository(db)
+
+	mock.ExpectExec(`ON CONFLICT \(topic, partition\) DO UPDATE\s+SET next_offset = GREATEST\(kafka_offsets.next_offset, EXCLUDED.next_offset\)`).
+		WithArgs("orders", 1, int64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.SaveOffset(models.KafkaOffset{Topic: "orders", Partition: 1, NextOffset: 10}))
+}
+
+func TestGetOffsets(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOrderRepository(db)
+
+	mock.ExpectQuery(`SELECT topic, partition, next_offset FROM kafka_offsets WHERE topic = \$1`).
+		WithArgs("orders").
+		WillReturnRows(sqlmock.NewRows([]string{"topic", "partition", "next_offset"}).
+			AddRow("orders", 0, 43).
+			AddRow("orders", 1, 7))
+
+	offsets, err := repo.GetOffsets("orders")
+	require.NoError(t, err)
+	assert.Equal(t, []models.KafkaOffset{
+		{Topic: "orders", Partition: 0, NextOffset: 43},
+		{Topic: "orders", Partition: 1, NextOffset: 7},
+	}, offsets)
+}
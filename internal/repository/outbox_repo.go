@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shenikar/order-service/internal/models"
+)
+
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+// NewOutboxRepository создает новый экземпляр OutboxRepository
+func NewOutboxRepository(dbConn *sqlx.DB) *OutboxRepository {
+	return &OutboxRepository{db: dbConn}
+}
+
+// insertOutboxEvent сохраняет событие в outbox в рамках переданной транзакции
+func insertOutboxEvent(ctx context.Context, tx *sqlx.Tx, event *models.OutboxEvent) error {
+	_, err := namedExec(ctx, tx, "INSERT", "outbox_events", `
+		INSERT INTO outbox_events (
+			event_type, aggregate_id, dedupe_key, payload
+		) VALUES (
+			:event_type, :aggregate_id, :dedupe_key, :payload
+		) ON CONFLICT (dedupe_key) DO NOTHING`, event)
+	if err != nil {
+		return fmt.Errorf("failed to save outbox event: %w", err)
+	}
+	return nil
+}
+
+// PublishPending выбирает до limit неопубликованных событий, передает их в publish
+// и помечает опубликованными. Строки блокируются на время публикации (SKIP LOCKED),
+// поэтому несколько экземпляров сервиса не отправляют одно и то же событие одновременно.
+// Если publish вернул ошибку, события останутся неопубликованными и будут отправлены повторно.
+func (r *OutboxRepository) PublishPending(
+	ctx context.Context, limit int, publish func([]models.OutboxEvent) error,
+) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin tx: %w", err)
+	}
+
+	// Безопасный rollback
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			slog.Error("Failed to rollback tx", "error", rbErr)
+		}
+	}()
+
+	var events []models.OutboxEvent
+	err = tx.SelectContext(ctx, &events, `
+		SELECT id, event_type, aggregate_id, dedupe_key, payload, created_at, published_at
+		FROM outbox_events
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get outbox events: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(events); err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE outbox_events SET published_at = now() WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark outbox events published: %w", err)
+	}
+
+	// Фиксируем транзакцию
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return len(events), nil
+}
+
+// DeletePublished удаляет до limit событий, опубликованных раньше before, и возвращает их количество.
+// Последние keepLast событий не удаляются независимо от возраста: по ним лента заказов досылает
+// пропущенные события при возобновлении подписки. Неопубликованные события не удаляются никогда
+func (r *OutboxRepository) DeletePublished(ctx context.Context, before time.Time, keepLast, limit int) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM outbox_events
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE published_at < $1
+				AND id < (
+					SELECT COALESCE(MIN(id), 0) FROM (
+						SELECT id FROM outbox_events ORDER BY id DESC LIMIT $2
+					) AS recent
+				)
+			ORDER BY id
+			LIMIT $3
+		)`, before, keepLast, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deleted outbox events count: %w", err)
+	}
+	return deleted, nil
+}
+
+// GetEventsAfter возвращает до limit событий типа eventType с идентификатором больше afterID
+// в порядке возрастания идентификатора, независимо от того, опубликованы ли они
+func (r *OutboxRepository) GetEventsAfter(
+	ctx context.Context, eventType string, afterID int64, limit int,
+) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.SelectContext(ctx, &events, `
+		SELECT id, event_type, aggregate_id, dedupe_key, payload, created_at, published_at
+		FROM outbox_events
+		WHERE event_type = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`, eventType, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox events after %d: %w", afterID, err)
+	}
+	return events, nil
+}
+
+// GetLastEventID возвращает идентификатор последнего события типа eventType или 0, если событий нет
+func (r *OutboxRepository) GetLastEventID(ctx context.Context, eventType string) (int64, error) {
+	var id int64
+	err := r.db.GetContext(ctx, &id, `SELECT COALESCE(MAX(id), 0) FROM outbox_events WHERE event_type = $1`, eventType)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last outbox event id: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var outboxEventColumns = []string{"id", "event_type", "aggregate_id", "dedupe_key", "payload", "created_at", "published_at"}
+
+func TestOutboxRepository_PublishPending(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOutboxRepository(db)
+	created := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM outbox_events\s+WHERE published_at IS NULL.*FOR UPDATE SKIP LOCKED`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows(outboxEventColumns).
+			AddRow(1, models.EventTypeOrderAccepted, "uid1", "OrderAccepted:uid1", []byte(`{}`), created, nil).
+			AddRow(2, models.EventTypeOrderAccepted, "uid2", "OrderAccepted:uid2", []byte(`{}`), created, nil))
+	mock.ExpectExec(`UPDATE outbox_events SET published_at = now\(\) WHERE id = ANY\(\$1\)`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	var published []string
+	n, err := repo.PublishPending(context.Background(), 10, func(events []models.OutboxEvent) error {
+		for _, event := range events {
+			published = append(published, event.AggregateID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"uid1", "uid2"}, published)
+}
+
+func TestOutboxRepository_PublishPending_PublishFailure(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM outbox_events`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows(outboxEventColumns).
+			AddRow(1, models.EventTypeOrderAccepted, "uid1", "OrderAccepted:uid1", []byte(`{}`), time.Now(), nil))
+	// События не помечаются опубликованными, транзакция откатывается
+	mock.ExpectRollback()
+
+	publishErr := errors.New("kafka unavailable")
+	_, err := repo.PublishPending(context.Background(), 10, func([]models.OutboxEvent) error {
+		return publishErr
+	})
+	assert.ErrorIs(t, err, publishErr)
+}
+
+func TestOutboxRepository_DeletePublished(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOutboxRepository(db)
+	before := time.Date(2026, 10, 12, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`DELETE FROM outbox_events\s+WHERE id IN \(\s+SELECT id FROM outbox_events\s+WHERE published_at < \$1`).
+		WithArgs(before, 1000, 500).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	deleted, err := repo.DeletePublished(context.Background(), before, 1000, 500)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), deleted)
+}
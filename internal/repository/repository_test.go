@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockDB создает sqlx.DB поверх sqlmock и проверяет в конце теста, что все ожидания выполнены
+func newMockDB(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+		_ = db.Close()
+	})
+	return sqlx.NewDb(db, "pgx"), mock
+}
+
+// arrayConverter пропускает срезы как есть: массивы PostgreSQL, например для = ANY($1),
+// передаются драйверу pgx без преобразования
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v any) (driver.Value, error) {
+	if v != nil && reflect.TypeOf(v).Kind() == reflect.Slice {
+		if _, ok := v.([]byte); !ok {
+			return v, nil
+		}
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
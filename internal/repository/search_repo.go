@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/search"
+	"github.com/shenikar/order-service/internal/tracing"
+)
+
+// SearchRepositoryInterface - поиск заказов по текстовым полям
+type SearchRepositoryInterface interface {
+	SearchOrders(ctx context.Context, terms []string, limit int) ([]models.SearchHit, error)
+}
+
+type SearchRepository struct {
+	db *sqlx.DB
+}
+
+// NewSearchRepository создает новый экземпляр SearchRepository
+func NewSearchRepository(dbConn *sqlx.DB) *SearchRepository {
+	return &SearchRepository{db: dbConn}
+}
+
+// insertSearchDocument сохраняет текстовые поля заказа для поиска в рамках переданной транзакции
+func insertSearchDocument(ctx context.Context, tx *sqlx.Tx, order *models.Order) error {
+	fields := search.Fields(order)
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		values[i] = field.Value
+	}
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal search fields: %w", err)
+	}
+
+	ctx, span := startStatement(ctx, "INSERT", "order_search")
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO order_search (order_uid, fields, document)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (order_uid) DO NOTHING`, order.OrderUID, fieldsJSON, strings.Join(values, " "))
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to save search document: %w", err)
+	}
+	return nil
+}
+
+// SearchOrders ищет заказы, в которых все слова terms встречаются как префиксы слов
+// (полнотекстовый индекс), либо запрос похож на часть текста заказа или содержится
+// в нем (триграммный индекс). Заказы упорядочены по убыванию релевантности
+func (r *SearchRepository) SearchOrders(ctx context.Context, terms []string, limit int) ([]models.SearchHit, error) {
+	phrase := strings.Join(terms, " ")
+	query := `
+		SELECT s.order_uid, o.date_created, s.fields,
+		       ts_rank_cd(s.tsv, q.tsq) + word_similarity($2, s.document) AS rank
+		FROM order_search s
+		JOIN orders o ON o.order_uid = s.order_uid
+		CROSS JOIN to_tsquery('simple', $1) AS q (tsq)
+		WHERE s.tsv @@ q.tsq
+		   OR $2 <% s.document
+		   OR s.document ILIKE '%' || $2 || '%'
+		ORDER BY rank DESC, o.date_created DESC, s.order_uid
+		LIMIT $3`
+
+	var rows []struct {
+		OrderUID    string    `db:"order_uid"`
+		DateCreated time.Time `db:"date_created"`
+		Fields      []byte    `db:"fields"`
+		Rank        float64   `db:"rank"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, search.TSQuery(terms), phrase, limit); err != nil {
+		return nil, fmt.Errorf("failed to search orders: %w", err)
+	}
+
+	hits := make([]models.SearchHit, 0, len(rows))
+	for _, row := range rows {
+		hit := models.SearchHit{OrderUID: row.OrderUID, DateCreated: row.DateCreated, Rank: row.Rank}
+		if err := json.Unmarshal(row.Fields, &hit.Fields); err != nil {
+			return nil, fmt.Errorf("failed to decode search fields for order %s: %w", row.OrderUID, err)
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
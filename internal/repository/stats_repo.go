@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// StatsRepositoryInterface - агрегаты заказов для аналитики
+type StatsRepositoryInterface interface {
+	GetOrderStats(ctx context.Context, query models.StatsQuery) ([]models.OrderStats, error)
+	GetOrderStatsRollup(ctx context.Context, query models.StatsQuery) ([]models.OrderStats, error)
+	RefreshStatsRollups(ctx context.Context) error
+	GetProductStats(ctx context.Context, query models.ProductStatsQuery) (*models.ProductStats, error)
+}
+
+// liveStatsDimensions - выражения измерений статистики по таблицам заказов
+var liveStatsDimensions = map[string]string{
+	models.StatsByDay:             "date_trunc('day', o.date_created, 'UTC') AS period",
+	models.StatsByHour:            "date_trunc('hour', o.date_created, 'UTC') AS period",
+	models.StatsByCurrency:        "COALESCE(p.currency, '') AS currency",
+	models.StatsByDeliveryService: "COALESCE(o.delivery_service, '') AS delivery_service",
+	models.StatsByBank:            "COALESCE(p.bank, '') AS bank",
+	models.StatsByProvider:        "COALESCE(p.provider, '') AS provider",
+	models.StatsByRegion:          "COALESCE(d.region, '') AS region",
+	models.StatsByBrand:           "ob.brand AS brand",
+}
+
+// rollupStatsDimensions - выражения измерений статистики по почасовым срезам
+var rollupStatsDimensions = map[string]string{
+	models.StatsByDay:             "date_trunc('day', r.bucket, 'UTC') AS period",
+	models.StatsByHour:            "r.bucket AS period",
+	models.StatsByCurrency:        "r.currency",
+	models.StatsByDeliveryService: "r.delivery_service",
+	models.StatsByBank:            "r.bank",
+	models.StatsByProvider:        "r.provider",
+	models.StatsByRegion:          "r.region",
+	models.StatsByBrand:           "r.brand",
+}
+
+type StatsRepository struct {
+	db *sqlx.DB
+}
+
+// NewStatsRepository создает новый экземпляр StatsRepository
+func NewStatsRepository(dbConn *sqlx.DB) *StatsRepository {
+	return &StatsRepository{db: dbConn}
+}
+
+// GetOrderStats считает агрегаты заказов по таблицам заказов.
+// Дубли по содержимому (duplicate_of) в статистику не входят. При группировке
+// по бренду заказ учитывается в каждом бренде, товары которого в нем есть
+func (r *StatsRepository) GetOrderStats(ctx context.Context, query models.StatsQuery) ([]models.OrderStats, error) {
+	from := `
+		FROM orders o
+		JOIN payments p ON p.order_uid = o.order_uid
+		JOIN deliveries d ON d.order_uid = o.order_uid
+		CROSS JOIN LATERAL (SELECT COUNT(*) AS items FROM items i WHERE i.order_uid = o.order_uid) ic`
+	if slices.Contains(query.GroupBy, models.StatsByBrand) {
+		from += `
+		CROSS JOIN LATERAL (SELECT DISTINCT COALESCE(i.brand, '') AS brand FROM items i WHERE i.order_uid = o.order_uid) ob`
+	}
+
+	conditions := []string{"o.duplicate_of IS NULL"}
+	var args []any
+	if !query.CreatedFrom.IsZero() {
+		args = append(args, query.CreatedFrom)
+		conditions = append(conditions, fmt.Sprintf("o.date_created >= $%d", len(args)))
+	}
+	if !query.CreatedTo.IsZero() {
+		args = append(args, query.CreatedTo)
+		conditions = append(conditions, fmt.Sprintf("o.date_created < $%d", len(args)))
+	}
+
+	aggregates := `COUNT(*) AS orders,
+		COALESCE(SUM(p.amount), 0) AS amount,
+		COALESCE(SUM(p.goods_total), 0) AS goods_total,
+		COALESCE(SUM(p.delivery_cost), 0) AS delivery_cost,
+		COALESCE(AVG(ic.items), 0)::float8 AS avg_items`
+
+	return r.selectStats(ctx, statsQuery(query.GroupBy, liveStatsDimensions, aggregates, from, conditions), args)
+}
+
+// GetOrderStatsRollup считает агрегаты заказов по почасовым срезам.
+// Границы периода должны быть выровнены по часу, иначе срезы захватят лишние заказы
+func (r *StatsRepository) GetOrderStatsRollup(ctx context.Context, query models.StatsQuery) ([]models.OrderStats, error) {
+	from := " FROM order_stats_hourly r"
+	if slices.Contains(query.GroupBy, models.StatsByBrand) {
+		from = " FROM order_brand_stats_hourly r"
+	}
+
+	var conditions []string
+	var args []any
+	if !query.CreatedFrom.IsZero() {
+		args = append(args, query.CreatedFrom)
+		conditions = append(conditions, fmt.Sprintf("r.bucket >= $%d", len(args)))
+	}
+	if !query.CreatedTo.IsZero() {
+		args = append(args, query.CreatedTo)
+		conditions = append(conditions, fmt.Sprintf("r.bucket < $%d", len(args)))
+	}
+
+	aggregates := `SUM(r.orders)::bigint AS orders,
+		SUM(r.amount)::bigint AS amount,
+		SUM(r.goods_total)::bigint AS goods_total,
+		SUM(r.delivery_cost)::bigint AS delivery_cost,
+		COALESCE(SUM(r.items)::float8 / NULLIF(SUM(r.orders), 0), 0) AS avg_items`
+
+	return r.selectStats(ctx, statsQuery(query.GroupBy, rollupStatsDimensions, aggregates, from, conditions), args)
+}
+
+// RefreshStatsRollups пересчитывает почасовые срезы, не блокируя чтение из них
+func (r *StatsRepository) RefreshStatsRollups(ctx context.Context) error {
+	for _, view := range []string{"order_stats_hourly", "order_brand_stats_hourly"} {
+		if _, err := r.db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+view); err != nil {
+			return fmt.Errorf("failed to refresh %s: %w", view, err)
+		}
+	}
+	return nil
+}
+
+// GetProductStats считает продажи товара или бренда одним согласованным снимком БД.
+// Дубли по содержимому (duplicate_of) в статистику не входят
+func (r *StatsRepository) GetProductStats(ctx context.Context, query models.ProductStatsQuery) (*models.ProductStats, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+
+	// Транзакция только читает данные, поэтому всегда откатывается
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			slog.Error("Failed to rollback tx", "error", rbErr)
+		}
+	}()
+
+	conditions := []string{"o.duplicate_of IS NULL"}
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if query.NmID != 0 {
+		addCondition("i.nm_id = $%d", query.NmID)
+	}
+	if query.Brand != "" {
+		addCondition("i.brand = $%d", query.Brand)
+	}
+	if !query.CreatedFrom.IsZero() {
+		addCondition("o.date_created >= $%d", query.CreatedFrom)
+	}
+	if !query.CreatedTo.IsZero() {
+		addCondition("o.date_created < $%d", query.CreatedTo)
+	}
+	from := `
+		FROM items i
+		JOIN orders o ON o.order_uid = i.order_uid
+		JOIN payments p ON p.order_uid = i.order_uid
+		WHERE ` + strings.Join(conditions, " AND ")
+
+	stats := &models.ProductStats{NmID: query.NmID, Brand: query.Brand}
+	if err := tx.QueryRowxContext(ctx, "SELECT COUNT(*), COUNT(DISTINCT i.order_uid)"+from, args...).
+		Scan(&stats.Units, &stats.Orders); err != nil {
+		return nil, fmt.Errorf("failed to count product sales: %w", err)
+	}
+
+	stats.Revenue = []models.CurrencyAmount{}
+	if err := tx.SelectContext(ctx, &stats.Revenue,
+		"SELECT COALESCE(p.currency, '') AS currency, COALESCE(SUM(i.total_price), 0) AS amount"+from+
+			" GROUP BY 1 ORDER BY 1", args...); err != nil {
+		return nil, fmt.Errorf("failed to get product revenue: %w", err)
+	}
+
+	stats.SaleDistribution = []models.SaleBucket{}
+	if err := tx.SelectContext(ctx, &stats.SaleDistribution,
+		"SELECT COALESCE(i.sale, 0) AS sale, COUNT(*) AS units"+from+" GROUP BY 1 ORDER BY 1", args...); err != nil {
+		return nil, fmt.Errorf("failed to get product sale distribution: %w", err)
+	}
+
+	stats.Sizes = []models.SizeStats{}
+	if err := tx.SelectContext(ctx, &stats.Sizes,
+		"SELECT COALESCE(i.size, '') AS size, COUNT(*) AS units"+from+" GROUP BY 1 ORDER BY 2 DESC, 1", args...); err != nil {
+		return nil, fmt.Errorf("failed to get product sizes: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (r *StatsRepository) selectStats(ctx context.Context, query string, args []any) ([]models.OrderStats, error) {
+	stats := []models.OrderStats{}
+	if err := r.db.SelectContext(ctx, &stats, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get order stats: %w", err)
+	}
+	return stats, nil
+}
+
+// statsQuery собирает запрос агрегатов, сгруппированных и упорядоченных по измерениям groupBy
+func statsQuery(groupBy []string, dimensions map[string]string, aggregates, from string, conditions []string) string {
+	columns := make([]string, 0, len(groupBy)+1)
+	positions := make([]string, 0, len(groupBy))
+	for i, dimension := range groupBy {
+		columns = append(columns, dimensions[dimension])
+		positions = append(positions, fmt.Sprint(i+1))
+	}
+	columns = append(columns, aggregates)
+
+	query := "SELECT " + strings.Join(columns, ", ") + from
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	if len(positions) > 0 {
+		query += " GROUP BY " + strings.Join(positions, ", ") + " ORDER BY " + strings.Join(positions, ", ")
+	}
+	return query
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shenikar/order-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/shenikar/order-service/internal/repository")
+
+// startStatement начинает span запроса operation к таблице table
+func startStatement(ctx context.Context, operation, table string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBCollectionName(table),
+		),
+	)
+}
+
+// namedExec выполняет именованный запрос operation к таблице table в отдельном span'е
+func namedExec(ctx context.Context, db sqlx.ExtContext, operation, table, query string, arg any) (sql.Result, error) {
+	ctx, span := startStatement(ctx, operation, table)
+	res, err := sqlx.NamedExecContext(ctx, db, query, arg)
+	tracing.End(span, err)
+	return res, err
+}
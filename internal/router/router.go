@@ -1,27 +1,119 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	_ "github.com/shenikar/order-service/docs" // Import Swagger docs for router initialization
+	"github.com/shenikar/order-service/internal/auth"
 	"github.com/shenikar/order-service/internal/handler"
 	"github.com/shenikar/order-service/internal/metrics"
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/ratelimit"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRoutes(engine *gin.Engine, orderHandler *handler.OrderHandler, metricsMiddleware gin.HandlerFunc) {
+// SetupRoutes регистрирует маршруты. Маршруты API повторяются для каждой версии из versions,
+// служебные маршруты (главная страница, GraphQL, проверки состояния, Swagger) от версии не зависят.
+// Данные заказов доступны только аутентифицированным клиентам с нужной ролью:
+// партнерам - отдельные заказы, аналитикам - еще списки, выгрузки и статистика,
+// службе поддержки - все, включая поиск по персональным данным и GraphQL.
+// Частота запросов ограничивается отдельно для каждой группы из rateLimits:
+// orders - отдельные заказы, batch - пакетное получение (по токену на заказ),
+// reports - списки и статистика, export - выгрузки и поток заказов, search - поиск и GraphQL.
+// Перед проверкой учетных данных authLimiter ограничивает неудачные попытки с одного IP-адреса
+func SetupRoutes(
+	engine *gin.Engine,
+	versions []Version,
+	authenticator *auth.Authenticator,
+	rateLimits ratelimit.Limits,
+	authLimiter *ratelimit.AuthLimiter,
+	healthHandler *handler.HealthHandler,
+	orderHandler *handler.OrderHandler,
+	streamHandler *handler.StreamHandler,
+	statsHandler *handler.StatsHandler,
+	productHandler *handler.ProductHandler,
+	searchHandler *handler.SearchHandler,
+	graphqlHandler http.Handler,
+	metricsMiddleware gin.HandlerFunc,
+) {
 	engine.LoadHTMLFiles("web/index.html")
 
 	// Группа для метрик - без нашего middleware
 	engine.GET("/metrics", metrics.PrometheusHandler())
 
+	anyRole := authenticator.Require()
+	staff := authenticator.Require(auth.RoleSupport, auth.RoleAnalyst)
+	support := authenticator.Require(auth.RoleSupport)
+
+	// Ограничители создаются один раз, чтобы маршруты всех версий делили лимит клиента
+	ordersLimit := rateLimits.Middleware(ratelimit.GroupOrders)
+	batchLimit := rateLimits.MiddlewareWithCost(ratelimit.GroupBatch, batchGetCost)
+	reportsLimit := rateLimits.Middleware(ratelimit.GroupReports)
+	exportLimit := rateLimits.Middleware(ratelimit.GroupExport)
+	searchLimit := rateLimits.Middleware(ratelimit.GroupSearch)
+	authLimit := authLimiter.Middleware()
+
 	// Группа для API - с middleware для метрик
 	apiGroup := engine.Group("/")
 	apiGroup.Use(metricsMiddleware)
 	{
 		apiGroup.GET("/", orderHandler.Index)
-		apiGroup.GET("/orders/:order_uid", orderHandler.GetOrderByUID)
-		apiGroup.GET("/health", orderHandler.HealthCheck)
+		apiGroup.POST("/graphql", authLimit, support, searchLimit, gin.WrapH(graphqlHandler))
+		apiGroup.GET("/livez", healthHandler.Livez)
+		apiGroup.GET("/readyz", healthHandler.Readyz)
+		// Старый маршрут проверки состояния оставлен для существующих проб
+		apiGroup.GET("/health", healthHandler.Livez)
 		apiGroup.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
+
+	for _, version := range versions {
+		versionGroup := engine.Group(version.Prefix, metricsMiddleware, handler.WithAPIVersion(version.Representation))
+		if version.Deprecated() {
+			versionGroup.Use(deprecation(version))
+		}
+		// Все маршруты версии требуют аутентификации
+		versionGroup.Use(authLimit)
+
+		versionGroup.GET("/orders/duplicates", staff, reportsLimit, orderHandler.GetDuplicateOrders)
+		versionGroup.GET("/orders/stream", staff, exportLimit, streamHandler.StreamOrders)
+		versionGroup.GET("/orders/export", staff, exportLimit, orderHandler.ExportOrders)
+		versionGroup.GET("/orders/:order_uid", anyRole, ordersLimit, orderHandler.GetOrderByUID)
+		versionGroup.POST("/orders:action", anyRole, batchLimit, customMethods("action", map[string]gin.HandlerFunc{
+			":batchGet": orderHandler.BatchGetOrders,
+		}))
+		versionGroup.GET("/stats/orders", staff, reportsLimit, statsHandler.GetOrderStats)
+		versionGroup.GET("/products/:nm_id/orders", staff, reportsLimit, productHandler.GetProductOrders)
+		versionGroup.GET("/products/:nm_id/stats", staff, reportsLimit, productHandler.GetProductStats)
+		versionGroup.GET("/brands/:brand/orders", staff, reportsLimit, productHandler.GetBrandOrders)
+		versionGroup.GET("/brands/:brand/stats", staff, reportsLimit, productHandler.GetBrandStats)
+		versionGroup.GET("/search", support, searchLimit, searchHandler.SearchOrders)
+	}
+}
+
+// batchGetCost возвращает количество заказов в пакетном запросе: лимит batch считается
+// в заказах, а не в запросах. Тело запроса сохраняется в контексте для обработчика
+func batchGetCost(c *gin.Context) int {
+	var req models.BatchGetOrdersRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		return 1
+	}
+	return len(req.OrderUIDs)
+}
+
+// customMethods выбирает обработчик пользовательского метода вида /orders:batchGet.
+// Gin считает ':' началом параметра пути и не поддерживает его экранирование, поэтому
+// маршрут "/orders:action" захватывает в параметр все после "/orders", включая двоеточие.
+// Для неизвестных методов возвращается 404
+func customMethods(param string, handlers map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h, ok := handlers[c.Param(param)]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+		h(c)
+	}
 }
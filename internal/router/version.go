@@ -0,0 +1,75 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/handler"
+)
+
+// Version - версия HTTP API. Маршруты API регистрируются для каждой версии со своим префиксом
+type Version struct {
+	Prefix         string    // префикс маршрутов: "/v1", "/v2"; "/" - маршруты без версии
+	Representation string    // представление заказов в ответах: handler.APIVersion1, handler.APIVersion2
+	DeprecatedAt   time.Time // дата, с которой версия устарела; нулевая - версия актуальна
+	Sunset         time.Time // дата отключения версии; нулевая - не объявлена
+	Successor      string    // префикс версии, на которую нужно перейти
+}
+
+// Deprecated сообщает, что версия устарела
+func (v Version) Deprecated() bool {
+	return !v.DeprecatedAt.IsZero()
+}
+
+// Versions возвращает версии API. Маршруты без префикса повторяют /v1 для существующих
+// клиентов и считаются устаревшими; /v2 отличается представлением заказов
+func Versions(cfg config.APIConfig) []Version {
+	return []Version{
+		{
+			Prefix:         "/",
+			Representation: handler.APIVersion1,
+			DeprecatedAt:   cfg.UnversionedDeprecatedAt,
+			Sunset:         cfg.UnversionedSunset,
+			Successor:      "/v1",
+		},
+		{
+			Prefix:         "/v1",
+			Representation: handler.APIVersion1,
+			DeprecatedAt:   cfg.V1DeprecatedAt,
+			Sunset:         cfg.V1Sunset,
+			Successor:      "/v2",
+		},
+		{
+			Prefix:         "/v2",
+			Representation: handler.APIVersion2,
+		},
+	}
+}
+
+// deprecation добавляет к ответам устаревшей версии заголовки Deprecation (RFC 9745),
+// Sunset (RFC 8594) и Link на тот же ресурс в версии-преемнике
+func deprecation(v Version) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("Deprecation", "@"+strconv.FormatInt(v.DeprecatedAt.Unix(), 10))
+		if !v.Sunset.IsZero() {
+			header.Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if v.Successor != "" {
+			header.Add("Link", "<"+successorPath(v, c.Request.URL.Path)+`>; rel="successor-version"`)
+		}
+		c.Next()
+	}
+}
+
+// successorPath возвращает путь ресурса в версии-преемнике
+func successorPath(v Version, path string) string {
+	if v.Prefix != "/" {
+		path = strings.TrimPrefix(path, v.Prefix)
+	}
+	return v.Successor + path
+}
@@ -0,0 +1,66 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveVersions(t *testing.T, versions []Version, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	for _, version := range versions {
+		group := engine.Group(version.Prefix)
+		if version.Deprecated() {
+			group.Use(deprecation(version))
+		}
+		group.GET("/orders/:order_uid", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestVersions_DeprecationHeaders(t *testing.T) {
+	deprecatedAt := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC)
+	versions := Versions(config.APIConfig{
+		UnversionedDeprecatedAt: deprecatedAt,
+		UnversionedSunset:       sunset,
+		V1DeprecatedAt:          deprecatedAt,
+	})
+
+	w := serveVersions(t, versions, "/orders/uid1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Mon, 19 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/orders/uid1>; rel="successor-version"`, w.Header().Get("Link"))
+
+	w = serveVersions(t, versions, "/v1/orders/uid1")
+	assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+	assert.Equal(t, `</v2/orders/uid1>; rel="successor-version"`, w.Header().Get("Link"))
+
+	w = serveVersions(t, versions, "/v2/orders/uid1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Link"))
+}
+
+func TestVersions_WithoutDates(t *testing.T) {
+	versions := Versions(config.APIConfig{})
+
+	assert.Len(t, versions, 3)
+	for _, version := range versions {
+		assert.False(t, version.Deprecated(), version.Prefix)
+	}
+	assert.Equal(t, handler.APIVersion2, versions[2].Representation)
+}
@@ -0,0 +1,159 @@
+// Package search готовит заказы к полнотекстовому и нечеткому поиску
+// и подсвечивает совпадения в найденных заказах
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// Поля заказа, по которым ведется поиск
+const (
+	FieldCustomerID      = "customer_id"
+	FieldTrackNumber     = "track_number"
+	FieldDeliveryName    = "delivery.name"
+	FieldDeliveryPhone   = "delivery.phone"
+	FieldDeliveryEmail   = "delivery.email"
+	FieldDeliveryCity    = "delivery.city"
+	FieldDeliveryAddress = "delivery.address"
+	FieldItemTrackNumber = "items.track_number"
+	FieldItemName        = "items.name"
+	FieldItemBrand       = "items.brand"
+)
+
+// MaxTerms - максимальное количество слов поискового запроса
+const MaxTerms = 8
+
+// similarityThreshold - минимальное триграммное сходство слова с запросом для подсветки,
+// совпадает с порогом pg_trgm.similarity_threshold по умолчанию
+const similarityThreshold = 0.3
+
+// Fields возвращает непустые текстовые поля заказа для поискового индекса.
+// Одинаковые значения полей товаров не повторяются
+func Fields(order *models.Order) []models.SearchField {
+	var fields []models.SearchField
+	seen := make(map[models.SearchField]struct{})
+	add := func(field, value string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		f := models.SearchField{Field: field, Value: value}
+		if _, ok := seen[f]; ok {
+			return
+		}
+		seen[f] = struct{}{}
+		fields = append(fields, f)
+	}
+
+	add(FieldCustomerID, order.CustomerID)
+	add(FieldTrackNumber, order.TrackNumber)
+	add(FieldDeliveryName, order.Delivery.Name)
+	add(FieldDeliveryPhone, order.Delivery.Phone)
+	add(FieldDeliveryEmail, order.Delivery.Email)
+	add(FieldDeliveryCity, order.Delivery.City)
+	add(FieldDeliveryAddress, order.Delivery.Address)
+	for _, item := range order.Items {
+		add(FieldItemTrackNumber, item.TrackNumber)
+		add(FieldItemName, item.Name)
+		add(FieldItemBrand, item.Brand)
+	}
+
+	return fields
+}
+
+// Terms разбивает поисковый запрос на слова в нижнем регистре, не больше MaxTerms
+func Terms(query string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > MaxTerms {
+		terms = terms[:MaxTerms]
+	}
+	return terms
+}
+
+// TSQuery строит запрос для to_tsquery, в котором каждое слово ищется как префикс
+func TSQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// Highlight возвращает поля, в которых есть слова, совпадающие с terms: содержащие
+// слово запроса или похожие на него по триграммам (опечатки). Совпавшие слова
+// обернуты в <mark></mark>, остальной текст экранирован для HTML
+func Highlight(fields []models.SearchField, terms []string) []models.SearchHighlight {
+	highlights := []models.SearchHighlight{}
+	for _, field := range fields {
+		if snippet, ok := highlightValue(field.Value, terms); ok {
+			highlights = append(highlights, models.SearchHighlight{Field: field.Field, Snippet: snippet})
+		}
+	}
+	return highlights
+}
+
+func highlightValue(value string, terms []string) (string, bool) {
+	var b strings.Builder
+	matched := false
+	runes := []rune(value)
+	for start := 0; start < len(runes); {
+		end := start
+		isWord := isWordRune(runes[start])
+		for end < len(runes) && isWordRune(runes[end]) == isWord {
+			end++
+		}
+		part := string(runes[start:end])
+		if isWord && matchesAny(strings.ToLower(part), terms) {
+			matched = true
+			b.WriteString("<mark>" + html.EscapeString(part) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(part))
+		}
+		start = end
+	}
+	return b.String(), matched
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.Contains(word, term) || Similarity(word, term) >= similarityThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Similarity считает триграммное сходство двух слов так же, как similarity в pg_trgm:
+// доля общих триграмм слов, дополненных двумя пробелами в начале и одним в конце
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+func trigrams(word string) map[string]struct{} {
+	runes := []rune("  " + strings.ToLower(word) + " ")
+	set := make(map[string]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields(t *testing.T) {
+	order := &models.Order{
+		CustomerID:  "test",
+		TrackNumber: "WBILMTESTTRACK",
+		Delivery:    models.Delivery{Name: "Test Testov", City: "Kiryat Mozkin", Address: " "},
+		Items: []models.Item{
+			{TrackNumber: "WBILMTESTTRACK", Name: "Mascaras", Brand: "Vivienne Sabo"},
+			{TrackNumber: "WBILMTESTTRACK", Name: "Lipstick", Brand: "Vivienne Sabo"},
+		},
+	}
+
+	assert.Equal(t, []models.SearchField{
+		{Field: FieldCustomerID, Value: "test"},
+		{Field: FieldTrackNumber, Value: "WBILMTESTTRACK"},
+		{Field: FieldDeliveryName, Value: "Test Testov"},
+		{Field: FieldDeliveryCity, Value: "Kiryat Mozkin"},
+		{Field: FieldItemTrackNumber, Value: "WBILMTESTTRACK"},
+		{Field: FieldItemName, Value: "Mascaras"},
+		{Field: FieldItemBrand, Value: "Vivienne Sabo"},
+		{Field: FieldItemName, Value: "Lipstick"},
+	}, Fields(order))
+}
+
+func TestTermsAndTSQuery(t *testing.T) {
+	terms := Terms("  Testov, Kiryat & <b>!")
+	assert.Equal(t, []string{"testov", "kiryat", "b"}, terms)
+	assert.Equal(t, "testov:* & kiryat:* & b:*", TSQuery(terms))
+	assert.Empty(t, Terms("&|!"))
+}
+
+func TestHighlight(t *testing.T) {
+	fields := []models.SearchField{
+		{Field: FieldDeliveryName, Value: "Test Testov"},
+		{Field: FieldDeliveryCity, Value: "Kiryat Mozkin"},
+		{Field: FieldDeliveryPhone, Value: "+9720000000"},
+		{Field: FieldItemName, Value: "Mascaras <new>"},
+	}
+
+	highlights := Highlight(fields, Terms("mozkn 0000"))
+	assert.Equal(t, []models.SearchHighlight{
+		{Field: FieldDeliveryCity, Snippet: "Kiryat <mark>Mozkin</mark>"},
+		{Field: FieldDeliveryPhone, Snippet: "+<mark>9720000000</mark>"},
+	}, highlights)
+
+	highlights = Highlight(fields, Terms("new"))
+	assert.Equal(t, []models.SearchHighlight{
+		{Field: FieldItemName, Snippet: "Mascaras &lt;<mark>new</mark>&gt;"},
+	}, highlights)
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("mozkin", "Mozkin"))
+	assert.Greater(t, Similarity("mozkin", "mozkn"), similarityThreshold)
+	assert.Less(t, Similarity("mozkin", "moscow"), similarityThreshold)
+	assert.Equal(t, 0.0, Similarity("", "mozkin"))
+}
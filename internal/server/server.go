@@ -3,24 +3,44 @@ package server
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"slices"
 	"time"
 
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/auth"
+	"github.com/shenikar/order-service/internal/compress"
+	"github.com/shenikar/order-service/internal/feed"
+	"github.com/shenikar/order-service/internal/graphqlapi"
 	"github.com/shenikar/order-service/internal/handler"
+	"github.com/shenikar/order-service/internal/health"
+	"github.com/shenikar/order-service/internal/logging"
 	"github.com/shenikar/order-service/internal/metrics"
+	"github.com/shenikar/order-service/internal/projection"
+	"github.com/shenikar/order-service/internal/ratelimit"
 	"github.com/shenikar/order-service/internal/router"
 	"github.com/shenikar/order-service/internal/service"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 var httpServer *http.Server
 
-func StartServer(cfg *config.Config, orderService *service.OrderService) {
-	r := gin.Default()
+func StartServer(cfg *config.Config, checker *health.Checker, authenticator *auth.Authenticator, piiPolicy *projection.Policy, rateLimits ratelimit.Limits, authLimiter *ratelimit.AuthLimiter, orderService *service.OrderService, statsService *service.StatsService, searchService *service.SearchService, orderFeed *feed.Hub) {
+	r, err := newEngine(cfg)
+	if err != nil {
+		logging.Fatal("Failed to configure HTTP server", "error", err)
+	}
+	r.Use(logging.RequestID(), gin.CustomRecoveryWithWriter(io.Discard, recoverPanic))
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(traced)))
+	r.Use(accessLog)
+	r.Use(compress.Middleware(compress.DefaultMinSize))
 
 	// Prometheus middleware
 	metricsMiddleware := func(c *gin.Context) {
@@ -39,14 +59,20 @@ func StartServer(cfg *config.Config, orderService *service.OrderService) {
 	}
 
 	// Создаем обработчик
-	orderHandler := handler.NewOrderHandler(orderService)
+	healthHandler := handler.NewHealthHandler(checker)
+	orderHandler := handler.NewOrderHandler(orderService, piiPolicy)
+	streamHandler := handler.NewStreamHandler(orderFeed)
+	statsHandler := handler.NewStatsHandler(statsService)
+	productHandler := handler.NewProductHandler(orderService, statsService, piiPolicy)
+	searchHandler := handler.NewSearchHandler(searchService)
+	graphqlHandler := graphqlapi.NewHandler(orderService)
 
 	// настраиваем маршруты
-	router.SetupRoutes(r, orderHandler, metricsMiddleware)
+	router.SetupRoutes(r, router.Versions(cfg.API), authenticator, rateLimits, authLimiter, healthHandler, orderHandler, streamHandler, statsHandler, productHandler, searchHandler, graphqlHandler, metricsMiddleware)
 
 	// запускаем сервер
 	addr := cfg.GetServerAddress()
-	log.Printf("Starting server on %s\n", addr)
+	slog.Info("Starting HTTP server", "addr", addr)
 
 	httpServer = &http.Server{
 		Addr:              addr,
@@ -55,17 +81,75 @@ func StartServer(cfg *config.Config, orderService *service.OrderService) {
 	}
 
 	go func() {
-		if err := httpServer.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("Failed to start server: %v", err)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Fatal("Failed to start HTTP server", "error", err)
 		}
 	}()
 }
 
+// newEngine создает gin.Engine, который доверяет X-Forwarded-For только прокси из
+// TRUSTED_PROXIES. По умолчанию gin доверяет любому прокси, и клиент мог бы подменять
+// свой IP, обходя ограничения частоты по IP-адресу
+func newEngine(cfg *config.Config) (*gin.Engine, error) {
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	return r, nil
+}
+
+// untracedPaths - служебные маршруты, которые опрашиваются постоянно и не трассируются
+var untracedPaths = []string{"/metrics", "/livez", "/readyz", "/health"}
+
+// traced сообщает, нужно ли трассировать запрос
+func traced(r *http.Request) bool {
+	return !slices.Contains(untracedPaths, r.URL.Path)
+}
+
+// accessLog пишет в журнал обработанный запрос вместе с клиентом, выполнившим запрос.
+// Ответы 4xx пишутся с уровнем warn, 5xx - error
+func accessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	status := c.Writer.Status()
+	attrs := []any{
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"route", c.FullPath(),
+		"status", status,
+		"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+		"bytes", c.Writer.Size(),
+		"client_ip", c.ClientIP(),
+	}
+	if identity, ok := auth.IdentityFromGin(c); ok {
+		attrs = append(attrs, "caller", identity.Subject)
+	}
+	if len(c.Errors) > 0 {
+		attrs = append(attrs, "errors", c.Errors.String())
+	}
+
+	level := slog.LevelInfo
+	switch {
+	case status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+	slog.Log(c.Request.Context(), level, "HTTP request", attrs...)
+}
+
+// recoverPanic пишет в журнал панику обработчика и отвечает 500
+func recoverPanic(c *gin.Context, err any) {
+	slog.ErrorContext(c.Request.Context(), "Panic in HTTP handler", "error", err, "stack", string(debug.Stack()))
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
 // ShutdownServer корректно завершает работу HTTP сервера
 func ShutdownServer(ctx context.Context) error {
 	if httpServer == nil {
 		return nil
 	}
-	log.Println("Shutting down server")
+	slog.Info("Shutting down HTTP server")
 	return httpServer.Shutdown(ctx)
 }
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shenikar/order-service/config"
+	"github.com/shenikar/order-service/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEngine_ClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limits := ratelimit.Limits{ratelimit.GroupOrders: {Rate: 0.1, Burst: 1}}
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		wantSecond     int
+	}{
+		{name: "spoofed X-Forwarded-For is ignored", remoteAddr: "10.0.0.1:40000", wantSecond: http.StatusTooManyRequests},
+		{name: "trusted proxy", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.1:40000", wantSecond: http.StatusOK},
+		{name: "untrusted proxy", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "192.168.0.1:40000", wantSecond: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newEngine(&config.Config{Server: config.ServerConfig{TrustedProxies: tt.trustedProxies}})
+			require.NoError(t, err)
+			r.GET("/orders/:order_uid", limits.Middleware(ratelimit.GroupOrders), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			do := func(forwardedFor string) int {
+				req := httptest.NewRequest(http.MethodGet, "/orders/b563feb7b2b84b6test", nil)
+				req.RemoteAddr = tt.remoteAddr
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				return w.Code
+			}
+			assert.Equal(t, http.StatusOK, do("203.0.113.1"))
+			assert.Equal(t, tt.wantSecond, do("203.0.113.2"))
+		})
+	}
+}
+
+func TestNewEngine_InvalidProxy(t *testing.T) {
+	_, err := newEngine(&config.Config{Server: config.ServerConfig{TrustedProxies: []string{"not-an-ip"}}})
+	assert.Error(t, err)
+}
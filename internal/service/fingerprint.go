@@ -0,0 +1,44 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// Fingerprint вычисляет канонический хэш содержимого заказа.
+// UID заказа не учитывается, порядок полей во входящем JSON и порядок товаров
+// на результат не влияют, дата создания приводится к UTC
+func Fingerprint(order *models.Order) (string, error) {
+	canonical := *order
+	canonical.OrderUID = ""
+	canonical.Fingerprint = ""
+	canonical.DuplicateOf = ""
+	canonical.DateCreated = order.DateCreated.UTC()
+
+	canonical.Items = make([]models.Item, len(order.Items))
+	copy(canonical.Items, order.Items)
+	for i := range canonical.Items {
+		canonical.Items[i].OrderUID = ""
+	}
+	sort.Slice(canonical.Items, func(i, j int) bool {
+		a, b := canonical.Items[i], canonical.Items[j]
+		if a.ChrtID != b.ChrtID {
+			return a.ChrtID < b.ChrtID
+		}
+		return a.Rid < b.Rid
+	})
+
+	// Поля структуры сериализуются в фиксированном порядке, поэтому результат детерминирован
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal order for fingerprint: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint_IgnoresOrderUIDAndFieldOrder(t *testing.T) {
+	first := []byte(`{"order_uid":"uid1","track_number":"WBILMTESTTRACK","customer_id":"test",
+		"date_created":"2021-11-26T06:22:19Z","payment":{"transaction":"tx1","amount":1817,"currency":"USD"},
+		"items":[{"chrt_id":1,"name":"Mascaras"},{"chrt_id":2,"name":"Lipstick"}]}`)
+	second := []byte(`{"items":[{"name":"Lipstick","chrt_id":2},{"name":"Mascaras","chrt_id":1}],
+		"payment":{"currency":"USD","amount":1817,"transaction":"tx1"},"date_created":"2021-11-26T09:22:19+03:00",
+		"customer_id":"test","track_number":"WBILMTESTTRACK","order_uid":"uid2"}`)
+
+	var a, b models.Order
+	assert.NoError(t, json.Unmarshal(first, &a))
+	assert.NoError(t, json.Unmarshal(second, &b))
+
+	fpA, err := Fingerprint(&a)
+	assert.NoError(t, err)
+	fpB, err := Fingerprint(&b)
+	assert.NoError(t, err)
+
+	assert.Equal(t, fpA, fpB)
+	// исходный порядок товаров не меняется
+	assert.Equal(t, 2, b.Items[0].ChrtID)
+}
+
+func TestFingerprint_DiffersOnContent(t *testing.T) {
+	a := &models.Order{OrderUID: "uid1", DateCreated: time.Unix(0, 0), Payment: models.Payment{Amount: 100}}
+	b := &models.Order{OrderUID: "uid1", DateCreated: time.Unix(0, 0), Payment: models.Payment{Amount: 101}}
+
+	fpA, err := Fingerprint(a)
+	assert.NoError(t, err)
+	fpB, err := Fingerprint(b)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, fpA, fpB)
+}
@@ -1,37 +1,282 @@
 package service
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/shenikar/order-service/internal/cache"
+	"github.com/shenikar/order-service/internal/metrics"
 	"github.com/shenikar/order-service/internal/models"
 	"github.com/shenikar/order-service/internal/repository"
+	"github.com/shenikar/order-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var validate = validator.New()
 
+var tracer = otel.Tracer("github.com/shenikar/order-service/internal/service")
+
+// Режимы обработки дублей по содержимому
+const (
+	DedupModeFlag = "flag" // сохранить заказ с пометкой duplicate_of
+	DedupModeSkip = "skip" // не сохранять заказ
+)
+
+// ErrDuplicateOrder возвращается при сохранении дубля в режиме DedupModeSkip
+var ErrDuplicateOrder = errors.New("duplicate order")
+
+// saveAttempts - количество попыток сохранения, если одновременно сохранили заказ с тем же содержимым
+const saveAttempts = 3
+
+// fingerprintBackfillBatch - количество заказов, отпечатки которых заполняются за один запрос
+const fingerprintBackfillBatch = 500
+
+// MaxBatchGetUIDs - максимальное количество UID в одном пакетном запросе заказов
+const MaxBatchGetUIDs = 1000
+
+// ErrInvalidBatch возвращается, если список UID пакетного запроса пуст или слишком длинный
+var ErrInvalidBatch = errors.New("invalid batch")
+
 type OrderService struct {
-	repo  repository.OrderRepositoryInterface
-	cache *cache.Cache // Добавляем кэш для оптимизации
+	repo      repository.OrderRepositoryInterface
+	cache     *cache.Cache // Добавляем кэш для оптимизации
+	dedupMode string
+
+	warmupMu   sync.RWMutex
+	warmupDone bool  // восстановление кэша из БД завершено
+	warmupErr  error // ошибка восстановления кэша
+}
+
+// Option настраивает OrderService
+type Option func(*OrderService)
+
+// WithDedupMode задает режим обработки дублей по содержимому
+func WithDedupMode(mode string) Option {
+	return func(s *OrderService) {
+		s.dedupMode = mode
+	}
 }
 
 // NewOrderService создает новый экземпляр OrderService
-func NewOrderService(repo repository.OrderRepositoryInterface, cache *cache.Cache) *OrderService {
-	return &OrderService{
-		repo:  repo,
-		cache: cache,
+func NewOrderService(repo repository.OrderRepositoryInterface, cache *cache.Cache, opts ...Option) *OrderService {
+	s := &OrderService{
+		repo:      repo,
+		cache:     cache,
+		dedupMode: DedupModeFlag,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // SaveOrder сохраняет заказ
 func (s *OrderService) SaveOrder(order *models.Order) error {
 	// Сохраняем заказ в БД
-	if err := s.repo.SaveOrder(order); err != nil {
+	err := s.saveDeduplicated(func() error {
+		if err := s.checkDuplicate(order); err != nil {
+			return err
+		}
+		return s.repo.SaveOrder(order)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.reloadItems(order)
+}
+
+// SaveOrderWithOffset сохраняет заказ вместе с позицией чтения Kafka в одной транзакции
+func (s *OrderService) SaveOrderWithOffset(ctx context.Context, order *models.Order, offset models.KafkaOffset) (err error) {
+	ctx, span := tracer.Start(ctx, "order.save", trace.WithAttributes(attribute.String("order.uid", order.OrderUID)))
+	defer func() { tracing.End(span, err) }()
+
+	err = s.saveDeduplicated(func() error {
+		if err := s.checkDuplicate(order); err != nil {
+			return err
+		}
+		return s.repo.SaveOrderWithOffset(ctx, order, offset)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.reloadItems(order)
+}
+
+// ImportOrders сохраняет пачку исторических заказов одной транзакцией без событий OrderAccepted.
+// Возвращает ошибки по заказам
+// в порядке orders (nil для сохраненных): в режиме DedupModeSkip дубли не сохраняются
+// и получают ErrDuplicateOrder. Ошибка транзакции возвращается вторым значением
+func (s *OrderService) ImportOrders(orders []*models.Order) (orderErrs []error, err error) {
+	err = s.saveDeduplicated(func() error {
+		orderErrs, err = s.importOrders(orders)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderErrs, nil
+}
+
+// importOrders проверяет пачку заказов на дубли и сохраняет ее одной транзакцией
+func (s *OrderService) importOrders(orders []*models.Order) ([]error, error) {
+	orderErrs := make([]error, len(orders))
+	toSave := make([]*models.Order, 0, len(orders))
+	// Дубли внутри пачки не видны FindDuplicate до фиксации транзакции
+	batchFingerprints := make(map[string]string, len(orders))
+
+	for i, order := range orders {
+		if err := s.checkDuplicate(order); err != nil {
+			if !errors.Is(err, ErrDuplicateOrder) {
+				return nil, err
+			}
+			orderErrs[i] = err
+			continue
+		}
+
+		if first, ok := batchFingerprints[order.Fingerprint]; ok && order.DuplicateOf == "" {
+			if s.dedupMode == DedupModeSkip {
+				metrics.OrderDuplicatesTotal.WithLabelValues("skipped").Inc()
+				orderErrs[i] = fmt.Errorf("%w: order %s duplicates %s", ErrDuplicateOrder, order.OrderUID, first)
+				continue
+			}
+			metrics.OrderDuplicatesTotal.WithLabelValues("flagged").Inc()
+			order.DuplicateOf = first
+		} else if !ok {
+			batchFingerprints[order.Fingerprint] = order.OrderUID
+		}
+		toSave = append(toSave, order)
+	}
+
+	if err := s.repo.ImportOrders(toSave); err != nil {
+		return nil, err
+	}
+
+	return orderErrs, nil
+}
+
+// SaveOffset сохраняет позицию чтения Kafka для сообщения без заказа
+func (s *OrderService) SaveOffset(offset models.KafkaOffset) error {
+	return s.repo.SaveOffset(offset)
+}
+
+// GetOffsets возвращает сохраненные позиции чтения партиций топика
+func (s *OrderService) GetOffsets(topic string) ([]models.KafkaOffset, error) {
+	return s.repo.GetOffsets(topic)
+}
+
+// GetDuplicateGroups возвращает группы заказов с общей платежной транзакцией
+func (s *OrderService) GetDuplicateGroups(limit int) ([]models.DuplicateGroup, error) {
+	return s.repo.GetDuplicateGroups(limit)
+}
+
+// saveDeduplicated выполняет проверку на дубли и сохранение save. Если заказ с тем же содержимым
+// сохранили одновременно, уникальный индекс отклоняет транзакцию и save повторяется: теперь
+// сохраненный заказ виден FindDuplicate, и новый будет помечен или пропущен как дубль
+func (s *OrderService) saveDeduplicated(save func() error) error {
+	var err error
+	for range saveAttempts {
+		err = save()
+		if !errors.Is(err, repository.ErrFingerprintConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// BackfillFingerprints заполняет отпечатки заказов, сохраненных до появления поиска дублей,
+// от старых к новым и помечает дублями заказы, совпавшие по содержимому с ранее обработанными.
+// Возвращает количество обработанных заказов
+func (s *OrderService) BackfillFingerprints(ctx context.Context) (int, error) {
+	var after *models.OrderCursor
+	processed := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+
+		orders, err := s.repo.GetOrdersWithoutFingerprint(after, fingerprintBackfillBatch)
+		if err != nil {
+			return processed, err
+		}
+
+		for i := range orders {
+			if err := s.backfillFingerprint(&orders[i]); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+
+		if len(orders) < fingerprintBackfillBatch {
+			return processed, nil
+		}
+		last := orders[len(orders)-1]
+		after = &models.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID}
+	}
+}
+
+// backfillFingerprint сохраняет отпечаток одного заказа из базы
+func (s *OrderService) backfillFingerprint(order *models.Order) error {
+	fingerprint, err := Fingerprint(order)
+	if err != nil {
 		return err
 	}
 
-	// Получаем items для кэширования
+	var duplicateOf string
+	err = s.saveDeduplicated(func() (err error) {
+		duplicateOf, err = s.repo.SetFingerprint(order.OrderUID, fingerprint)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if duplicateOf != "" {
+		metrics.OrderDuplicatesTotal.WithLabelValues("flagged").Inc()
+		slog.Info("Order flagged as duplicate", "order_uid", order.OrderUID, "duplicate_of", duplicateOf)
+	}
+	return nil
+}
+
+// checkDuplicate вычисляет отпечаток заказа и ищет ранее сохраненный заказ с тем же содержимым.
+// В режиме DedupModeSkip возвращает ErrDuplicateOrder, в режиме DedupModeFlag помечает заказ
+func (s *OrderService) checkDuplicate(order *models.Order) error {
+	fingerprint, err := Fingerprint(order)
+	if err != nil {
+		return err
+	}
+	order.Fingerprint = fingerprint
+	// Пометку дубля определяет только сервис, значение из входных данных не используется
+	order.DuplicateOf = ""
+
+	duplicateOf, err := s.repo.FindDuplicate(order.OrderUID, fingerprint)
+	if err != nil {
+		return err
+	}
+	if duplicateOf == "" {
+		return nil
+	}
+
+	if s.dedupMode == DedupModeSkip {
+		metrics.OrderDuplicatesTotal.WithLabelValues("skipped").Inc()
+		return fmt.Errorf("%w: order %s duplicates %s", ErrDuplicateOrder, order.OrderUID, duplicateOf)
+	}
+
+	metrics.OrderDuplicatesTotal.WithLabelValues("flagged").Inc()
+	slog.Info("Order flagged as duplicate", "order_uid", order.OrderUID, "duplicate_of", duplicateOf)
+	order.DuplicateOf = duplicateOf
+	return nil
+}
+
+// reloadItems загружает сохраненные items заказа для кэширования
+func (s *OrderService) reloadItems(order *models.Order) error {
 	items, err := s.repo.GetItemByOrderUID(order.OrderUID)
 	if err != nil {
 		return err
@@ -42,11 +287,15 @@ func (s *OrderService) SaveOrder(order *models.Order) error {
 }
 
 // GetOrderByUID извлекает заказ из кэша или БД
-func (s *OrderService) GetOrderByUID(orderUID string) (*models.Order, error) {
+func (s *OrderService) GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error) {
 	// проверяем кэш
-	if order, found := s.cache.Get(orderUID); found {
-		log.Printf("Order %s retrieved from cache", orderUID)
-		return &order, nil
+	_, span := tracer.Start(ctx, "order.cache_lookup", trace.WithAttributes(attribute.String("order.uid", orderUID)))
+	cached, found := s.cache.Get(orderUID)
+	span.SetAttributes(attribute.Bool("cache.hit", found))
+	span.End()
+	if found {
+		slog.DebugContext(ctx, "Order retrieved from cache", "order_uid", orderUID)
+		return &cached, nil
 	}
 
 	// если нет в кэше, извлекаем из БД
@@ -64,13 +313,179 @@ func (s *OrderService) GetOrderByUID(orderUID string) (*models.Order, error) {
 
 	// Обновляем кэш
 	s.cache.Set(*order)
-	log.Printf("Order %s retrieved from database", orderUID)
+	slog.DebugContext(ctx, "Order retrieved from database", "order_uid", orderUID)
 
 	return order, nil
 }
 
-// RestoreCacheFromDB восстанавливает кэш из БД
-func (s *OrderService) RestoreCacheFromDB() error {
+// GetOrdersByUIDs возвращает заказы по списку UID: сначала из кэша, а промахи - из БД
+// одним запросом. Повторы UID отбрасываются, заказы возвращаются в порядке запроса.
+// missing - UID, которых нет ни в кэше, ни в БД
+func (s *OrderService) GetOrdersByUIDs(ctx context.Context, orderUIDs []string) (found []models.Order, missing []string, err error) {
+	uids := make([]string, 0, len(orderUIDs))
+	seen := make(map[string]struct{}, len(orderUIDs))
+	for _, uid := range orderUIDs {
+		if uid == "" {
+			return nil, nil, fmt.Errorf("%w: empty order uid", ErrInvalidBatch)
+		}
+		if _, ok := seen[uid]; ok {
+			continue
+		}
+		seen[uid] = struct{}{}
+		uids = append(uids, uid)
+	}
+	if len(uids) == 0 {
+		return nil, nil, fmt.Errorf("%w: no order uids", ErrInvalidBatch)
+	}
+	if len(uids) > MaxBatchGetUIDs {
+		return nil, nil, fmt.Errorf("%w: more than %d order uids", ErrInvalidBatch, MaxBatchGetUIDs)
+	}
+
+	_, span := tracer.Start(ctx, "order.cache_lookup", trace.WithAttributes(attribute.Int("order.count", len(uids))))
+	orders := make(map[string]models.Order, len(uids))
+	var misses []string
+	for _, uid := range uids {
+		if order, ok := s.cache.Get(uid); ok {
+			orders[uid] = order
+		} else {
+			misses = append(misses, uid)
+		}
+	}
+	span.SetAttributes(attribute.Int("cache.misses", len(misses)))
+	span.End()
+
+	if len(misses) > 0 {
+		fromDB, err := s.repo.GetOrdersByUIDs(misses)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, order := range fromDB {
+			orders[order.OrderUID] = order
+			s.cache.Set(order)
+		}
+		slog.DebugContext(ctx, "Batch get", "from_cache", len(uids)-len(misses),
+			"from_database", len(fromDB), "missing", len(misses)-len(fromDB))
+	}
+
+	found = make([]models.Order, 0, len(orders))
+	missing = []string{}
+	for _, uid := range uids {
+		if order, ok := orders[uid]; ok {
+			found = append(found, order)
+		} else {
+			missing = append(missing, uid)
+		}
+	}
+	return found, missing, nil
+}
+
+// ListOrders возвращает страницу заказов с товарами, подходящих под filter, от новых к старым,
+// и токен следующей страницы (пустой, если страниц больше нет)
+func (s *OrderService) ListOrders(filter models.OrderFilter, pageToken string, pageSize int) ([]models.Order, string, error) {
+	orders, nextPageToken, err := s.ListOrdersWithoutItems(filter, pageToken, pageSize)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.attachItems(orders); err != nil {
+		return nil, "", err
+	}
+	return orders, nextPageToken, nil
+}
+
+// ListOrdersWithoutItems работает как ListOrders, но не загружает товары заказов.
+// Товары можно догрузить для всей страницы через GetItemsByOrderUIDs
+func (s *OrderService) ListOrdersWithoutItems(filter models.OrderFilter, pageToken string, pageSize int) ([]models.Order, string, error) {
+	after, err := DecodePageToken(pageToken)
+	if err != nil {
+		return nil, "", err
+	}
+	pageSize = normalizePageSize(pageSize)
+
+	// Запрашиваем на один заказ больше, чтобы узнать, есть ли следующая страница
+	orders, err := s.repo.ListOrders(filter, after, pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(orders) <= pageSize {
+		return orders, "", nil
+	}
+
+	orders = orders[:pageSize]
+	last := orders[pageSize-1]
+	return orders, EncodePageToken(models.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID}), nil
+}
+
+// StreamOrders передает в fn все заказы, подходящие под filter, от новых к старым.
+// Заказы читаются из БД страницами, чтение прерывается при отмене ctx или ошибке fn
+func (s *OrderService) StreamOrders(ctx context.Context, filter models.OrderFilter, fn func(order *models.Order) error) error {
+	var after *models.OrderCursor
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		orders, err := s.repo.ListOrders(filter, after, MaxPageSize)
+		if err != nil {
+			return err
+		}
+		if err := s.attachItems(orders); err != nil {
+			return err
+		}
+		for i := range orders {
+			if err := fn(&orders[i]); err != nil {
+				return err
+			}
+		}
+		if len(orders) < MaxPageSize {
+			return nil
+		}
+
+		last := orders[len(orders)-1]
+		after = &models.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID}
+	}
+}
+
+// ExportOrders передает в fn все заказы с товарами, подходящие под filter, от новых к старым.
+// В отличие от StreamOrders заказы читаются одним серверным курсором из согласованного снимка БД
+func (s *OrderService) ExportOrders(ctx context.Context, filter models.OrderFilter, fn func(order *models.Order) error) error {
+	return s.repo.ExportOrders(ctx, filter, fn)
+}
+
+// GetItemsByOrderUIDs возвращает товары нескольких заказов, ключ - UID заказа
+func (s *OrderService) GetItemsByOrderUIDs(orderUIDs []string) (map[string][]models.Item, error) {
+	return s.repo.GetItemsByOrderUIDs(orderUIDs)
+}
+
+// attachItems загружает товары для всех заказов одним запросом
+func (s *OrderService) attachItems(orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	uids := make([]string, len(orders))
+	for i, order := range orders {
+		uids[i] = order.OrderUID
+	}
+
+	items, err := s.repo.GetItemsByOrderUIDs(uids)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		orders[i].Items = items[orders[i].OrderUID]
+	}
+
+	return nil
+}
+
+// RestoreCacheFromDB восстанавливает кэш из БД. Итог восстановления доступен через CacheWarmupStatus
+func (s *OrderService) RestoreCacheFromDB() (err error) {
+	defer func() {
+		s.warmupMu.Lock()
+		s.warmupDone, s.warmupErr = true, err
+		s.warmupMu.Unlock()
+	}()
+
 	orders, err := s.repo.GetAllOrders()
 	if err != nil {
 		return err
@@ -88,11 +503,14 @@ func (s *OrderService) RestoreCacheFromDB() error {
 	return nil
 }
 
-func (s *OrderService) ValidateOrder(order *models.Order) bool {
-	err := validate.Struct(order)
-	if err != nil {
-		log.Printf("Invalid order %s: %v", order.OrderUID, err)
-		return false
-	}
-	return true
+// CacheWarmupStatus сообщает, завершено ли восстановление кэша из БД, и его ошибку
+func (s *OrderService) CacheWarmupStatus() (bool, error) {
+	s.warmupMu.RLock()
+	defer s.warmupMu.RUnlock()
+	return s.warmupDone, s.warmupErr
+}
+
+// Validate проверяет заказ и возвращает описание нарушенных правил
+func (s *OrderService) Validate(order *models.Order) error {
+	return validate.Struct(order)
 }
@@ -1,22 +1,41 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"testing"
 	"time"
 
 	"github.com/shenikar/order-service/internal/cache"
 	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // mockRepo реализует интерфейс OrderRepository
 type mockRepo struct {
-	saveOrder func(order *models.Order) error
-	getByUID  func(uid string) (*models.Order, error)
-	getItems  func(uid string) ([]models.Item, error)
-	getAll    func() ([]models.Order, error)
+	saveOrder           func(order *models.Order) error
+	importOrders        func(orders []*models.Order) error
+	saveOrderWithOffset func(order *models.Order, offset models.KafkaOffset) error
+	saveOffset          func(offset models.KafkaOffset) error
+	getOffsets          func(topic string) ([]models.KafkaOffset, error)
+	getByUID            func(uid string) (*models.Order, error)
+	getItems            func(uid string) ([]models.Item, error)
+	getAll              func() ([]models.Order, error)
+	listOrders          func(filter models.OrderFilter, after *models.OrderCursor, limit int) ([]models.Order, error)
+	getItemsByUIDs      func(uids []string) (map[string][]models.Item, error)
+	getOrdersByUIDs     func(uids []string) ([]models.Order, error)
+	exportOrders        func(ctx context.Context, filter models.OrderFilter, fn func(order *models.Order) error) error
+	findDuplicate       func(uid, fingerprint string) (string, error)
+	getWithoutFP        func(after *models.OrderCursor, limit int) ([]models.Order, error)
+	setFingerprint      func(uid, fingerprint string) (string, error)
+	getDuplicateGroups  func(limit int) ([]models.DuplicateGroup, error)
 }
 
 func (m *mockRepo) SaveOrder(order *models.Order) error {
@@ -26,6 +45,34 @@ func (m *mockRepo) SaveOrder(order *models.Order) error {
 	return nil
 }
 
+func (m *mockRepo) ImportOrders(orders []*models.Order) error {
+	if m.importOrders != nil {
+		return m.importOrders(orders)
+	}
+	return nil
+}
+
+func (m *mockRepo) SaveOrderWithOffset(_ context.Context, order *models.Order, offset models.KafkaOffset) error {
+	if m.saveOrderWithOffset != nil {
+		return m.saveOrderWithOffset(order, offset)
+	}
+	return nil
+}
+
+func (m *mockRepo) SaveOffset(offset models.KafkaOffset) error {
+	if m.saveOffset != nil {
+		return m.saveOffset(offset)
+	}
+	return nil
+}
+
+func (m *mockRepo) GetOffsets(topic string) ([]models.KafkaOffset, error) {
+	if m.getOffsets != nil {
+		return m.getOffsets(topic)
+	}
+	return nil, nil
+}
+
 func (m *mockRepo) GetOrderByUID(uid string) (*models.Order, error) {
 	if m.getByUID != nil {
 		return m.getByUID(uid)
@@ -47,6 +94,62 @@ func (m *mockRepo) GetAllOrders() ([]models.Order, error) {
 	return nil, nil
 }
 
+func (m *mockRepo) ListOrders(filter models.OrderFilter, after *models.OrderCursor, limit int) ([]models.Order, error) {
+	if m.listOrders != nil {
+		return m.listOrders(filter, after, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) GetItemsByOrderUIDs(uids []string) (map[string][]models.Item, error) {
+	if m.getItemsByUIDs != nil {
+		return m.getItemsByUIDs(uids)
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) GetOrdersByUIDs(uids []string) ([]models.Order, error) {
+	if m.getOrdersByUIDs != nil {
+		return m.getOrdersByUIDs(uids)
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) ExportOrders(ctx context.Context, filter models.OrderFilter, fn func(order *models.Order) error) error {
+	if m.exportOrders != nil {
+		return m.exportOrders(ctx, filter, fn)
+	}
+	return nil
+}
+
+func (m *mockRepo) FindDuplicate(uid, fingerprint string) (string, error) {
+	if m.findDuplicate != nil {
+		return m.findDuplicate(uid, fingerprint)
+	}
+	return "", nil
+}
+
+func (m *mockRepo) GetOrdersWithoutFingerprint(after *models.OrderCursor, limit int) ([]models.Order, error) {
+	if m.getWithoutFP != nil {
+		return m.getWithoutFP(after, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) SetFingerprint(uid, fingerprint string) (string, error) {
+	if m.setFingerprint != nil {
+		return m.setFingerprint(uid, fingerprint)
+	}
+	return "", nil
+}
+
+func (m *mockRepo) GetDuplicateGroups(limit int) ([]models.DuplicateGroup, error) {
+	if m.getDuplicateGroups != nil {
+		return m.getDuplicateGroups(limit)
+	}
+	return nil, nil
+}
+
 func TestSaveOrder_Success(t *testing.T) {
 	repo := &mockRepo{
 		saveOrder: func(order *models.Order) error { return nil },
@@ -85,6 +188,185 @@ func TestSaveOrder_RepoError(t *testing.T) {
 	assert.Equal(t, "db error", err.Error())
 }
 
+func TestSaveOrder_DuplicateFlagged(t *testing.T) {
+	var saved *models.Order
+	repo := &mockRepo{
+		findDuplicate: func(uid, fingerprint string) (string, error) {
+			assert.NotEmpty(t, fingerprint)
+			return "uid_original", nil
+		},
+		saveOrder: func(order *models.Order) error {
+			saved = order
+			return nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+
+	err = svc.SaveOrder(&models.Order{OrderUID: "uid_copy"})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, saved)
+	assert.Equal(t, "uid_original", saved.DuplicateOf)
+	assert.NotEmpty(t, saved.Fingerprint)
+}
+
+func TestSaveOrder_DuplicateSkipped(t *testing.T) {
+	repo := &mockRepo{
+		findDuplicate: func(uid, fingerprint string) (string, error) {
+			return "uid_original", nil
+		},
+		saveOrder: func(order *models.Order) error {
+			t.Fatal("duplicate must not be saved")
+			return nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c, WithDedupMode(DedupModeSkip))
+
+	err = svc.SaveOrder(&models.Order{OrderUID: "uid_copy"})
+
+	assert.ErrorIs(t, err, ErrDuplicateOrder)
+}
+
+func TestSaveOrder_ConcurrentDuplicate(t *testing.T) {
+	// Копия заказа сохранена одновременно: первая попытка отклонена уникальным индексом,
+	// повторная проверка находит сохраненный заказ
+	lookups, saves := 0, 0
+	repo := &mockRepo{
+		findDuplicate: func(uid, fingerprint string) (string, error) {
+			lookups++
+			if lookups == 1 {
+				return "", nil
+			}
+			return "uid_original", nil
+		},
+		saveOrderWithOffset: func(order *models.Order, offset models.KafkaOffset) error {
+			saves++
+			if saves == 1 {
+				return fmt.Errorf("failed to save order: %w", repository.ErrFingerprintConflict)
+			}
+			assert.Equal(t, "uid_original", order.DuplicateOf)
+			return nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+
+	err = svc.SaveOrderWithOffset(context.Background(), &models.Order{OrderUID: "uid_copy"}, models.KafkaOffset{Topic: "orders"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, saves)
+}
+
+func TestSaveOrder_ConflictRetriesExhausted(t *testing.T) {
+	saves := 0
+	repo := &mockRepo{
+		saveOrder: func(order *models.Order) error {
+			saves++
+			return repository.ErrFingerprintConflict
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+
+	err = svc.SaveOrder(&models.Order{OrderUID: "uid_copy"})
+
+	assert.ErrorIs(t, err, repository.ErrFingerprintConflict)
+	assert.Equal(t, saveAttempts, saves)
+}
+
+func TestBackfillFingerprints(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	orders := make([]models.Order, fingerprintBackfillBatch+1)
+	for i := range orders {
+		orders[i] = models.Order{
+			OrderUID:    fmt.Sprintf("uid%04d", i),
+			DateCreated: base.Add(time.Duration(i) * time.Minute),
+		}
+	}
+
+	var cursors []*models.OrderCursor
+	fingerprints := map[string]string{}
+	repo := &mockRepo{
+		getWithoutFP: func(after *models.OrderCursor, limit int) ([]models.Order, error) {
+			cursors = append(cursors, after)
+			if after == nil {
+				return orders[:limit], nil
+			}
+			return orders[limit:], nil
+		},
+		setFingerprint: func(uid, fingerprint string) (string, error) {
+			fingerprints[uid] = fingerprint
+			return "", nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+
+	processed, err := svc.BackfillFingerprints(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), processed)
+	assert.Len(t, fingerprints, len(orders))
+	assert.Len(t, cursors, 2)
+	last := orders[fingerprintBackfillBatch-1]
+	assert.Equal(t, &models.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID}, cursors[1])
+
+	// Заказы с одинаковым содержимым получают одинаковый отпечаток, что и при сохранении
+	want, err := Fingerprint(&models.Order{OrderUID: "other", DateCreated: orders[0].DateCreated})
+	assert.NoError(t, err)
+	assert.Equal(t, want, fingerprints["uid0000"])
+}
+
+func TestSaveOrderWithOffset_Success(t *testing.T) {
+	var savedOffset models.KafkaOffset
+	repo := &mockRepo{
+		saveOrderWithOffset: func(order *models.Order, offset models.KafkaOffset) error {
+			savedOffset = offset
+			return nil
+		},
+		getItems: func(uid string) ([]models.Item, error) {
+			return []models.Item{{ChrtID: 1, TrackNumber: "TN123"}}, nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+
+	order := &models.Order{OrderUID: "uid123"}
+	offset := models.KafkaOffset{Topic: "orders", Partition: 1, NextOffset: 43}
+	err = svc.SaveOrderWithOffset(context.Background(), order, offset)
+
+	assert.NoError(t, err)
+	assert.Equal(t, offset, savedOffset)
+	assert.Len(t, order.Items, 1)
+}
+
+func TestSaveOrderWithOffset_RepoError(t *testing.T) {
+	repo := &mockRepo{
+		saveOrderWithOffset: func(order *models.Order, offset models.KafkaOffset) error {
+			return errors.New("db error")
+		},
+		getItems: func(uid string) ([]models.Item, error) {
+			t.Fatal("items must not be loaded after failed save")
+			return nil, nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+
+	err = svc.SaveOrderWithOffset(context.Background(), &models.Order{OrderUID: "uid123"}, models.KafkaOffset{Topic: "orders"})
+
+	assert.EqualError(t, err, "db error")
+}
+
 func TestGetOrderByUID_FromCache(t *testing.T) {
 	repo := &mockRepo{}
 	c, err := cache.NewCache(100, time.Minute*5)
@@ -95,7 +377,7 @@ func TestGetOrderByUID_FromCache(t *testing.T) {
 	expected := models.Order{OrderUID: "uid123"}
 	c.Set(expected)
 
-	order, err := svc.GetOrderByUID("uid123")
+	order, err := svc.GetOrderByUID(context.Background(), "uid123")
 
 	assert.NoError(t, err)
 	assert.Equal(t, "uid123", order.OrderUID)
@@ -114,7 +396,7 @@ func TestGetOrderByUID_FromDB(t *testing.T) {
 	assert.NoError(t, err)
 	svc := NewOrderService(repo, c)
 
-	order, err := svc.GetOrderByUID("uid456")
+	order, err := svc.GetOrderByUID(context.Background(), "uid456")
 
 	assert.NoError(t, err)
 	assert.Equal(t, "uid456", order.OrderUID)
@@ -127,6 +409,35 @@ func TestGetOrderByUID_FromDB(t *testing.T) {
 	assert.Equal(t, "uid456", cached.OrderUID)
 }
 
+func TestGetOrderByUID_CacheLookupSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	repo := &mockRepo{
+		getByUID: func(uid string) (*models.Order, error) {
+			return &models.Order{OrderUID: uid}, nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "GET /v1/orders/:order_uid")
+	_, err = svc.GetOrderByUID(ctx, "uid789")
+	assert.NoError(t, err)
+	_, err = svc.GetOrderByUID(ctx, "uid789")
+	assert.NoError(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+	for i, hit := range []bool{false, true} {
+		assert.Equal(t, "order.cache_lookup", spans[i].Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[i].Parent().SpanID())
+		assert.Contains(t, spans[i].Attributes(), attribute.Bool("cache.hit", hit))
+	}
+}
+
 func TestGetOrderByUID_DBError(t *testing.T) {
 	repo := &mockRepo{
 		getByUID: func(uid string) (*models.Order, error) {
@@ -137,13 +448,76 @@ func TestGetOrderByUID_DBError(t *testing.T) {
 	assert.NoError(t, err)
 	svc := NewOrderService(repo, c)
 
-	order, err := svc.GetOrderByUID("bad_uid")
+	order, err := svc.GetOrderByUID(context.Background(), "bad_uid")
 
 	assert.Nil(t, order)
 	assert.Error(t, err)
 	assert.Equal(t, "not found", err.Error())
 }
 
+func TestGetOrdersByUIDs(t *testing.T) {
+	var requested []string
+	repo := &mockRepo{
+		getOrdersByUIDs: func(uids []string) ([]models.Order, error) {
+			requested = uids
+			return []models.Order{{OrderUID: "uid3", Items: []models.Item{{ChrtID: 3}}}}, nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+	c.Set(models.Order{OrderUID: "uid1"})
+
+	found, missing, err := svc.GetOrdersByUIDs(context.Background(), []string{"uid3", "uid1", "uid2", "uid3"})
+
+	assert.NoError(t, err)
+	// в БД запрашиваются только промахи кэша, без повторов
+	assert.Equal(t, []string{"uid3", "uid2"}, requested)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "uid3", found[0].OrderUID)
+	assert.Equal(t, "uid1", found[1].OrderUID)
+	assert.Equal(t, []string{"uid2"}, missing)
+
+	cached, ok := c.Get("uid3")
+	assert.True(t, ok)
+	assert.Len(t, cached.Items, 1)
+}
+
+func TestGetOrdersByUIDs_AllCached(t *testing.T) {
+	repo := &mockRepo{
+		getOrdersByUIDs: func(uids []string) ([]models.Order, error) {
+			t.Fatal("repository must not be called")
+			return nil, nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+	c.Set(models.Order{OrderUID: "uid1"})
+
+	found, missing, err := svc.GetOrdersByUIDs(context.Background(), []string{"uid1"})
+
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Empty(t, missing)
+}
+
+func TestGetOrdersByUIDs_InvalidBatch(t *testing.T) {
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(&mockRepo{}, c)
+
+	tooMany := make([]string, MaxBatchGetUIDs+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("uid%d", i)
+	}
+
+	for _, uids := range [][]string{nil, {""}, tooMany} {
+		_, _, err := svc.GetOrdersByUIDs(context.Background(), uids)
+		assert.ErrorIs(t, err, ErrInvalidBatch)
+	}
+}
+
 func TestRestoreCacheFromDB(t *testing.T) {
 	repo := &mockRepo{
 		getAll: func() ([]models.Order, error) {
@@ -160,8 +534,14 @@ func TestRestoreCacheFromDB(t *testing.T) {
 	assert.NoError(t, err)
 	svc := NewOrderService(repo, c)
 
+	done, _ := svc.CacheWarmupStatus()
+	assert.False(t, done)
+
 	err = svc.RestoreCacheFromDB()
 
+	assert.NoError(t, err)
+	done, err = svc.CacheWarmupStatus()
+	assert.True(t, done)
 	assert.NoError(t, err)
 
 	// проверяем, что оба заказа оказались в кэше
@@ -173,3 +553,99 @@ func TestRestoreCacheFromDB(t *testing.T) {
 	assert.Equal(t, "uid111", o1.OrderUID)
 	assert.Equal(t, "uid222", o2.OrderUID)
 }
+
+func TestListOrders_Pagination(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var calls []*models.OrderCursor
+	repo := &mockRepo{
+		listOrders: func(filter models.OrderFilter, after *models.OrderCursor, limit int) ([]models.Order, error) {
+			calls = append(calls, after)
+			assert.Equal(t, "test", filter.CustomerID)
+			assert.Equal(t, 3, limit)
+			if after == nil {
+				return []models.Order{
+					{OrderUID: "uid3", DateCreated: base.Add(3 * time.Hour)},
+					{OrderUID: "uid2", DateCreated: base.Add(2 * time.Hour)},
+					{OrderUID: "uid1", DateCreated: base.Add(time.Hour)},
+				}, nil
+			}
+			return []models.Order{{OrderUID: "uid1", DateCreated: base.Add(time.Hour)}}, nil
+		},
+		getItemsByUIDs: func(uids []string) (map[string][]models.Item, error) {
+			items := make(map[string][]models.Item)
+			for _, uid := range uids {
+				items[uid] = []models.Item{{ChrtID: 1, TrackNumber: "TN_" + uid}}
+			}
+			return items, nil
+		},
+	}
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(repo, c)
+	filter := models.OrderFilter{CustomerID: "test"}
+
+	page, token, err := svc.ListOrders(filter, "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, "TN_uid3", page[0].Items[0].TrackNumber)
+
+	page, token, err = svc.ListOrders(filter, token, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Empty(t, token)
+
+	assert.Len(t, calls, 2)
+	assert.Equal(t, "uid2", calls[1].OrderUID)
+	assert.True(t, calls[1].DateCreated.Equal(base.Add(2*time.Hour)))
+}
+
+func TestListOrders_InvalidToken(t *testing.T) {
+	c, err := cache.NewCache(100, time.Minute*5)
+	assert.NoError(t, err)
+	svc := NewOrderService(&mockRepo{}, c)
+
+	_, _, err = svc.ListOrders(models.OrderFilter{}, "not-a-token", 10)
+
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+}
+
+func TestSaveOrders_DuplicatesInBatch(t *testing.T) {
+	newOrder := func(uid string) *models.Order {
+		return &models.Order{OrderUID: uid, CustomerID: "test", Payment: models.Payment{Transaction: "tx1"}}
+	}
+
+	for _, tc := range []struct {
+		mode        string
+		wantSaved   int
+		wantSkipped bool
+	}{
+		{mode: DedupModeFlag, wantSaved: 2},
+		{mode: DedupModeSkip, wantSaved: 1, wantSkipped: true},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			var saved []*models.Order
+			repo := &mockRepo{
+				importOrders: func(orders []*models.Order) error {
+					saved = orders
+					return nil
+				},
+			}
+			c, err := cache.NewCache(100, time.Minute*5)
+			assert.NoError(t, err)
+			svc := NewOrderService(repo, c, WithDedupMode(tc.mode))
+
+			orderErrs, err := svc.ImportOrders([]*models.Order{newOrder("uid1"), newOrder("uid2")})
+
+			assert.NoError(t, err)
+			assert.Len(t, saved, tc.wantSaved)
+			assert.NoError(t, orderErrs[0])
+			if tc.wantSkipped {
+				assert.ErrorIs(t, orderErrs[1], ErrDuplicateOrder)
+			} else {
+				assert.NoError(t, orderErrs[1])
+				assert.Equal(t, "uid1", saved[1].DuplicateOf)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/shenikar/order-service/internal/models"
+)
+
+// Размеры страницы при постраничном чтении заказов
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// ErrInvalidPageToken возвращается, если токен страницы не удалось разобрать
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// EncodePageToken кодирует позицию чтения в непрозрачный токен страницы
+func EncodePageToken(cursor models.OrderCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePageToken разбирает токен страницы, пустой токен означает первую страницу
+func DecodePageToken(token string) (*models.OrderCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+
+	var cursor models.OrderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+	if cursor.OrderUID == "" {
+		return nil, fmt.Errorf("%w: empty order uid", ErrInvalidPageToken)
+	}
+
+	return &cursor, nil
+}
+
+// normalizePageSize приводит размер страницы к допустимому диапазону
+func normalizePageSize(pageSize int) int {
+	if pageSize <= 0 {
+		return DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		return MaxPageSize
+	}
+	return pageSize
+}
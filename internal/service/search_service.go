@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+	"github.com/shenikar/order-service/internal/search"
+)
+
+// Ограничения количества результатов поиска
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// ErrEmptySearchQuery возвращается, если в поисковом запросе нет ни одного слова
+var ErrEmptySearchQuery = errors.New("empty search query")
+
+// SearchService ищет заказы по покупателю, получателю, трек-номерам и товарам
+type SearchService struct {
+	repo repository.SearchRepositoryInterface
+}
+
+// NewSearchService создает новый экземпляр SearchService
+func NewSearchService(repo repository.SearchRepositoryInterface) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+// SearchOrders возвращает до limit заказов, подходящих под запрос, от наиболее релевантных,
+// с подсвеченными совпадениями в полях заказа
+func (s *SearchService) SearchOrders(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+	if limit <= 0 || limit > MaxSearchLimit {
+		limit = DefaultSearchLimit
+	}
+
+	hits, err := s.repo.SearchOrders(ctx, terms, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, models.SearchResult{
+			OrderUID:    hit.OrderUID,
+			DateCreated: hit.DateCreated,
+			Rank:        hit.Rank,
+			Highlights:  search.Highlight(hit.Fields, terms),
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSearchRepo struct {
+	terms []string
+	limit int
+	hits  []models.SearchHit
+}
+
+func (m *mockSearchRepo) SearchOrders(_ context.Context, terms []string, limit int) ([]models.SearchHit, error) {
+	m.terms = terms
+	m.limit = limit
+	return m.hits, nil
+}
+
+func TestSearchOrders_Highlights(t *testing.T) {
+	repo := &mockSearchRepo{hits: []models.SearchHit{{
+		OrderUID: "uid1",
+		Rank:     0.5,
+		Fields: []models.SearchField{
+			{Field: "delivery.name", Value: "Test Testov"},
+			{Field: "delivery.city", Value: "Kiryat Mozkin"},
+		},
+	}}}
+	svc := NewSearchService(repo)
+
+	results, err := svc.SearchOrders(context.Background(), "Mozkn", 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"mozkn"}, repo.terms)
+	assert.Equal(t, DefaultSearchLimit, repo.limit)
+	require.Len(t, results, 1)
+	assert.Equal(t, []models.SearchHighlight{{Field: "delivery.city", Snippet: "Kiryat <mark>Mozkin</mark>"}}, results[0].Highlights)
+}
+
+func TestSearchOrders_EmptyQuery(t *testing.T) {
+	_, err := NewSearchService(&mockSearchRepo{}).SearchOrders(context.Background(), " ,.! ", 10)
+	assert.ErrorIs(t, err, ErrEmptySearchQuery)
+}
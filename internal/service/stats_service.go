@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/shenikar/order-service/internal/repository"
+)
+
+// ErrInvalidStatsQuery возвращается для некорректных параметров статистики
+var ErrInvalidStatsQuery = errors.New("invalid stats query")
+
+// ErrNoSales возвращается, если у товара или бренда нет продаж за период
+var ErrNoSales = errors.New("no sales found")
+
+// statsDimensions - допустимые измерения группировки
+var statsDimensions = []string{
+	models.StatsByDay,
+	models.StatsByHour,
+	models.StatsByCurrency,
+	models.StatsByDeliveryService,
+	models.StatsByBank,
+	models.StatsByProvider,
+	models.StatsByRegion,
+	models.StatsByBrand,
+}
+
+// StatsService считает агрегаты заказов. Если включено фоновое обновление
+// почасовых срезов, запросы с границами по часу считаются по срезам
+type StatsService struct {
+	repo            repository.StatsRepositoryInterface
+	refreshInterval time.Duration
+
+	mu          sync.RWMutex
+	refreshedAt time.Time
+}
+
+// NewStatsService создает новый экземпляр StatsService.
+// refreshInterval <= 0 отключает срезы: статистика всегда считается по таблицам заказов
+func NewStatsService(repo repository.StatsRepositoryInterface, refreshInterval time.Duration) *StatsService {
+	return &StatsService{
+		repo:            repo,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// Run обновляет почасовые срезы каждые refreshInterval до отмены ctx
+func (s *StatsService) Run(ctx context.Context) {
+	if s.refreshInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		s.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *StatsService) refresh(ctx context.Context) {
+	if err := s.repo.RefreshStatsRollups(ctx); err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to refresh stats rollups", "error", err)
+		}
+		return
+	}
+
+	s.mu.Lock()
+	s.refreshedAt = time.Now().UTC()
+	s.mu.Unlock()
+}
+
+// GetOrderStats возвращает агрегаты заказов, сгруппированные по query.GroupBy.
+// Суммы разных валют не складываются, поэтому валюта всегда входит в группировку
+func (s *StatsService) GetOrderStats(ctx context.Context, query models.StatsQuery) (*models.OrderStatsReport, error) {
+	groupBy, err := normalizeGroupBy(query.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	query.GroupBy = groupBy
+
+	if !query.CreatedFrom.IsZero() && !query.CreatedTo.IsZero() && !query.CreatedFrom.Before(query.CreatedTo) {
+		return nil, fmt.Errorf("%w: created_from must be before created_to", ErrInvalidStatsQuery)
+	}
+
+	if refreshedAt, ok := s.rollupRefreshedAt(); ok && hourAligned(query.CreatedFrom) && hourAligned(query.CreatedTo) {
+		rows, err := s.repo.GetOrderStatsRollup(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return &models.OrderStatsReport{Source: models.StatsSourceRollup, RefreshedAt: &refreshedAt, Rows: rows}, nil
+	}
+
+	rows, err := s.repo.GetOrderStats(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OrderStatsReport{Source: models.StatsSourceLive, Rows: rows}, nil
+}
+
+// GetProductStats возвращает продажи товара (query.NmID) или бренда (query.Brand)
+func (s *StatsService) GetProductStats(ctx context.Context, query models.ProductStatsQuery) (*models.ProductStats, error) {
+	if (query.NmID > 0) == (query.Brand != "") || query.NmID < 0 {
+		return nil, fmt.Errorf("%w: either nm_id or brand is required", ErrInvalidStatsQuery)
+	}
+	if !query.CreatedFrom.IsZero() && !query.CreatedTo.IsZero() && !query.CreatedFrom.Before(query.CreatedTo) {
+		return nil, fmt.Errorf("%w: created_from must be before created_to", ErrInvalidStatsQuery)
+	}
+
+	stats, err := s.repo.GetProductStats(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Units == 0 {
+		return nil, ErrNoSales
+	}
+	return stats, nil
+}
+
+// rollupRefreshedAt возвращает время последнего обновления срезов, если они обновлялись
+func (s *StatsService) rollupRefreshedAt() (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.refreshedAt, !s.refreshedAt.IsZero()
+}
+
+// normalizeGroupBy проверяет измерения, убирает повторы и добавляет валюту
+func normalizeGroupBy(groupBy []string) ([]string, error) {
+	normalized := make([]string, 0, len(groupBy)+1)
+	for _, dimension := range groupBy {
+		if !slices.Contains(statsDimensions, dimension) {
+			return nil, fmt.Errorf("%w: unknown group_by %q", ErrInvalidStatsQuery, dimension)
+		}
+		if !slices.Contains(normalized, dimension) {
+			normalized = append(normalized, dimension)
+		}
+	}
+	if slices.Contains(normalized, models.StatsByDay) && slices.Contains(normalized, models.StatsByHour) {
+		return nil, fmt.Errorf("%w: group_by day and hour are mutually exclusive", ErrInvalidStatsQuery)
+	}
+	if !slices.Contains(normalized, models.StatsByCurrency) {
+		normalized = append(normalized, models.StatsByCurrency)
+	}
+	return normalized, nil
+}
+
+func hourAligned(t time.Time) bool {
+	return t.Equal(t.Truncate(time.Hour))
+}
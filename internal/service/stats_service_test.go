@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shenikar/order-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStatsRepo struct {
+	liveQueries   []models.StatsQuery
+	rollupQueries []models.StatsQuery
+	refreshErr    error
+
+	productQueries []models.ProductStatsQuery
+	productUnits   int64
+}
+
+func (m *mockStatsRepo) GetOrderStats(_ context.Context, query models.StatsQuery) ([]models.OrderStats, error) {
+	m.liveQueries = append(m.liveQueries, query)
+	return []models.OrderStats{{Currency: "RUB", Orders: 1}}, nil
+}
+
+func (m *mockStatsRepo) GetOrderStatsRollup(_ context.Context, query models.StatsQuery) ([]models.OrderStats, error) {
+	m.rollupQueries = append(m.rollupQueries, query)
+	return []models.OrderStats{{Currency: "RUB", Orders: 1}}, nil
+}
+
+func (m *mockStatsRepo) RefreshStatsRollups(context.Context) error {
+	return m.refreshErr
+}
+
+func (m *mockStatsRepo) GetProductStats(_ context.Context, query models.ProductStatsQuery) (*models.ProductStats, error) {
+	m.productQueries = append(m.productQueries, query)
+	return &models.ProductStats{NmID: query.NmID, Brand: query.Brand, Units: m.productUnits}, nil
+}
+
+func TestGetOrderStats_GroupByAlwaysIncludesCurrency(t *testing.T) {
+	repo := &mockStatsRepo{}
+	svc := NewStatsService(repo, 0)
+
+	report, err := svc.GetOrderStats(context.Background(), models.StatsQuery{GroupBy: []string{"day", "bank", "day"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.StatsSourceLive, report.Source)
+	require.Len(t, repo.liveQueries, 1)
+	assert.Equal(t, []string{"day", "bank", "currency"}, repo.liveQueries[0].GroupBy)
+}
+
+func TestGetOrderStats_InvalidQuery(t *testing.T) {
+	svc := NewStatsService(&mockStatsRepo{}, 0)
+	now := time.Now()
+
+	for _, query := range []models.StatsQuery{
+		{GroupBy: []string{"week"}},
+		{GroupBy: []string{"day", "hour"}},
+		{CreatedFrom: now, CreatedTo: now.Add(-time.Hour)},
+	} {
+		_, err := svc.GetOrderStats(context.Background(), query)
+		assert.ErrorIs(t, err, ErrInvalidStatsQuery)
+	}
+}
+
+func TestGetOrderStats_UsesRollupsForHourAlignedRange(t *testing.T) {
+	repo := &mockStatsRepo{}
+	svc := NewStatsService(repo, time.Minute)
+	svc.refresh(context.Background())
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	report, err := svc.GetOrderStats(context.Background(), models.StatsQuery{CreatedFrom: from, CreatedTo: from.Add(24 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatsSourceRollup, report.Source)
+	assert.NotNil(t, report.RefreshedAt)
+
+	// Граница не по часу: срезы дали бы лишние заказы
+	report, err = svc.GetOrderStats(context.Background(), models.StatsQuery{CreatedFrom: from.Add(30 * time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatsSourceLive, report.Source)
+
+	assert.Len(t, repo.rollupQueries, 1)
+	assert.Len(t, repo.liveQueries, 1)
+}
+
+func TestGetOrderStats_LiveUntilRollupsRefreshed(t *testing.T) {
+	repo := &mockStatsRepo{refreshErr: assert.AnError}
+	svc := NewStatsService(repo, time.Minute)
+	svc.refresh(context.Background())
+
+	report, err := svc.GetOrderStats(context.Background(), models.StatsQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatsSourceLive, report.Source)
+}
+
+func TestGetProductStats(t *testing.T) {
+	repo := &mockStatsRepo{productUnits: 3}
+	svc := NewStatsService(repo, 0)
+
+	stats, err := svc.GetProductStats(context.Background(), models.ProductStatsQuery{NmID: 2389212})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Units)
+
+	_, err = svc.GetProductStats(context.Background(), models.ProductStatsQuery{NmID: 1, Brand: "Vivienne Sabo"})
+	assert.ErrorIs(t, err, ErrInvalidStatsQuery)
+	_, err = svc.GetProductStats(context.Background(), models.ProductStatsQuery{})
+	assert.ErrorIs(t, err, ErrInvalidStatsQuery)
+	assert.Len(t, repo.productQueries, 1)
+
+	repo.productUnits = 0
+	_, err = svc.GetProductStats(context.Background(), models.ProductStatsQuery{Brand: "Vivienne Sabo"})
+	assert.ErrorIs(t, err, ErrNoSales)
+}
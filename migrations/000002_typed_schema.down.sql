@@ -0,0 +1,50 @@
+-- Удаление внешних ключей
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_order_uid_fkey;
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_order_uid_fkey;
+ALTER TABLE deliveries DROP CONSTRAINT IF EXISTS deliveries_order_uid_fkey;
+
+-- Число минорных единиц в основной единице валюты: 10 в степени масштаба по ISO 4217,
+-- как в models.CurrencyScale
+CREATE OR REPLACE FUNCTION pg_temp.minor_units_factor(currency TEXT) RETURNS BIGINT
+    LANGUAGE sql IMMUTABLE AS $$
+    SELECT CASE
+        WHEN upper(currency) IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW',
+            'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN upper(currency) IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        WHEN upper(currency) IN ('CLF', 'UYW') THEN 10000
+        ELSE 100
+    END
+$$;
+
+-- Возврат сумм в основные единицы валюты; дробная часть округляется
+UPDATE items i
+SET price       = round(i.price::NUMERIC / pg_temp.minor_units_factor(p.currency)),
+    total_price = round(i.total_price::NUMERIC / pg_temp.minor_units_factor(p.currency))
+FROM payments p
+WHERE p.order_uid = i.order_uid;
+
+UPDATE payments
+SET amount        = round(amount::NUMERIC / pg_temp.minor_units_factor(currency)),
+    delivery_cost = round(delivery_cost::NUMERIC / pg_temp.minor_units_factor(currency)),
+    goods_total   = round(goods_total::NUMERIC / pg_temp.minor_units_factor(currency)),
+    custom_fee    = round(custom_fee::NUMERIC / pg_temp.minor_units_factor(currency));
+
+DROP FUNCTION pg_temp.minor_units_factor(TEXT);
+
+-- Возврат исходных типов колонок
+ALTER TABLE items
+    DROP CONSTRAINT IF EXISTS items_amounts_non_negative,
+    ALTER COLUMN order_uid DROP NOT NULL,
+    ALTER COLUMN price TYPE INTEGER,
+    ALTER COLUMN total_price TYPE INTEGER;
+
+ALTER TABLE payments
+    DROP CONSTRAINT IF EXISTS payments_amounts_non_negative,
+    ALTER COLUMN amount TYPE INTEGER,
+    ALTER COLUMN delivery_cost TYPE INTEGER,
+    ALTER COLUMN goods_total TYPE INTEGER,
+    ALTER COLUMN custom_fee TYPE INTEGER;
+
+ALTER TABLE orders
+    ALTER COLUMN date_created TYPE TEXT
+        USING to_char(date_created AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"');
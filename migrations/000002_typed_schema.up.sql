@@ -0,0 +1,88 @@
+-- "Осиротевшие" записи не дают создать внешние ключи. Данные в миграции не удаляются:
+-- если такие записи есть, миграция останавливается, и их нужно разобрать вручную
+DO $$
+DECLARE
+    orphans BIGINT;
+BEGIN
+    SELECT (SELECT COUNT(*) FROM deliveries d
+             WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE o.order_uid = d.order_uid))
+         + (SELECT COUNT(*) FROM payments p
+             WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE o.order_uid = p.order_uid))
+         + (SELECT COUNT(*) FROM items i
+             WHERE i.order_uid IS NULL
+                OR NOT EXISTS (SELECT 1 FROM orders o WHERE o.order_uid = i.order_uid))
+      INTO orphans;
+    IF orphans > 0 THEN
+        RAISE EXCEPTION 'found % deliveries, payments or items without an order', orphans
+            USING HINT = 'Move or delete rows without a matching orders.order_uid before applying this migration';
+    END IF;
+END
+$$;
+
+-- Число минорных единиц в основной единице валюты: 10 в степени масштаба по ISO 4217,
+-- как в models.CurrencyScale
+CREATE OR REPLACE FUNCTION pg_temp.minor_units_factor(currency TEXT) RETURNS BIGINT
+    LANGUAGE sql IMMUTABLE AS $$
+    SELECT CASE
+        WHEN upper(currency) IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW',
+            'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN upper(currency) IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        WHEN upper(currency) IN ('CLF', 'UYW') THEN 10000
+        ELSE 100
+    END
+$$;
+
+-- Дата создания заказа как timestamptz
+ALTER TABLE orders
+    ALTER COLUMN date_created TYPE TIMESTAMPTZ USING NULLIF(date_created, '')::TIMESTAMPTZ;
+
+-- Денежные суммы хранятся в минорных единицах валюты (копейки, центы и т.д.),
+-- масштаб определяется кодом валюты по ISO 4217
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE BIGINT,
+    ALTER COLUMN delivery_cost TYPE BIGINT,
+    ALTER COLUMN goods_total TYPE BIGINT,
+    ALTER COLUMN custom_fee TYPE BIGINT,
+    ADD CONSTRAINT payments_amounts_non_negative
+        CHECK (amount >= 0 AND delivery_cost >= 0 AND goods_total >= 0 AND custom_fee >= 0);
+
+ALTER TABLE items
+    ALTER COLUMN order_uid SET NOT NULL,
+    ALTER COLUMN price TYPE BIGINT,
+    ALTER COLUMN total_price TYPE BIGINT,
+    ADD CONSTRAINT items_amounts_non_negative CHECK (price >= 0 AND total_price >= 0);
+
+-- Существующие суммы записаны в основных единицах валюты (рубли, доллары и т.д.)
+UPDATE payments
+SET amount        = amount * pg_temp.minor_units_factor(currency),
+    delivery_cost = delivery_cost * pg_temp.minor_units_factor(currency),
+    goods_total   = goods_total * pg_temp.minor_units_factor(currency),
+    custom_fee    = custom_fee * pg_temp.minor_units_factor(currency);
+
+UPDATE items i
+SET price       = i.price * pg_temp.minor_units_factor(p.currency),
+    total_price = i.total_price * pg_temp.minor_units_factor(p.currency)
+FROM payments p
+WHERE p.order_uid = i.order_uid;
+
+DROP FUNCTION pg_temp.minor_units_factor(TEXT);
+
+COMMENT ON COLUMN payments.amount IS 'Сумма в минорных единицах валюты (масштаб по ISO 4217)';
+COMMENT ON COLUMN payments.delivery_cost IS 'Стоимость доставки в минорных единицах валюты';
+COMMENT ON COLUMN payments.goods_total IS 'Стоимость товаров в минорных единицах валюты';
+COMMENT ON COLUMN payments.custom_fee IS 'Таможенный сбор в минорных единицах валюты';
+COMMENT ON COLUMN items.price IS 'Цена в минорных единицах валюты заказа';
+COMMENT ON COLUMN items.total_price IS 'Итоговая цена в минорных единицах валюты заказа';
+
+-- Внешние ключи с каскадным удалением
+ALTER TABLE deliveries
+    ADD CONSTRAINT deliveries_order_uid_fkey
+        FOREIGN KEY (order_uid) REFERENCES orders (order_uid) ON DELETE CASCADE;
+
+ALTER TABLE payments
+    ADD CONSTRAINT payments_order_uid_fkey
+        FOREIGN KEY (order_uid) REFERENCES orders (order_uid) ON DELETE CASCADE;
+
+ALTER TABLE items
+    ADD CONSTRAINT items_order_uid_fkey
+        FOREIGN KEY (order_uid) REFERENCES orders (order_uid) ON DELETE CASCADE;
@@ -0,0 +1,8 @@
+-- Позиции чтения Kafka, фиксируемые в одной транзакции с заказом
+CREATE TABLE IF NOT EXISTS kafka_offsets (
+    topic TEXT NOT NULL,
+    partition INTEGER NOT NULL,
+    next_offset BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (topic, partition)
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_payments_transaction;
+DROP INDEX IF EXISTS idx_orders_fingerprint;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS duplicate_of,
+    DROP COLUMN IF EXISTS fingerprint;
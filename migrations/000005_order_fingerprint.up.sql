@@ -0,0 +1,7 @@
+-- Отпечаток содержимого заказа для поиска дублей
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS fingerprint TEXT,
+    ADD COLUMN IF NOT EXISTS duplicate_of TEXT REFERENCES orders (order_uid) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_fingerprint ON orders(fingerprint);
+CREATE INDEX IF NOT EXISTS idx_payments_transaction ON payments(transaction);
@@ -0,0 +1,2 @@
+DROP MATERIALIZED VIEW IF EXISTS order_brand_stats_hourly;
+DROP MATERIALIZED VIEW IF EXISTS order_stats_hourly;
@@ -0,0 +1,49 @@
+-- Почасовые срезы статистики заказов для GET /stats/orders.
+-- Обновляются сервисом в фоне (REFRESH MATERIALIZED VIEW CONCURRENTLY),
+-- дубли по содержимому (duplicate_of) в статистику не входят
+CREATE MATERIALIZED VIEW IF NOT EXISTS order_stats_hourly AS
+SELECT date_trunc('hour', o.date_created, 'UTC') AS bucket,
+       COALESCE(p.currency, '') AS currency,
+       COALESCE(o.delivery_service, '') AS delivery_service,
+       COALESCE(p.bank, '') AS bank,
+       COALESCE(p.provider, '') AS provider,
+       COALESCE(d.region, '') AS region,
+       COUNT(*) AS orders,
+       SUM(p.amount) AS amount,
+       SUM(p.goods_total) AS goods_total,
+       SUM(p.delivery_cost) AS delivery_cost,
+       SUM(ic.items) AS items
+FROM orders o
+JOIN payments p ON p.order_uid = o.order_uid
+JOIN deliveries d ON d.order_uid = o.order_uid
+CROSS JOIN LATERAL (SELECT COUNT(*) AS items FROM items i WHERE i.order_uid = o.order_uid) ic
+WHERE o.duplicate_of IS NULL AND o.date_created IS NOT NULL
+GROUP BY 1, 2, 3, 4, 5, 6;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_stats_hourly
+    ON order_stats_hourly (bucket, currency, delivery_service, bank, provider, region);
+
+-- Заказ учитывается в срезе каждого бренда, товары которого в нем есть
+CREATE MATERIALIZED VIEW IF NOT EXISTS order_brand_stats_hourly AS
+SELECT date_trunc('hour', o.date_created, 'UTC') AS bucket,
+       COALESCE(p.currency, '') AS currency,
+       COALESCE(o.delivery_service, '') AS delivery_service,
+       COALESCE(p.bank, '') AS bank,
+       COALESCE(p.provider, '') AS provider,
+       COALESCE(d.region, '') AS region,
+       ob.brand,
+       COUNT(*) AS orders,
+       SUM(p.amount) AS amount,
+       SUM(p.goods_total) AS goods_total,
+       SUM(p.delivery_cost) AS delivery_cost,
+       SUM(ic.items) AS items
+FROM orders o
+JOIN payments p ON p.order_uid = o.order_uid
+JOIN deliveries d ON d.order_uid = o.order_uid
+CROSS JOIN LATERAL (SELECT COUNT(*) AS items FROM items i WHERE i.order_uid = o.order_uid) ic
+CROSS JOIN LATERAL (SELECT DISTINCT COALESCE(i.brand, '') AS brand FROM items i WHERE i.order_uid = o.order_uid) ob
+WHERE o.duplicate_of IS NULL AND o.date_created IS NOT NULL
+GROUP BY 1, 2, 3, 4, 5, 6, 7;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_brand_stats_hourly
+    ON order_brand_stats_hourly (bucket, currency, delivery_service, bank, provider, region, brand);
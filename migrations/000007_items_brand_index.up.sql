@@ -0,0 +1,2 @@
+-- Поиск заказов и статистика продаж по бренду
+CREATE INDEX IF NOT EXISTS idx_items_brand ON items(brand);
@@ -0,0 +1,45 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Текстовые поля заказа для поиска (GET /search): fields - пары поле/значение
+-- для подсветки совпадений, document - значения через пробел для индексов
+CREATE TABLE IF NOT EXISTS order_search (
+    order_uid TEXT PRIMARY KEY REFERENCES orders (order_uid) ON DELETE CASCADE,
+    fields JSONB NOT NULL,
+    document TEXT NOT NULL,
+    tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', document)) STORED
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_search_tsv ON order_search USING GIN (tsv);
+CREATE INDEX IF NOT EXISTS idx_order_search_document_trgm ON order_search USING GIN (document gin_trgm_ops);
+
+-- Заполняем поиск для уже сохраненных заказов
+INSERT INTO order_search (order_uid, fields, document)
+SELECT s.order_uid, s.fields, COALESCE((SELECT string_agg(e->>'value', ' ') FROM jsonb_array_elements(s.fields) e), '')
+FROM (
+    SELECT o.order_uid, (
+        SELECT COALESCE(jsonb_agg(jsonb_build_object('field', v.field, 'value', v.value) ORDER BY v.pos, v.value), '[]'::jsonb)
+        FROM (
+            SELECT f.field, btrim(f.value) AS value, MIN(f.pos) AS pos
+            FROM (
+                VALUES (1, 'customer_id', o.customer_id),
+                       (2, 'track_number', o.track_number),
+                       (3, 'delivery.name', d.name),
+                       (4, 'delivery.phone', d.phone),
+                       (5, 'delivery.email', d.email),
+                       (6, 'delivery.city', d.city),
+                       (7, 'delivery.address', d.address)
+                UNION ALL
+                SELECT 8, 'items.track_number', i.track_number FROM items i WHERE i.order_uid = o.order_uid
+                UNION ALL
+                SELECT 9, 'items.name', i.name FROM items i WHERE i.order_uid = o.order_uid
+                UNION ALL
+                SELECT 10, 'items.brand', i.brand FROM items i WHERE i.order_uid = o.order_uid
+            ) AS f (pos, field, value)
+            WHERE btrim(f.value) <> ''
+            GROUP BY 1, 2
+        ) v
+    ) AS fields
+    FROM orders o
+    JOIN deliveries d ON d.order_uid = o.order_uid
+) s
+ON CONFLICT (order_uid) DO NOTHING;
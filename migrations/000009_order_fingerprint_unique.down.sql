@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_without_fingerprint;
+DROP INDEX IF EXISTS idx_orders_fingerprint_original;
@@ -0,0 +1,22 @@
+-- Заказы с одинаковым содержимым, сохраненные одновременно и поэтому не помеченные дублями,
+-- помечаются дублями самого раннего из них
+UPDATE orders o
+SET duplicate_of = original.order_uid
+FROM (
+    SELECT DISTINCT ON (fingerprint) fingerprint, order_uid
+    FROM orders
+    WHERE fingerprint IS NOT NULL AND duplicate_of IS NULL
+    ORDER BY fingerprint, date_created, order_uid
+) original
+WHERE o.fingerprint = original.fingerprint
+  AND o.duplicate_of IS NULL
+  AND o.order_uid <> original.order_uid;
+
+-- Для одного содержимого может быть только один заказ без пометки дубля: из двух одновременно
+-- сохраняемых копий вторая получит ошибку и будет проверена на дубль повторно
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_fingerprint_original
+    ON orders(fingerprint) WHERE duplicate_of IS NULL;
+
+-- Заказы, сохраненные до появления отпечатков, заполняются фоновой задачей сервиса
+CREATE INDEX IF NOT EXISTS idx_orders_without_fingerprint
+    ON orders(date_created, order_uid) WHERE fingerprint IS NULL;